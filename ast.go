@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"golox/parser"
+	"golox/printer"
+	"golox/scanner"
+	"os"
+)
+
+// runAst prints the syntax tree of a script, either in parenthesized prefix
+// form or, with -json, as JSON for editors and other tools.
+func runAst(args []string) error {
+	flags := flag.NewFlagSet("ast", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the tree as JSON")
+	flags.Usage = usage
+	parseFlags(flags, args)
+	if flags.NArg() != 1 {
+		usage()
+		os.Exit(EX_USAGE)
+	}
+	content, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return ioError("failed to read file: %v", err)
+	}
+
+	tokens, scanErrors := scanner.NewScanner(string(content)).Scan()
+	statements, parseErrors := parser.NewParser(tokens).Parse()
+	if errors := append(scanErrors, parseErrors...); len(errors) > 0 {
+		printErrors(flags.Arg(0), string(content), errors)
+		fail(EX_DATAERR)
+		return nil
+	}
+	if !*asJSON {
+		fmt.Print(printer.NewAstPrinter().Print(statements))
+		return nil
+	}
+	data, err := printer.ToJSON(statements)
+	if err != nil {
+		return &statusError{status: EX_SOFTWARE, err: fmt.Errorf("failed to encode syntax tree: %v", err)}
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"golox/compiler"
+	"golox/expr"
+	"golox/interpreter"
+	"golox/stmt"
+	"golox/vm"
+)
+
+// Backend executes a parsed and resolved program, read from filename, and
+// returns every error it ran into, along with the exit status they amount
+// to: EX_DATAERR when the program could not be compiled and EX_SOFTWARE when
+// it failed at runtime. filename names the file in stack traces.
+type Backend interface {
+	Execute(filename string, statements []stmt.Stmt, locals map[expr.Expr]int) (int, []error)
+}
+
+func NewBackend(name string) (Backend, error) {
+	switch name {
+	case "ast":
+		return &treeWalker{interpreter: interpreter.NewInterpreter()}, nil
+	case "vm":
+		return &bytecodeVM{vm: vm.NewVM()}, nil
+	}
+	return nil, fmt.Errorf("unknown backend %q", name)
+}
+
+// treeWalker evaluates the syntax tree directly.
+type treeWalker struct {
+	interpreter *interpreter.Interpreter
+}
+
+func (b *treeWalker) Execute(filename string, statements []stmt.Stmt, locals map[expr.Expr]int) (int, []error) {
+	b.interpreter.SetFile(filename)
+	b.interpreter.Resolve(locals)
+	b.interpreter.Interpret(statements)
+	errors := []error{}
+	for _, statement := range b.interpreter.Results {
+		if statement.Err != nil {
+			errors = append(errors, statement.Err)
+		}
+	}
+	if len(errors) > 0 {
+		return EX_SOFTWARE, errors
+	}
+	return EX_OK, nil
+}
+
+// bytecodeVM compiles the syntax tree to bytecode and runs it on the stack
+// machine. The compiler does its own scope analysis, so locals are unused.
+type bytecodeVM struct {
+	vm *vm.VM
+}
+
+func (b *bytecodeVM) Execute(filename string, statements []stmt.Stmt, locals map[expr.Expr]int) (int, []error) {
+	compiler := compiler.NewCompiler()
+	function, errors := compiler.Compile(statements)
+	if len(errors) > 0 {
+		return EX_DATAERR, errors
+	}
+	b.vm.SetFile(filename)
+	if err := b.vm.Interpret(function); err != nil {
+		return EX_SOFTWARE, []error{err}
+	}
+	return EX_OK, nil
+}
@@ -0,0 +1,113 @@
+package bytecode
+
+import (
+	tkn "golox/token"
+	loxvalue "golox/value"
+)
+
+type OpCode byte
+
+// Operands follow the opcode in the code stream. Constant indices and jump
+// offsets are two bytes, big endian; local slots, upvalue indices and
+// argument counts are one byte.
+const (
+	OP_CONSTANT OpCode = iota
+	OP_NIL
+	OP_TRUE
+	OP_FALSE
+	OP_POP
+	OP_GET_LOCAL
+	OP_SET_LOCAL
+	OP_GET_GLOBAL
+	OP_DEFINE_GLOBAL
+	OP_SET_GLOBAL
+	OP_GET_UPVALUE
+	OP_SET_UPVALUE
+	OP_GET_PROPERTY
+	OP_SET_PROPERTY
+	OP_GET_SUPER
+	OP_EQUAL
+	OP_GREATER
+	OP_LESS
+	OP_ADD
+	OP_SUBTRACT
+	OP_MULTIPLY
+	OP_DIVIDE
+	OP_NOT
+	OP_NEGATE
+	OP_PRINT
+	OP_JUMP
+	OP_JUMP_IF_FALSE
+	OP_LOOP
+	OP_CALL
+	OP_INVOKE
+	OP_SUPER_INVOKE
+	OP_CLOSURE
+	OP_CLOSE_UPVALUE
+	OP_RETURN
+	OP_CLASS
+	OP_INHERIT
+	OP_METHOD
+	OP_TRY
+	OP_TRY_FINALLY
+	OP_END_TRY
+	OP_THROW
+	OP_INTERPOLATE
+	OP_MODULO
+	OP_FLOOR_DIVIDE
+	OP_POWER
+	OP_BIT_AND
+	OP_BIT_OR
+	OP_BIT_XOR
+	OP_BIT_NOT
+	OP_SHIFT_LEFT
+	OP_SHIFT_RIGHT
+	OP_JUMP_IF_NIL
+	OP_DUP
+	OP_SWAP
+	OP_INCREMENT
+	OP_DECREMENT
+)
+
+// MAX_CONSTANTS is the number of constants addressable by a two byte operand.
+const MAX_CONSTANTS = 1 << 16
+
+// Chunk is a sequence of instructions together with the constants they refer
+// to. Lines holds the source line of every byte in Code, and Spans the
+// source range that runtime errors raised by it point at.
+type Chunk struct {
+	Code      []byte
+	Constants []loxvalue.LoxValue
+	Lines     []int
+	Spans     []tkn.Span
+}
+
+func NewChunk() *Chunk {
+	return &Chunk{
+		Code:      []byte{},
+		Constants: []loxvalue.LoxValue{},
+		Lines:     []int{},
+		Spans:     []tkn.Span{},
+	}
+}
+
+func (c *Chunk) Write(b byte, line int, span tkn.Span) {
+	c.Code = append(c.Code, b)
+	c.Lines = append(c.Lines, line)
+	c.Spans = append(c.Spans, span)
+}
+
+func (c *Chunk) WriteOp(op OpCode, line int, span tkn.Span) {
+	c.Write(byte(op), line, span)
+}
+
+// AddConstant appends value to the constant pool and returns its index.
+func (c *Chunk) AddConstant(value loxvalue.LoxValue) int {
+	c.Constants = append(c.Constants, value)
+	return len(c.Constants) - 1
+}
+
+// ReadShort decodes the two byte operand starting at offset.
+func (c *Chunk) ReadShort(offset int) int {
+	return int(c.Code[offset])<<8 | int(c.Code[offset+1])
+}
@@ -0,0 +1,174 @@
+package bytecode
+
+import (
+	"fmt"
+	loxvalue "golox/value"
+	"io"
+)
+
+var opNames = map[OpCode]string{
+	OP_CONSTANT:      "OP_CONSTANT",
+	OP_NIL:           "OP_NIL",
+	OP_TRUE:          "OP_TRUE",
+	OP_FALSE:         "OP_FALSE",
+	OP_POP:           "OP_POP",
+	OP_GET_LOCAL:     "OP_GET_LOCAL",
+	OP_SET_LOCAL:     "OP_SET_LOCAL",
+	OP_GET_GLOBAL:    "OP_GET_GLOBAL",
+	OP_DEFINE_GLOBAL: "OP_DEFINE_GLOBAL",
+	OP_SET_GLOBAL:    "OP_SET_GLOBAL",
+	OP_GET_UPVALUE:   "OP_GET_UPVALUE",
+	OP_SET_UPVALUE:   "OP_SET_UPVALUE",
+	OP_GET_PROPERTY:  "OP_GET_PROPERTY",
+	OP_SET_PROPERTY:  "OP_SET_PROPERTY",
+	OP_GET_SUPER:     "OP_GET_SUPER",
+	OP_EQUAL:         "OP_EQUAL",
+	OP_GREATER:       "OP_GREATER",
+	OP_LESS:          "OP_LESS",
+	OP_ADD:           "OP_ADD",
+	OP_SUBTRACT:      "OP_SUBTRACT",
+	OP_MULTIPLY:      "OP_MULTIPLY",
+	OP_DIVIDE:        "OP_DIVIDE",
+	OP_NOT:           "OP_NOT",
+	OP_NEGATE:        "OP_NEGATE",
+	OP_PRINT:         "OP_PRINT",
+	OP_JUMP:          "OP_JUMP",
+	OP_JUMP_IF_FALSE: "OP_JUMP_IF_FALSE",
+	OP_LOOP:          "OP_LOOP",
+	OP_CALL:          "OP_CALL",
+	OP_INVOKE:        "OP_INVOKE",
+	OP_SUPER_INVOKE:  "OP_SUPER_INVOKE",
+	OP_CLOSURE:       "OP_CLOSURE",
+	OP_CLOSE_UPVALUE: "OP_CLOSE_UPVALUE",
+	OP_RETURN:        "OP_RETURN",
+	OP_CLASS:         "OP_CLASS",
+	OP_INHERIT:       "OP_INHERIT",
+	OP_METHOD:        "OP_METHOD",
+	OP_TRY:           "OP_TRY",
+	OP_TRY_FINALLY:   "OP_TRY_FINALLY",
+	OP_END_TRY:       "OP_END_TRY",
+	OP_THROW:         "OP_THROW",
+	OP_INTERPOLATE:   "OP_INTERPOLATE",
+	OP_MODULO:        "OP_MODULO",
+	OP_FLOOR_DIVIDE:  "OP_FLOOR_DIVIDE",
+	OP_POWER:         "OP_POWER",
+	OP_BIT_AND:       "OP_BIT_AND",
+	OP_BIT_OR:        "OP_BIT_OR",
+	OP_BIT_XOR:       "OP_BIT_XOR",
+	OP_BIT_NOT:       "OP_BIT_NOT",
+	OP_SHIFT_LEFT:    "OP_SHIFT_LEFT",
+	OP_SHIFT_RIGHT:   "OP_SHIFT_RIGHT",
+	OP_JUMP_IF_NIL:   "OP_JUMP_IF_NIL",
+	OP_DUP:           "OP_DUP",
+	OP_SWAP:          "OP_SWAP",
+	OP_INCREMENT:     "OP_INCREMENT",
+	OP_DECREMENT:     "OP_DECREMENT",
+}
+
+func (op OpCode) String() string {
+	if name, ok := opNames[op]; ok {
+		return name
+	}
+	return fmt.Sprintf("OP_UNKNOWN(%d)", byte(op))
+}
+
+// Disassemble writes the chunk of function followed by the chunks of every
+// function nested in its constant pool.
+func Disassemble(w io.Writer, function *Function) {
+	DisassembleChunk(w, function.Chunk, function.ToString())
+	for _, constant := range function.Chunk.Constants {
+		if nested, ok := constant.(*Function); ok {
+			fmt.Fprintln(w)
+			Disassemble(w, nested)
+		}
+	}
+}
+
+func DisassembleChunk(w io.Writer, chunk *Chunk, name string) {
+	fmt.Fprintf(w, "== %s ==\n", name)
+	for offset := 0; offset < len(chunk.Code); {
+		offset = DisassembleInstruction(w, chunk, offset)
+	}
+}
+
+// DisassembleInstruction writes the instruction at offset as
+// "offset line opcode operands" and returns the offset of the next one.
+// The line column shows "|" when it repeats the previous instruction's line.
+func DisassembleInstruction(w io.Writer, chunk *Chunk, offset int) int {
+	fmt.Fprintf(w, "%04d ", offset)
+	if offset > 0 && chunk.Lines[offset] == chunk.Lines[offset-1] {
+		fmt.Fprint(w, "   | ")
+	} else {
+		fmt.Fprintf(w, "%4d ", chunk.Lines[offset])
+	}
+
+	op := OpCode(chunk.Code[offset])
+	switch op {
+	case OP_CONSTANT, OP_GET_GLOBAL, OP_DEFINE_GLOBAL, OP_SET_GLOBAL,
+		OP_GET_PROPERTY, OP_SET_PROPERTY, OP_GET_SUPER, OP_CLASS, OP_METHOD:
+		return constantInstruction(w, op, chunk, offset)
+	case OP_GET_LOCAL, OP_SET_LOCAL, OP_GET_UPVALUE, OP_SET_UPVALUE, OP_CALL, OP_INTERPOLATE:
+		return byteInstruction(w, op, chunk, offset)
+	case OP_JUMP, OP_JUMP_IF_FALSE, OP_JUMP_IF_NIL, OP_TRY, OP_TRY_FINALLY:
+		return jumpInstruction(w, op, 1, chunk, offset)
+	case OP_LOOP:
+		return jumpInstruction(w, op, -1, chunk, offset)
+	case OP_INVOKE, OP_SUPER_INVOKE:
+		return invokeInstruction(w, op, chunk, offset)
+	case OP_CLOSURE:
+		return closureInstruction(w, op, chunk, offset)
+	}
+	if _, ok := opNames[op]; ok {
+		fmt.Fprintln(w, op)
+	} else {
+		fmt.Fprintf(w, "Unknown opcode %d\n", byte(op))
+	}
+	return offset + 1
+}
+
+func constantString(value loxvalue.LoxValue) string {
+	if value.Type() == loxvalue.STRING {
+		return fmt.Sprintf("%q", value.ToString())
+	}
+	return value.ToString()
+}
+
+func constantInstruction(w io.Writer, op OpCode, chunk *Chunk, offset int) int {
+	constant := chunk.ReadShort(offset + 1)
+	fmt.Fprintf(w, "%-16s %4d %s\n", op, constant, constantString(chunk.Constants[constant]))
+	return offset + 3
+}
+
+func byteInstruction(w io.Writer, op OpCode, chunk *Chunk, offset int) int {
+	fmt.Fprintf(w, "%-16s %4d\n", op, chunk.Code[offset+1])
+	return offset + 2
+}
+
+func jumpInstruction(w io.Writer, op OpCode, sign int, chunk *Chunk, offset int) int {
+	jump := chunk.ReadShort(offset + 1)
+	fmt.Fprintf(w, "%-16s %4d -> %d\n", op, offset, offset+3+sign*jump)
+	return offset + 3
+}
+
+func invokeInstruction(w io.Writer, op OpCode, chunk *Chunk, offset int) int {
+	constant := chunk.ReadShort(offset + 1)
+	argCount := chunk.Code[offset+3]
+	fmt.Fprintf(w, "%-16s (%d args) %4d %s\n", op, argCount, constant, constantString(chunk.Constants[constant]))
+	return offset + 4
+}
+
+func closureInstruction(w io.Writer, op OpCode, chunk *Chunk, offset int) int {
+	constant := chunk.ReadShort(offset + 1)
+	function := chunk.Constants[constant].(*Function)
+	fmt.Fprintf(w, "%-16s %4d %s\n", op, constant, function.ToString())
+	offset += 3
+	for i := 0; i < function.UpvalueCount; i++ {
+		kind := "upvalue"
+		if chunk.Code[offset] == 1 {
+			kind = "local"
+		}
+		fmt.Fprintf(w, "%04d    |                     %s %d\n", offset, kind, chunk.Code[offset+1])
+		offset += 2
+	}
+	return offset
+}
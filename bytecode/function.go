@@ -0,0 +1,34 @@
+package bytecode
+
+import (
+	tkn "golox/token"
+	loxvalue "golox/value"
+)
+
+// Function is the compiled form of a Lox function or of a whole script. Span
+// locates the name in the function's declaration; it is zero for the script.
+type Function struct {
+	Name         string
+	Span         tkn.Span
+	Arity        int
+	UpvalueCount int
+	Chunk        *Chunk
+}
+
+func NewFunction(name string) *Function {
+	return &Function{
+		Name:  name,
+		Chunk: NewChunk(),
+	}
+}
+
+func (f *Function) Type() int {
+	return loxvalue.FUNCTION
+}
+
+func (f *Function) ToString() string {
+	if f.Name == "" {
+		return "<script>"
+	}
+	return "<fn " + f.Name + ">"
+}
@@ -0,0 +1,340 @@
+package bytecode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	tkn "golox/token"
+	loxvalue "golox/value"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// A compiled image starts with a fixed header followed by the encoded script
+// function:
+//
+//	magic    4 bytes  "LOXC"
+//	version  uint16   FORMAT_VERSION
+//	checksum uint32   CRC-32 (IEEE) of the payload
+//	length   uint32   payload length in bytes
+//
+// All fixed-width integers are big endian. Inside the payload, counts and
+// small integers are unsigned varints, numbers are IEEE 754 bits and strings
+// are a varint length followed by UTF-8 bytes. A function is encoded as its
+// name, the span of its name, arity, upvalue count, code, run-length encoded
+// line and span tables and constants; nested functions appear inline in the
+// constant pool. A span is the line, column and offset of its start and then
+// of its end.
+const FORMAT_VERSION = 3
+
+var MAGIC = []byte("LOXC")
+
+const headerSize = 14
+
+const (
+	tagNumber byte = iota
+	tagString
+	tagFunction
+)
+
+var ErrNotImage = errors.New("not a compiled golox image")
+var ErrChecksum = errors.New("compiled image is corrupt: checksum mismatch")
+var ErrTruncated = errors.New("compiled image is truncated")
+
+// IsImage reports whether data starts with the compiled image magic.
+func IsImage(data []byte) bool {
+	return bytes.HasPrefix(data, MAGIC)
+}
+
+// WriteImage encodes function, usually the script returned by the compiler.
+func WriteImage(w io.Writer, function *Function) error {
+	payload := &bytes.Buffer{}
+	if err := encodeFunction(payload, function); err != nil {
+		return err
+	}
+
+	header := make([]byte, headerSize)
+	copy(header, MAGIC)
+	binary.BigEndian.PutUint16(header[4:], FORMAT_VERSION)
+	binary.BigEndian.PutUint32(header[6:], crc32.ChecksumIEEE(payload.Bytes()))
+	binary.BigEndian.PutUint32(header[10:], uint32(payload.Len()))
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(payload.Bytes())
+	return err
+}
+
+// ReadImage validates the header and checksum of data, decodes the script
+// function and verifies that every instruction is well formed.
+func ReadImage(data []byte) (*Function, error) {
+	if !IsImage(data) {
+		return nil, ErrNotImage
+	}
+	if len(data) < headerSize {
+		return nil, ErrTruncated
+	}
+	version := binary.BigEndian.Uint16(data[4:])
+	if version != FORMAT_VERSION {
+		return nil, fmt.Errorf("unsupported image format version %d (expected %d)", version, FORMAT_VERSION)
+	}
+	checksum := binary.BigEndian.Uint32(data[6:])
+	length := binary.BigEndian.Uint32(data[10:])
+	payload := data[headerSize:]
+	if uint32(len(payload)) < length {
+		return nil, ErrTruncated
+	}
+	payload = payload[:length]
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return nil, ErrChecksum
+	}
+
+	reader := bytes.NewReader(payload)
+	function, err := decodeFunction(reader)
+	if err != nil {
+		return nil, err
+	}
+	if reader.Len() != 0 {
+		return nil, fmt.Errorf("compiled image has %d trailing bytes", reader.Len())
+	}
+	if err := Verify(function); err != nil {
+		return nil, err
+	}
+	return function, nil
+}
+
+func writeUvarint(w *bytes.Buffer, value uint64) {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, value)
+	w.Write(buf[:n])
+}
+
+func writeString(w *bytes.Buffer, value string) {
+	writeUvarint(w, uint64(len(value)))
+	w.WriteString(value)
+}
+
+func writeSpan(w *bytes.Buffer, span tkn.Span) {
+	for _, position := range []tkn.Position{span.Start, span.End} {
+		writeUvarint(w, uint64(position.Line))
+		writeUvarint(w, uint64(position.Column))
+		writeUvarint(w, uint64(position.Offset))
+	}
+}
+
+func encodeFunction(w *bytes.Buffer, function *Function) error {
+	writeString(w, function.Name)
+	writeSpan(w, function.Span)
+	writeUvarint(w, uint64(function.Arity))
+	writeUvarint(w, uint64(function.UpvalueCount))
+
+	chunk := function.Chunk
+	writeUvarint(w, uint64(len(chunk.Code)))
+	w.Write(chunk.Code)
+
+	runs := [][2]int{}
+	for _, line := range chunk.Lines {
+		if len(runs) > 0 && runs[len(runs)-1][0] == line {
+			runs[len(runs)-1][1]++
+		} else {
+			runs = append(runs, [2]int{line, 1})
+		}
+	}
+	writeUvarint(w, uint64(len(runs)))
+	for _, run := range runs {
+		writeUvarint(w, uint64(run[0]))
+		writeUvarint(w, uint64(run[1]))
+	}
+
+	spanRuns := []int{}
+	for i, span := range chunk.Spans {
+		if i > 0 && chunk.Spans[i-1] == span {
+			spanRuns[len(spanRuns)-1]++
+		} else {
+			spanRuns = append(spanRuns, 1)
+		}
+	}
+	writeUvarint(w, uint64(len(spanRuns)))
+	offset := 0
+	for _, count := range spanRuns {
+		writeSpan(w, chunk.Spans[offset])
+		writeUvarint(w, uint64(count))
+		offset += count
+	}
+
+	writeUvarint(w, uint64(len(chunk.Constants)))
+	for _, constant := range chunk.Constants {
+		switch constant := constant.(type) {
+		case *loxvalue.Number:
+			w.WriteByte(tagNumber)
+			var bits [8]byte
+			binary.BigEndian.PutUint64(bits[:], math.Float64bits(constant.Value))
+			w.Write(bits[:])
+		case *loxvalue.String:
+			w.WriteByte(tagString)
+			writeString(w, constant.Value)
+		case *Function:
+			w.WriteByte(tagFunction)
+			if err := encodeFunction(w, constant); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("cannot encode constant %s", constant.ToString())
+		}
+	}
+	return nil
+}
+
+func readUvarint(r *bytes.Reader) (int, error) {
+	value, err := binary.ReadUvarint(r)
+	if err != nil || value > math.MaxInt32 {
+		return 0, ErrTruncated
+	}
+	return int(value), nil
+}
+
+func readBytes(r *bytes.Reader, n int) ([]byte, error) {
+	if n > r.Len() {
+		return nil, ErrTruncated
+	}
+	data := make([]byte, n)
+	r.Read(data)
+	return data, nil
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	n, err := readUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	data, err := readBytes(r, n)
+	return string(data), err
+}
+
+func readSpan(r *bytes.Reader) (tkn.Span, error) {
+	var span tkn.Span
+	var err error
+	for _, position := range []*tkn.Position{&span.Start, &span.End} {
+		if position.Line, err = readUvarint(r); err != nil {
+			return span, err
+		}
+		if position.Column, err = readUvarint(r); err != nil {
+			return span, err
+		}
+		if position.Offset, err = readUvarint(r); err != nil {
+			return span, err
+		}
+	}
+	return span, nil
+}
+
+func decodeFunction(r *bytes.Reader) (*Function, error) {
+	name, err := readString(r)
+	if err != nil {
+		return nil, err
+	}
+	function := NewFunction(name)
+	if function.Span, err = readSpan(r); err != nil {
+		return nil, err
+	}
+	if function.Arity, err = readUvarint(r); err != nil {
+		return nil, err
+	}
+	if function.UpvalueCount, err = readUvarint(r); err != nil {
+		return nil, err
+	}
+
+	codeLength, err := readUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if function.Chunk.Code, err = readBytes(r, codeLength); err != nil {
+		return nil, err
+	}
+
+	runCount, err := readUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < runCount; i++ {
+		line, err := readUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		count, err := readUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(function.Chunk.Lines)+count > codeLength {
+			return nil, errors.New("compiled image has a line table longer than its code")
+		}
+		for j := 0; j < count; j++ {
+			function.Chunk.Lines = append(function.Chunk.Lines, line)
+		}
+	}
+	if len(function.Chunk.Lines) != codeLength {
+		return nil, errors.New("compiled image has a line table shorter than its code")
+	}
+
+	runCount, err = readUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < runCount; i++ {
+		span, err := readSpan(r)
+		if err != nil {
+			return nil, err
+		}
+		count, err := readUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(function.Chunk.Spans)+count > codeLength {
+			return nil, errors.New("compiled image has a span table longer than its code")
+		}
+		for j := 0; j < count; j++ {
+			function.Chunk.Spans = append(function.Chunk.Spans, span)
+		}
+	}
+	if len(function.Chunk.Spans) != codeLength {
+		return nil, errors.New("compiled image has a span table shorter than its code")
+	}
+
+	constantCount, err := readUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < constantCount; i++ {
+		tag, err := r.ReadByte()
+		if err != nil {
+			return nil, ErrTruncated
+		}
+		switch tag {
+		case tagNumber:
+			bits, err := readBytes(r, 8)
+			if err != nil {
+				return nil, err
+			}
+			value := math.Float64frombits(binary.BigEndian.Uint64(bits))
+			function.Chunk.AddConstant(&loxvalue.Number{Value: value})
+		case tagString:
+			value, err := readString(r)
+			if err != nil {
+				return nil, err
+			}
+			function.Chunk.AddConstant(loxvalue.NewString(value))
+		case tagFunction:
+			nested, err := decodeFunction(r)
+			if err != nil {
+				return nil, err
+			}
+			function.Chunk.AddConstant(nested)
+		default:
+			return nil, fmt.Errorf("compiled image has unknown constant tag %d", tag)
+		}
+	}
+	return function, nil
+}
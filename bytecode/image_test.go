@@ -0,0 +1,105 @@
+package bytecode_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"golox/bytecode"
+	"golox/compiler"
+	"golox/parser"
+	"golox/scanner"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const program = `
+fun makeCounter() {
+  var i = 0;
+  fun count() { i = i + 1; return i; }
+  return count;
+}
+class A < B { init(n) { this.n = n * 1.5; } m() { return super.m() + "x"; } }
+for (var i = 0; i < 3; i = i + 1) print i;
+`
+
+func TestImage_RoundTrip(t *testing.T) {
+
+	function := compile(t, program)
+	image := &bytes.Buffer{}
+	require.NoError(t, bytecode.WriteImage(image, function))
+	require.True(t, bytecode.IsImage(image.Bytes()))
+
+	loaded, err := bytecode.ReadImage(image.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, function, loaded)
+
+}
+
+func TestImage_Errors(t *testing.T) {
+
+	image := &bytes.Buffer{}
+	require.NoError(t, bytecode.WriteImage(image, compile(t, program)))
+	valid := image.Bytes()
+
+	corrupt := func(change func(data []byte) []byte) []byte {
+		data := append([]byte{}, valid...)
+		return change(data)
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"source", []byte("print 1;"), bytecode.ErrNotImage.Error()},
+		{"header", valid[:8], bytecode.ErrTruncated.Error()},
+		{"payload", valid[:len(valid)-1], bytecode.ErrTruncated.Error()},
+		{"version", corrupt(func(data []byte) []byte {
+			binary.BigEndian.PutUint16(data[4:], bytecode.FORMAT_VERSION+1)
+			return data
+		}), "unsupported image format version 4 (expected 3)"},
+		{"checksum", corrupt(func(data []byte) []byte {
+			data[len(data)-1] ^= 0xff
+			return data
+		}), bytecode.ErrChecksum.Error()},
+	}
+
+	for _, test := range tests {
+		_, err := bytecode.ReadImage(test.data)
+		require.EqualError(t, err, test.expected, test.name)
+	}
+
+}
+
+func TestVerify(t *testing.T) {
+
+	function := compile(t, "print 1;")
+	require.NoError(t, bytecode.Verify(function))
+
+	function.Chunk.Code[1] = 0x10
+	require.EqualError(t, bytecode.Verify(function), "invalid bytecode in <script> at offset 0000: constant index out of range")
+
+	function = compile(t, "print 1;")
+	function.Chunk.Code = function.Chunk.Code[:len(function.Chunk.Code)-1]
+	function.Chunk.Lines = function.Chunk.Lines[:len(function.Chunk.Lines)-1]
+	require.EqualError(t, bytecode.Verify(function), "invalid bytecode in <script> at offset 0005: chunk does not end with OP_RETURN")
+
+	function = compile(t, "if (true) print 1;")
+	function.Chunk.Code[3] = 2
+	require.EqualError(t, bytecode.Verify(function), "invalid bytecode in <script> at offset 0001: jump target 0006 is not an instruction")
+
+}
+
+func compile(t *testing.T, input string) *bytecode.Function {
+
+	scanner := scanner.NewScanner(input)
+	tokens, errors := scanner.Scan()
+	require.Empty(t, errors)
+	parser := parser.NewParser(tokens)
+	statements, errors := parser.Parse()
+	require.Empty(t, errors)
+	function, errors := compiler.NewCompiler().Compile(statements)
+	require.Empty(t, errors)
+	return function
+
+}
@@ -0,0 +1,119 @@
+package bytecode
+
+import (
+	"fmt"
+	loxvalue "golox/value"
+)
+
+// Verify checks that function and every function nested in it only contain
+// known opcodes whose operands stay inside the chunk: constant indices refer
+// to constants of the right kind, jumps land on an instruction boundary and
+// the code ends with a return. The compiler always produces verifiable code;
+// this catches damaged images before they run. It does not follow the stack,
+// so code that pops more than it pushed, reads a local slot that does not
+// exist or passes the wrong kind of value to an instruction still gets
+// through and can crash the VM.
+func Verify(function *Function) error {
+	chunk := function.Chunk
+	code := chunk.Code
+	boundaries := make(map[int]bool)
+	targets := map[int]int{}
+
+	fail := func(offset int, format string, args ...interface{}) error {
+		return fmt.Errorf("invalid bytecode in %s at offset %04d: %s", function.ToString(), offset, fmt.Sprintf(format, args...))
+	}
+
+	last := -1
+	for offset := 0; offset < len(code); {
+		boundaries[offset] = true
+		last = offset
+		op := OpCode(code[offset])
+		if _, ok := opNames[op]; !ok {
+			return fail(offset, "unknown opcode %d", code[offset])
+		}
+		length := instructionLength(op)
+		if offset+length > len(code) {
+			return fail(offset, "%s operands run past the end of the chunk", op)
+		}
+
+		switch op {
+		case OP_CONSTANT:
+			if chunk.ReadShort(offset+1) >= len(chunk.Constants) {
+				return fail(offset, "constant index out of range")
+			}
+		case OP_GET_GLOBAL, OP_DEFINE_GLOBAL, OP_SET_GLOBAL, OP_GET_PROPERTY,
+			OP_SET_PROPERTY, OP_GET_SUPER, OP_CLASS, OP_METHOD, OP_INVOKE, OP_SUPER_INVOKE:
+			constant := chunk.ReadShort(offset + 1)
+			if constant >= len(chunk.Constants) || chunk.Constants[constant].Type() != loxvalue.STRING {
+				return fail(offset, "%s expects a string constant", op)
+			}
+		case OP_GET_UPVALUE, OP_SET_UPVALUE:
+			if int(code[offset+1]) >= function.UpvalueCount {
+				return fail(offset, "upvalue index out of range")
+			}
+		case OP_INTERPOLATE:
+			if code[offset+1] == 0 {
+				return fail(offset, "OP_INTERPOLATE joins no values")
+			}
+		case OP_JUMP, OP_JUMP_IF_FALSE, OP_JUMP_IF_NIL, OP_TRY, OP_TRY_FINALLY:
+			targets[offset] = offset + 3 + chunk.ReadShort(offset+1)
+		case OP_LOOP:
+			targets[offset] = offset + 3 - chunk.ReadShort(offset+1)
+		case OP_CLOSURE:
+			constant := chunk.ReadShort(offset + 1)
+			if constant >= len(chunk.Constants) {
+				return fail(offset, "constant index out of range")
+			}
+			nested, ok := chunk.Constants[constant].(*Function)
+			if !ok {
+				return fail(offset, "OP_CLOSURE expects a function constant")
+			}
+			length += 2 * nested.UpvalueCount
+			if offset+length > len(code) {
+				return fail(offset, "OP_CLOSURE upvalues run past the end of the chunk")
+			}
+			for i := 0; i < nested.UpvalueCount; i++ {
+				isLocal := code[offset+3+2*i]
+				index := int(code[offset+4+2*i])
+				if isLocal > 1 || (isLocal == 0 && index >= function.UpvalueCount) {
+					return fail(offset, "OP_CLOSURE captures an invalid upvalue")
+				}
+			}
+		}
+		offset += length
+	}
+
+	if last == -1 || OpCode(code[last]) != OP_RETURN {
+		return fail(len(code), "chunk does not end with OP_RETURN")
+	}
+	for offset, target := range targets {
+		if !boundaries[target] {
+			return fail(offset, "jump target %04d is not an instruction", target)
+		}
+	}
+
+	for _, constant := range chunk.Constants {
+		if nested, ok := constant.(*Function); ok {
+			if err := Verify(nested); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// instructionLength returns the size of an instruction with op, not counting
+// the upvalue pairs that follow OP_CLOSURE.
+func instructionLength(op OpCode) int {
+	switch op {
+	case OP_CONSTANT, OP_GET_GLOBAL, OP_DEFINE_GLOBAL, OP_SET_GLOBAL,
+		OP_GET_PROPERTY, OP_SET_PROPERTY, OP_GET_SUPER, OP_CLASS, OP_METHOD,
+		OP_JUMP, OP_JUMP_IF_FALSE, OP_JUMP_IF_NIL, OP_LOOP, OP_CLOSURE, OP_TRY, OP_TRY_FINALLY:
+		return 3
+	case OP_GET_LOCAL, OP_SET_LOCAL, OP_GET_UPVALUE, OP_SET_UPVALUE, OP_CALL, OP_INTERPOLATE:
+		return 2
+	case OP_INVOKE, OP_SUPER_INVOKE:
+		return 4
+	}
+	return 1
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"golox/bytecode"
+	"golox/compiler"
+	"golox/vm"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// runCompile compiles a script ahead of time into a bytecode image that can
+// later be run without scanning, parsing or compiling it again.
+func runCompile(args []string) error {
+	flags := flag.NewFlagSet("compile", flag.ContinueOnError)
+	output := flags.String("o", "", "output file (default: the script name with a .loxc extension)")
+	flags.Usage = usage
+	parseFlags(flags, args)
+	if flags.NArg() != 1 {
+		usage()
+		os.Exit(EX_USAGE)
+	}
+	filename := flags.Arg(0)
+	if *output == "" {
+		*output = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".loxc"
+	}
+
+	function, err := compileFile(filename)
+	if err != nil || function == nil {
+		return err
+	}
+	return writeImage(*output, function)
+}
+
+// writeImage writes function to the image file output. The image goes to a
+// temporary file next to output first and is only renamed over it once it
+// is complete, so a failed write never leaves a truncated image behind.
+func writeImage(output string, function *bytecode.Function) (err error) {
+	file, err := os.CreateTemp(filepath.Dir(output), "."+filepath.Base(output)+".*")
+	if err != nil {
+		return ioError("failed to create file: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(file.Name())
+		}
+	}()
+	if err := bytecode.WriteImage(file, function); err != nil {
+		file.Close()
+		return ioError("failed to write image: %v", err)
+	}
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		return ioError("failed to write image: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return ioError("failed to write image: %v", err)
+	}
+	if err := os.Rename(file.Name(), output); err != nil {
+		return ioError("failed to write image: %v", err)
+	}
+	return nil
+}
+
+// compileFile returns the compiled script in filename, which may be Lox
+// source or an existing image. Compile errors are printed, recorded in the
+// exit status and yield a nil function.
+func compileFile(filename string) (*bytecode.Function, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, ioError("failed to read file: %v", err)
+	}
+	if bytecode.IsImage(content) {
+		return readImage(content)
+	}
+	statements, _, errors := parse(string(content))
+	if len(errors) > 0 {
+		printErrors(filename, string(content), errors)
+		fail(EX_DATAERR)
+		return nil, nil
+	}
+	function, errors := compiler.NewCompiler().Compile(statements)
+	if len(errors) > 0 {
+		printErrors(filename, string(content), errors)
+		fail(EX_DATAERR)
+		return nil, nil
+	}
+	return function, nil
+}
+
+// runImage loads a compiled image and runs it on the virtual machine. The
+// faults that bytecode.Verify leaves to run time are reported as an invalid
+// image; any other panic is a bug in golox and is reported as one.
+func runImage(filename string, content []byte) (err error) {
+	function, err := readImage(content)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if !imageFault(r) {
+				err = &statusError{status: EX_SOFTWARE, err: fmt.Errorf("internal error: %v\n%s", r, debug.Stack())}
+				return
+			}
+			err = &statusError{status: EX_DATAERR, err: fmt.Errorf("invalid compiled image: %v", r)}
+		}
+	}()
+	machine := vm.NewVM()
+	machine.SetFile(filename)
+	if err := machine.Interpret(function); err != nil {
+		printErrors(filename, "", []error{err})
+		fail(EX_SOFTWARE)
+	}
+	return nil
+}
+
+// imageFault reports whether r, recovered from the VM, is a fault that
+// bytecode.Verify does not rule out: an index out of range from code that
+// pops more than it pushed or reads a local slot that does not exist, or a
+// failed type assertion from code that passes the wrong kind of value.
+func imageFault(r interface{}) bool {
+	if _, ok := r.(*runtime.TypeAssertionError); ok {
+		return true
+	}
+	err, ok := r.(runtime.Error)
+	return ok && strings.Contains(err.Error(), "index out of range")
+}
+
+// readImage loads a compiled image. A corrupt or incompatible image is a
+// data error, like a script that does not compile.
+func readImage(content []byte) (*bytecode.Function, error) {
+	function, err := bytecode.ReadImage(content)
+	if err != nil {
+		return nil, &statusError{status: EX_DATAERR, err: err}
+	}
+	return function, nil
+}
@@ -0,0 +1,1011 @@
+package compiler
+
+import (
+	"golox/bytecode"
+	loxerror "golox/error"
+	"golox/expr"
+	"golox/stmt"
+	tkn "golox/token"
+	loxvalue "golox/value"
+)
+
+type functionType int
+
+const (
+	TYPE_FUNCTION functionType = iota
+	TYPE_INITIALIZER
+	TYPE_METHOD
+	TYPE_SCRIPT
+)
+
+const MAX_LOCALS = 256
+const MAX_UPVALUES = 256
+const MAX_INTERPOLATION_PARTS = 255
+
+type local struct {
+	name       string
+	depth      int
+	isCaptured bool
+}
+
+// tryContext is a try statement whose body is being compiled. Returning from
+// inside it must first drop its handler and run its finally clause.
+type tryContext struct {
+	finally stmt.Stmt
+}
+
+// loopContext is a loop whose body is being compiled. The jumps of break
+// and continue statements are patched once the loop's end and the point
+// where it continues are known.
+type loopContext struct {
+	label      string
+	scopeDepth int
+	tries      int
+	breaks     []int
+	continues  []int
+}
+
+type upvalue struct {
+	index   int
+	isLocal bool
+}
+
+// functionCompiler holds the state of the function currently being compiled.
+// Nested function declarations push a new one linked through enclosing.
+type functionCompiler struct {
+	enclosing  *functionCompiler
+	function   *bytecode.Function
+	kind       functionType
+	locals     []local
+	upvalues   []upvalue
+	tries      []tryContext
+	loops      []*loopContext
+	scopeDepth int
+}
+
+type classCompiler struct {
+	enclosing     *classCompiler
+	hasSuperclass bool
+}
+
+// Compiler translates the statements produced by the parser into bytecode.
+// Like the resolver it tracks scopes itself, so every local lives in a stack
+// slot and captured variables become upvalues.
+type Compiler struct {
+	current      *functionCompiler
+	currentClass *classCompiler
+	line         int
+	span         tkn.Span
+	errors       []error
+}
+
+func NewCompiler() *Compiler {
+	return &Compiler{
+		line:   1,
+		errors: []error{},
+	}
+}
+
+// Compile returns the top-level script as a function taking no arguments.
+func (c *Compiler) Compile(statements []stmt.Stmt) (*bytecode.Function, []error) {
+	c.beginFunction(TYPE_SCRIPT, "")
+	for _, statement := range statements {
+		c.statement(statement)
+	}
+	function, _ := c.endFunction()
+	return function, c.errors
+}
+
+func (c *Compiler) statement(statement stmt.Stmt) {
+	statement.Accept(c)
+}
+
+func (c *Compiler) expression(e expr.Expr) {
+	e.Evaluate(c)
+}
+
+func (c *Compiler) chunk() *bytecode.Chunk {
+	return c.current.function.Chunk
+}
+
+// setSource makes token the source of the instructions emitted next, which
+// errors raised by them point at.
+func (c *Compiler) setSource(token tkn.Token) {
+	c.line = token.Line
+	c.span = token.Span
+}
+
+func (c *Compiler) error(message string) {
+	err := loxerror.NewError(c.line, "", message)
+	err.Span = c.span
+	c.errors = append(c.errors, err)
+}
+
+func (c *Compiler) emitByte(b byte) {
+	c.chunk().Write(b, c.line, c.span)
+}
+
+func (c *Compiler) emitOp(op bytecode.OpCode) {
+	c.chunk().WriteOp(op, c.line, c.span)
+}
+
+func (c *Compiler) emitShort(value int) {
+	c.emitByte(byte(value >> 8))
+	c.emitByte(byte(value))
+}
+
+func (c *Compiler) emitOpShort(op bytecode.OpCode, operand int) {
+	c.emitOp(op)
+	c.emitShort(operand)
+}
+
+func (c *Compiler) emitOpByte(op bytecode.OpCode, operand int) {
+	c.emitOp(op)
+	c.emitByte(byte(operand))
+}
+
+func (c *Compiler) makeConstant(value loxvalue.LoxValue) int {
+	constant := c.chunk().AddConstant(value)
+	if constant >= bytecode.MAX_CONSTANTS {
+		c.error(loxerror.COMPILER_ERROR_TOO_MANY_CONSTANTS)
+		return 0
+	}
+	return constant
+}
+
+func (c *Compiler) emitConstant(value loxvalue.LoxValue) {
+	c.emitOpShort(bytecode.OP_CONSTANT, c.makeConstant(value))
+}
+
+func (c *Compiler) identifierConstant(name string) int {
+	return c.makeConstant(loxvalue.NewString(name))
+}
+
+func (c *Compiler) emitJump(op bytecode.OpCode) int {
+	c.emitOp(op)
+	c.emitShort(0xffff)
+	return len(c.chunk().Code) - 2
+}
+
+// patchJump fills in the operand of the jump at offset so that it lands on
+// the next instruction to be emitted.
+func (c *Compiler) patchJump(offset int) {
+	jump := len(c.chunk().Code) - offset - 2
+	if jump > 0xffff {
+		c.error(loxerror.COMPILER_ERROR_JUMP_TOO_LARGE)
+	}
+	c.chunk().Code[offset] = byte(jump >> 8)
+	c.chunk().Code[offset+1] = byte(jump)
+}
+
+func (c *Compiler) emitLoop(loopStart int) {
+	c.emitOp(bytecode.OP_LOOP)
+	offset := len(c.chunk().Code) - loopStart + 2
+	if offset > 0xffff {
+		c.error(loxerror.COMPILER_ERROR_LOOP_TOO_LARGE)
+	}
+	c.emitShort(offset)
+}
+
+func (c *Compiler) emitReturn() {
+	c.emitReturnValue()
+	c.emitOp(bytecode.OP_RETURN)
+}
+
+// emitReturnValue pushes the value of a return without an expression.
+func (c *Compiler) emitReturnValue() {
+	if c.current.kind == TYPE_INITIALIZER {
+		c.emitOpByte(bytecode.OP_GET_LOCAL, 0)
+	} else {
+		c.emitOp(bytecode.OP_NIL)
+	}
+}
+
+// exitTries leaves the try statements entered after the first depth ones,
+// innermost first, dropping their handlers and running their finally
+// clauses. A value on top of the stack, such as the one being returned, is
+// kept as a hidden local so that the finally code finds its own locals in
+// the right slots.
+func (c *Compiler) exitTries(depth int, hasValue bool) {
+	fc := c.current
+	tries := fc.tries
+	if hasValue {
+		fc.locals = append(fc.locals, local{name: "", depth: fc.scopeDepth})
+	}
+	for i := len(tries) - 1; i >= depth; i-- {
+		fc.tries = tries[:i]
+		c.emitOp(bytecode.OP_END_TRY)
+		if tries[i].finally != nil {
+			c.statement(tries[i].finally)
+		}
+	}
+	fc.tries = tries
+	if hasValue {
+		fc.locals = fc.locals[:len(fc.locals)-1]
+	}
+}
+
+func (c *Compiler) beginFunction(kind functionType, name string) {
+	fc := &functionCompiler{
+		enclosing: c.current,
+		function:  bytecode.NewFunction(name),
+		kind:      kind,
+		locals:    []local{},
+		upvalues:  []upvalue{},
+	}
+	// Slot zero holds the function being called, or the receiver in methods.
+	slotZero := ""
+	if kind == TYPE_METHOD || kind == TYPE_INITIALIZER {
+		slotZero = "this"
+	}
+	fc.locals = append(fc.locals, local{name: slotZero, depth: 0})
+	c.current = fc
+}
+
+func (c *Compiler) endFunction() (*bytecode.Function, []upvalue) {
+	c.emitReturn()
+	fc := c.current
+	c.current = fc.enclosing
+	return fc.function, fc.upvalues
+}
+
+func (c *Compiler) beginScope() {
+	c.current.scopeDepth++
+}
+
+func (c *Compiler) endScope() {
+	fc := c.current
+	fc.scopeDepth--
+	for len(fc.locals) > 0 && fc.locals[len(fc.locals)-1].depth > fc.scopeDepth {
+		if fc.locals[len(fc.locals)-1].isCaptured {
+			c.emitOp(bytecode.OP_CLOSE_UPVALUE)
+		} else {
+			c.emitOp(bytecode.OP_POP)
+		}
+		fc.locals = fc.locals[:len(fc.locals)-1]
+	}
+}
+
+func (c *Compiler) addLocal(name string) {
+	if len(c.current.locals) == MAX_LOCALS {
+		c.error(loxerror.COMPILER_ERROR_TOO_MANY_LOCALS)
+		return
+	}
+	c.current.locals = append(c.current.locals, local{name: name, depth: -1})
+}
+
+func (c *Compiler) declareVariable(name tkn.Token) {
+	if c.current.scopeDepth == 0 {
+		return
+	}
+	c.addLocal(name.Lexeme)
+}
+
+func (c *Compiler) markInitialized() {
+	if c.current.scopeDepth == 0 {
+		return
+	}
+	c.current.locals[len(c.current.locals)-1].depth = c.current.scopeDepth
+}
+
+// defineVariable makes a declared variable available: locals are already in
+// their slot, globals are stored from the top of the stack.
+func (c *Compiler) defineVariable(name tkn.Token) {
+	if c.current.scopeDepth > 0 {
+		c.markInitialized()
+		return
+	}
+	c.emitOpShort(bytecode.OP_DEFINE_GLOBAL, c.identifierConstant(name.Lexeme))
+}
+
+func resolveLocal(fc *functionCompiler, name string) int {
+	for i := len(fc.locals) - 1; i >= 0; i-- {
+		if fc.locals[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *Compiler) resolveUpvalue(fc *functionCompiler, name string) int {
+	if fc.enclosing == nil {
+		return -1
+	}
+	if local := resolveLocal(fc.enclosing, name); local != -1 {
+		fc.enclosing.locals[local].isCaptured = true
+		return c.addUpvalue(fc, local, true)
+	}
+	if upvalue := c.resolveUpvalue(fc.enclosing, name); upvalue != -1 {
+		return c.addUpvalue(fc, upvalue, false)
+	}
+	return -1
+}
+
+func (c *Compiler) addUpvalue(fc *functionCompiler, index int, isLocal bool) int {
+	for i, upvalue := range fc.upvalues {
+		if upvalue.index == index && upvalue.isLocal == isLocal {
+			return i
+		}
+	}
+	if len(fc.upvalues) == MAX_UPVALUES {
+		c.error(loxerror.COMPILER_ERROR_TOO_MANY_UPVALUES)
+		return 0
+	}
+	fc.upvalues = append(fc.upvalues, upvalue{index: index, isLocal: isLocal})
+	fc.function.UpvalueCount = len(fc.upvalues)
+	return len(fc.upvalues) - 1
+}
+
+// variable is where a name lives: the instructions that read and write it
+// and their operand, which is two bytes wide for globals.
+type variable struct {
+	getOp, setOp bytecode.OpCode
+	arg          int
+	wide         bool
+}
+
+func (c *Compiler) resolveVariable(name tkn.Token) variable {
+	if arg := resolveLocal(c.current, name.Lexeme); arg != -1 {
+		return variable{bytecode.OP_GET_LOCAL, bytecode.OP_SET_LOCAL, arg, false}
+	}
+	if arg := c.resolveUpvalue(c.current, name.Lexeme); arg != -1 {
+		return variable{bytecode.OP_GET_UPVALUE, bytecode.OP_SET_UPVALUE, arg, false}
+	}
+	return variable{bytecode.OP_GET_GLOBAL, bytecode.OP_SET_GLOBAL, c.identifierConstant(name.Lexeme), true}
+}
+
+func (c *Compiler) emitVariable(name tkn.Token, op bytecode.OpCode, v variable) {
+	c.setSource(name)
+	if v.wide {
+		c.emitOpShort(op, v.arg)
+	} else {
+		c.emitOpByte(op, v.arg)
+	}
+}
+
+// namedVariable emits a read of name, or a write of value to name when value
+// is not nil.
+func (c *Compiler) namedVariable(name tkn.Token, value expr.Expr) {
+	v := c.resolveVariable(name)
+	if value == nil {
+		c.emitVariable(name, v.getOp, v)
+		return
+	}
+	c.expression(value)
+	c.emitVariable(name, v.setOp, v)
+}
+
+func (c *Compiler) function(declaration stmt.FunStmt, kind functionType) {
+	c.beginFunction(kind, declaration.Name.Lexeme)
+	c.current.function.Span = declaration.Name.Span
+	c.beginScope()
+	for _, param := range declaration.Params {
+		c.current.function.Arity++
+		c.declareVariable(param)
+		c.markInitialized()
+	}
+	for _, statement := range declaration.Body {
+		c.statement(statement)
+	}
+	function, upvalues := c.endFunction()
+
+	c.setSource(declaration.Name)
+	c.emitOpShort(bytecode.OP_CLOSURE, c.makeConstant(function))
+	for _, upvalue := range upvalues {
+		if upvalue.isLocal {
+			c.emitByte(1)
+		} else {
+			c.emitByte(0)
+		}
+		c.emitByte(byte(upvalue.index))
+	}
+}
+
+func (c *Compiler) thisToken(line int) tkn.Token {
+	return tkn.NewToken(tkn.THIS, "this", nil, line)
+}
+
+func (c *Compiler) VisitExpressionStatement(exprStmt stmt.ExprStmt) (interface{}, error) {
+	c.expression(exprStmt.E)
+	c.emitOp(bytecode.OP_POP)
+	return nil, nil
+}
+
+func (c *Compiler) VisitPrintStatement(printStmt stmt.PrintStmt) (interface{}, error) {
+	c.expression(printStmt.E)
+	c.emitOp(bytecode.OP_PRINT)
+	return nil, nil
+}
+
+func (c *Compiler) VisitVariableStatement(variableStmt stmt.VarStmt) (interface{}, error) {
+	c.setSource(variableStmt.Name)
+	c.declareVariable(variableStmt.Name)
+	if variableStmt.Initializer != nil {
+		c.expression(variableStmt.Initializer)
+	} else {
+		c.emitOp(bytecode.OP_NIL)
+	}
+	c.defineVariable(variableStmt.Name)
+	return nil, nil
+}
+
+func (c *Compiler) VisitBlockStatement(blockStmt stmt.BlockStmt) (interface{}, error) {
+	c.beginScope()
+	for _, statement := range blockStmt.Statements {
+		c.statement(statement)
+	}
+	c.endScope()
+	return nil, nil
+}
+
+func (c *Compiler) VisitIfStatement(ifStmt stmt.IfStmt) (interface{}, error) {
+	c.expression(ifStmt.Condition)
+	thenJump := c.emitJump(bytecode.OP_JUMP_IF_FALSE)
+	c.emitOp(bytecode.OP_POP)
+	c.statement(ifStmt.ThenBrnach)
+	elseJump := c.emitJump(bytecode.OP_JUMP)
+	c.patchJump(thenJump)
+	c.emitOp(bytecode.OP_POP)
+	if ifStmt.ElseBranch != nil {
+		c.statement(ifStmt.ElseBranch)
+	}
+	c.patchJump(elseJump)
+	return nil, nil
+}
+
+func (c *Compiler) VisitWhileStatement(whileStmt stmt.WhileStmt) (interface{}, error) {
+	loopStart := len(c.chunk().Code)
+	c.expression(whileStmt.Condition)
+	exitJump := c.emitJump(bytecode.OP_JUMP_IF_FALSE)
+	c.emitOp(bytecode.OP_POP)
+	loop := c.loopBody(whileStmt.Label, whileStmt.Body)
+	c.patchJumps(loop.continues)
+	c.emitLoop(loopStart)
+	c.patchJump(exitJump)
+	c.emitOp(bytecode.OP_POP)
+	c.patchJumps(loop.breaks)
+	return nil, nil
+}
+
+// loopBody compiles the body of a loop with label, or of an unlabeled one
+// when label is nil, and returns the jumps its break and continue statements
+// left to patch.
+func (c *Compiler) loopBody(label *tkn.Token, body stmt.Stmt) *loopContext {
+	fc := c.current
+	loop := &loopContext{scopeDepth: fc.scopeDepth, tries: len(fc.tries)}
+	if label != nil {
+		loop.label = label.Lexeme
+	}
+	fc.loops = append(fc.loops, loop)
+	c.statement(body)
+	fc.loops = fc.loops[:len(fc.loops)-1]
+	return loop
+}
+
+// leaveLoopBody emits a jump out of the body of loop, recorded in jumps,
+// after leaving the try statements entered inside the body and discarding
+// its locals. The locals are closed rather than popped: a closure made by an
+// earlier pass through an inner loop may hold one, even if the closure is
+// only compiled after this jump.
+func (c *Compiler) leaveLoopBody(loop *loopContext, jumps *[]int) {
+	fc := c.current
+	c.exitTries(loop.tries, false)
+	for i := len(fc.locals) - 1; i >= 0 && fc.locals[i].depth > loop.scopeDepth; i-- {
+		c.emitOp(bytecode.OP_CLOSE_UPVALUE)
+	}
+	*jumps = append(*jumps, c.emitJump(bytecode.OP_JUMP))
+}
+
+// targetLoop returns the loop a break or continue with label refers to. The
+// resolver has checked that it exists.
+func (c *Compiler) targetLoop(label *tkn.Token) *loopContext {
+	loops := c.current.loops
+	for i := len(loops) - 1; i >= 0; i-- {
+		if label == nil || loops[i].label == label.Lexeme {
+			return loops[i]
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) VisitBreakStatement(breakStmt stmt.BreakStmt) (interface{}, error) {
+	c.setSource(breakStmt.Keyword)
+	if loop := c.targetLoop(breakStmt.Label); loop != nil {
+		c.leaveLoopBody(loop, &loop.breaks)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitContinueStatement(continueStmt stmt.ContinueStmt) (interface{}, error) {
+	c.setSource(continueStmt.Keyword)
+	if loop := c.targetLoop(continueStmt.Label); loop != nil {
+		c.leaveLoopBody(loop, &loop.continues)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitForStatement(forStmt stmt.ForStmt) (interface{}, error) {
+	c.beginScope()
+	if forStmt.Initializer != nil {
+		c.statement(forStmt.Initializer)
+	}
+
+	loopStart := len(c.chunk().Code)
+	exitJump := -1
+	if forStmt.Condition != nil {
+		c.expression(forStmt.Condition)
+		exitJump = c.emitJump(bytecode.OP_JUMP_IF_FALSE)
+		c.emitOp(bytecode.OP_POP)
+	}
+
+	loop := c.loopBody(forStmt.Label, forStmt.Body)
+	c.patchJumps(loop.continues)
+	if forStmt.Increment != nil {
+		c.expression(forStmt.Increment)
+		c.emitOp(bytecode.OP_POP)
+	}
+	c.emitLoop(loopStart)
+
+	if exitJump != -1 {
+		c.patchJump(exitJump)
+		c.emitOp(bytecode.OP_POP)
+	}
+	c.patchJumps(loop.breaks)
+	c.endScope()
+	return nil, nil
+}
+
+func (c *Compiler) VisitFunctionStatement(funStmt stmt.FunStmt) (interface{}, error) {
+	c.setSource(funStmt.Name)
+	c.declareVariable(funStmt.Name)
+	c.markInitialized()
+	c.function(funStmt, TYPE_FUNCTION)
+	c.defineVariable(funStmt.Name)
+	return nil, nil
+}
+
+func (c *Compiler) VisitReturnStatement(returnStmt stmt.ReturnStmt) (interface{}, error) {
+	c.setSource(returnStmt.Keyword)
+	if returnStmt.Value == nil {
+		c.emitReturnValue()
+	} else {
+		c.expression(returnStmt.Value)
+		c.setSource(returnStmt.Keyword)
+	}
+	c.exitTries(0, true)
+	c.emitOp(bytecode.OP_RETURN)
+	return nil, nil
+}
+
+func (c *Compiler) VisitClassStatement(classStmt stmt.ClassStmt) (interface{}, error) {
+	c.setSource(classStmt.Name)
+	nameConstant := c.identifierConstant(classStmt.Name.Lexeme)
+	c.declareVariable(classStmt.Name)
+	c.emitOpShort(bytecode.OP_CLASS, nameConstant)
+	c.defineVariable(classStmt.Name)
+
+	class := &classCompiler{enclosing: c.currentClass}
+	c.currentClass = class
+	defer func() {
+		c.currentClass = class.enclosing
+	}()
+
+	if classStmt.Superclass != nil {
+		c.namedVariable(classStmt.Superclass.Name, nil)
+		c.beginScope()
+		c.addLocal("super")
+		c.markInitialized()
+		c.namedVariable(classStmt.Name, nil)
+		c.setSource(classStmt.Superclass.Name)
+		c.emitOp(bytecode.OP_INHERIT)
+		class.hasSuperclass = true
+	}
+
+	c.namedVariable(classStmt.Name, nil)
+	for _, method := range classStmt.Methods {
+		kind := TYPE_METHOD
+		if method.Name.Lexeme == "init" {
+			kind = TYPE_INITIALIZER
+		}
+		c.setSource(method.Name)
+		constant := c.identifierConstant(method.Name.Lexeme)
+		c.function(method, kind)
+		c.emitOpShort(bytecode.OP_METHOD, constant)
+	}
+	c.emitOp(bytecode.OP_POP)
+
+	if class.hasSuperclass {
+		c.endScope()
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitThrowStatement(throwStmt stmt.ThrowStmt) (interface{}, error) {
+	c.expression(throwStmt.Value)
+	c.setSource(throwStmt.Keyword)
+	c.emitOp(bytecode.OP_THROW)
+	return nil, nil
+}
+
+// VisitTryStatement protects the body with a handler that jumps to the catch
+// clause, with the caught value in the slot of the catch variable. A finally
+// clause is compiled twice: once on the normal path and once in an outer
+// handler that runs it and throws the error again.
+func (c *Compiler) VisitTryStatement(tryStmt stmt.TryStmt) (interface{}, error) {
+	fc := c.current
+	c.setSource(tryStmt.Keyword)
+	finallyHandler := -1
+	if tryStmt.Finally != nil {
+		finallyHandler = c.emitJump(bytecode.OP_TRY_FINALLY)
+		fc.tries = append(fc.tries, tryContext{finally: tryStmt.Finally})
+	}
+
+	if tryStmt.Catch != nil {
+		catchHandler := c.emitJump(bytecode.OP_TRY)
+		fc.tries = append(fc.tries, tryContext{})
+		c.statement(tryStmt.Body)
+		fc.tries = fc.tries[:len(fc.tries)-1]
+		c.emitOp(bytecode.OP_END_TRY)
+		endJump := c.emitJump(bytecode.OP_JUMP)
+		c.patchJump(catchHandler)
+		c.beginScope()
+		c.setSource(tryStmt.CatchName)
+		c.addLocal(tryStmt.CatchName.Lexeme)
+		c.markInitialized()
+		c.statement(tryStmt.Catch)
+		c.endScope()
+		c.patchJump(endJump)
+	} else {
+		c.statement(tryStmt.Body)
+	}
+
+	if tryStmt.Finally == nil {
+		return nil, nil
+	}
+	fc.tries = fc.tries[:len(fc.tries)-1]
+	c.emitOp(bytecode.OP_END_TRY)
+	c.statement(tryStmt.Finally)
+	endJump := c.emitJump(bytecode.OP_JUMP)
+	c.patchJump(finallyHandler)
+	c.beginScope()
+	c.addLocal("")
+	c.markInitialized()
+	c.statement(tryStmt.Finally)
+	c.emitOp(bytecode.OP_THROW)
+	// OP_THROW consumed the hidden local holding the error.
+	fc.scopeDepth--
+	fc.locals = fc.locals[:len(fc.locals)-1]
+	c.patchJump(endJump)
+	return nil, nil
+}
+
+func (c *Compiler) VisitLiteral(literalExpr expr.LiteralExpr) (interface{}, error) {
+	switch value := literalExpr.Value.(type) {
+	case *loxvalue.Boolean:
+		if value.Value {
+			c.emitOp(bytecode.OP_TRUE)
+		} else {
+			c.emitOp(bytecode.OP_FALSE)
+		}
+	case *loxvalue.Nil:
+		c.emitOp(bytecode.OP_NIL)
+	default:
+		c.emitConstant(value)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitUnary(unaryExpr expr.UnaryExpr) (interface{}, error) {
+	c.expression(unaryExpr.Right)
+	c.setSource(unaryExpr.Operator)
+	switch unaryExpr.Operator.Type {
+	case tkn.MINUS:
+		c.emitOp(bytecode.OP_NEGATE)
+	case tkn.BANG:
+		c.emitOp(bytecode.OP_NOT)
+	case tkn.TILDE:
+		c.emitOp(bytecode.OP_BIT_NOT)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitBinary(binaryExpr expr.BinaryExpr) (interface{}, error) {
+	c.expression(binaryExpr.Left)
+	c.expression(binaryExpr.Right)
+	c.setSource(binaryExpr.Operator)
+	c.binaryOp(binaryExpr.Operator.Type)
+	return nil, nil
+}
+
+// binaryOp emits the instructions of a binary operator, whose operands are
+// on the stack.
+func (c *Compiler) binaryOp(operator tkn.TokenType) {
+	switch operator {
+	case tkn.BANG_EQUAL:
+		c.emitOp(bytecode.OP_EQUAL)
+		c.emitOp(bytecode.OP_NOT)
+	case tkn.EQUAL_EQUAL:
+		c.emitOp(bytecode.OP_EQUAL)
+	case tkn.GREATER:
+		c.emitOp(bytecode.OP_GREATER)
+	case tkn.GREATER_EQUAL:
+		c.emitOp(bytecode.OP_LESS)
+		c.emitOp(bytecode.OP_NOT)
+	case tkn.LESS:
+		c.emitOp(bytecode.OP_LESS)
+	case tkn.LESS_EQUAL:
+		c.emitOp(bytecode.OP_GREATER)
+		c.emitOp(bytecode.OP_NOT)
+	case tkn.PLUS:
+		c.emitOp(bytecode.OP_ADD)
+	case tkn.MINUS:
+		c.emitOp(bytecode.OP_SUBTRACT)
+	case tkn.STAR:
+		c.emitOp(bytecode.OP_MULTIPLY)
+	case tkn.SLASH:
+		c.emitOp(bytecode.OP_DIVIDE)
+	case tkn.TILDE_SLASH:
+		c.emitOp(bytecode.OP_FLOOR_DIVIDE)
+	case tkn.PERCENT:
+		c.emitOp(bytecode.OP_MODULO)
+	case tkn.STAR_STAR:
+		c.emitOp(bytecode.OP_POWER)
+	case tkn.AMPERSAND:
+		c.emitOp(bytecode.OP_BIT_AND)
+	case tkn.PIPE:
+		c.emitOp(bytecode.OP_BIT_OR)
+	case tkn.CARET:
+		c.emitOp(bytecode.OP_BIT_XOR)
+	case tkn.LESS_LESS:
+		c.emitOp(bytecode.OP_SHIFT_LEFT)
+	case tkn.GREATER_GREATER:
+		c.emitOp(bytecode.OP_SHIFT_RIGHT)
+	}
+}
+
+// VisitUpdate reads the target, applies the operator and writes the result
+// back. A property's object is evaluated once and duplicated. A postfix
+// update reads the target a second time, so the old value stays below the
+// result, which is popped after the write.
+func (c *Compiler) VisitUpdate(updateExpr expr.UpdateExpr) (interface{}, error) {
+	switch target := updateExpr.Target.(type) {
+	case *expr.VariableExpr:
+		v := c.resolveVariable(target.Name)
+		c.emitVariable(target.Name, v.getOp, v)
+		if updateExpr.Postfix {
+			c.emitVariable(target.Name, v.getOp, v)
+		}
+		c.updateValue(updateExpr)
+		c.emitVariable(target.Name, v.setOp, v)
+	case expr.GetExpr:
+		c.expression(target.Object)
+		name := c.identifierConstant(target.Name.Lexeme)
+		c.setSource(target.Name)
+		c.emitOp(bytecode.OP_DUP)
+		c.emitOpShort(bytecode.OP_GET_PROPERTY, name)
+		if updateExpr.Postfix {
+			c.emitOp(bytecode.OP_SWAP)
+			c.emitOp(bytecode.OP_DUP)
+			c.emitOpShort(bytecode.OP_GET_PROPERTY, name)
+		}
+		c.updateValue(updateExpr)
+		c.setSource(target.Name)
+		c.emitOpShort(bytecode.OP_SET_PROPERTY, name)
+	}
+	if updateExpr.Postfix {
+		c.emitOp(bytecode.OP_POP)
+	}
+	return nil, nil
+}
+
+// updateValue applies the operator of an update to the old value of its
+// target, which is on the stack.
+func (c *Compiler) updateValue(updateExpr expr.UpdateExpr) {
+	if updateExpr.Value == nil {
+		c.setSource(updateExpr.Operator)
+		if updateExpr.Operator.Type == tkn.PLUS_PLUS {
+			c.emitOp(bytecode.OP_INCREMENT)
+		} else {
+			c.emitOp(bytecode.OP_DECREMENT)
+		}
+		return
+	}
+	c.expression(updateExpr.Value)
+	c.setSource(updateExpr.Operator)
+	c.binaryOp(tkn.BinaryOperator(updateExpr.Operator.Type))
+}
+
+func (c *Compiler) VisitGrouping(groupingExpr expr.GroupingExpr) (interface{}, error) {
+	c.expression(groupingExpr.Expr)
+	return nil, nil
+}
+
+func (c *Compiler) VisitVariable(variableExpr *expr.VariableExpr) (interface{}, error) {
+	c.namedVariable(variableExpr.Name, nil)
+	return nil, nil
+}
+
+func (c *Compiler) VisitAssing(assignExpr *expr.AssignExpr) (interface{}, error) {
+	c.namedVariable(assignExpr.Name, assignExpr.Right)
+	return nil, nil
+}
+
+func (c *Compiler) VisitLogical(logicalExpr expr.LogicalExpr) (interface{}, error) {
+	c.expression(logicalExpr.Left)
+	c.setSource(logicalExpr.Operator)
+	if logicalExpr.Operator.Type == tkn.AND {
+		endJump := c.emitJump(bytecode.OP_JUMP_IF_FALSE)
+		c.emitOp(bytecode.OP_POP)
+		c.expression(logicalExpr.Right)
+		c.patchJump(endJump)
+		return nil, nil
+	}
+	jump := bytecode.OP_JUMP_IF_FALSE
+	if logicalExpr.Operator.Type == tkn.QUESTION_QUESTION {
+		jump = bytecode.OP_JUMP_IF_NIL
+	}
+	elseJump := c.emitJump(jump)
+	endJump := c.emitJump(bytecode.OP_JUMP)
+	c.patchJump(elseJump)
+	c.emitOp(bytecode.OP_POP)
+	c.expression(logicalExpr.Right)
+	c.patchJump(endJump)
+	return nil, nil
+}
+
+func (c *Compiler) VisitFunction(functionExpr expr.FunctionExpr) (interface{}, error) {
+	c.function(stmt.Declaration(functionExpr), TYPE_FUNCTION)
+	return nil, nil
+}
+
+func (c *Compiler) VisitConditional(conditionalExpr expr.ConditionalExpr) (interface{}, error) {
+	c.expression(conditionalExpr.Condition)
+	c.setSource(conditionalExpr.Question)
+	thenJump := c.emitJump(bytecode.OP_JUMP_IF_FALSE)
+	c.emitOp(bytecode.OP_POP)
+	c.expression(conditionalExpr.Then)
+	elseJump := c.emitJump(bytecode.OP_JUMP)
+	c.patchJump(thenJump)
+	c.emitOp(bytecode.OP_POP)
+	c.expression(conditionalExpr.Else)
+	c.patchJump(elseJump)
+	return nil, nil
+}
+
+// chain compiles the object or callee of a property access or call. The
+// jumps of every '?.' along a chain of accesses and calls are collected in
+// exits and patched to its end, leaving the nil that was tested as the value
+// of the whole chain.
+func (c *Compiler) chain(e expr.Expr, exits *[]int) {
+	switch e := e.(type) {
+	case expr.GetExpr:
+		c.get(e, exits)
+	case expr.CallExpr:
+		c.call(e, exits)
+	default:
+		c.expression(e)
+	}
+}
+
+func (c *Compiler) skipIfNil(optional bool, exits *[]int) {
+	if optional {
+		*exits = append(*exits, c.emitJump(bytecode.OP_JUMP_IF_NIL))
+	}
+}
+
+func (c *Compiler) patchJumps(jumps []int) {
+	for _, jump := range jumps {
+		c.patchJump(jump)
+	}
+}
+
+func (c *Compiler) arguments(arguments []expr.Expr) {
+	for _, argument := range arguments {
+		c.expression(argument)
+	}
+}
+
+func (c *Compiler) VisitCall(callExpr expr.CallExpr) (interface{}, error) {
+	exits := []int{}
+	c.call(callExpr, &exits)
+	c.patchJumps(exits)
+	return nil, nil
+}
+
+// call fuses a property access or super access followed by a call into a
+// single invoke instruction, avoiding a bound method allocation. A call
+// written with '?.' tests the method itself, so it is not fused.
+func (c *Compiler) call(callExpr expr.CallExpr, exits *[]int) {
+	switch callee := callExpr.Callee.(type) {
+	case expr.GetExpr:
+		if callExpr.Optional {
+			break
+		}
+		c.chain(callee.Object, exits)
+		c.setSource(callee.Name)
+		c.skipIfNil(callee.Optional, exits)
+		c.arguments(callExpr.Arguments)
+		c.setSource(callee.Name)
+		c.emitOpShort(bytecode.OP_INVOKE, c.identifierConstant(callee.Name.Lexeme))
+		c.emitByte(byte(len(callExpr.Arguments)))
+		return
+	case *expr.SuperExpr:
+		c.namedVariable(c.thisToken(callee.Keyword.Line), nil)
+		c.arguments(callExpr.Arguments)
+		c.namedVariable(callee.Keyword, nil)
+		c.setSource(callee.Method)
+		c.emitOpShort(bytecode.OP_SUPER_INVOKE, c.identifierConstant(callee.Method.Lexeme))
+		c.emitByte(byte(len(callExpr.Arguments)))
+		return
+	}
+	c.chain(callExpr.Callee, exits)
+	c.setSource(callExpr.Paren)
+	c.skipIfNil(callExpr.Optional, exits)
+	c.arguments(callExpr.Arguments)
+	c.setSource(callExpr.Paren)
+	c.emitOpByte(bytecode.OP_CALL, len(callExpr.Arguments))
+}
+
+// VisitInterpolation pushes the text and the value of every part and joins
+// them into one string. OP_INTERPOLATE takes at most 255 values, so longer
+// strings are joined in batches, each batch starting with the previous one.
+func (c *Compiler) VisitInterpolation(interpolationExpr expr.InterpolationExpr) (interface{}, error) {
+	c.line = interpolationExpr.Span.Start.Line
+	c.span = interpolationExpr.Span
+	count := 0
+	part := func(emit func()) {
+		if count == MAX_INTERPOLATION_PARTS {
+			c.emitOpByte(bytecode.OP_INTERPOLATE, count)
+			count = 1
+		}
+		emit()
+		count++
+	}
+	text := func(text string) {
+		if text != "" {
+			part(func() { c.emitConstant(loxvalue.NewString(text)) })
+		}
+	}
+	text(interpolationExpr.Strings[0])
+	for i, e := range interpolationExpr.Exprs {
+		part(func() { c.expression(e) })
+		text(interpolationExpr.Strings[i+1])
+	}
+	c.emitOpByte(bytecode.OP_INTERPOLATE, count)
+	return nil, nil
+}
+
+func (c *Compiler) VisitGet(getExpr expr.GetExpr) (interface{}, error) {
+	exits := []int{}
+	c.get(getExpr, &exits)
+	c.patchJumps(exits)
+	return nil, nil
+}
+
+func (c *Compiler) get(getExpr expr.GetExpr, exits *[]int) {
+	c.chain(getExpr.Object, exits)
+	c.setSource(getExpr.Name)
+	c.skipIfNil(getExpr.Optional, exits)
+	c.emitOpShort(bytecode.OP_GET_PROPERTY, c.identifierConstant(getExpr.Name.Lexeme))
+}
+
+func (c *Compiler) VisitSet(setExpr expr.SetExpr) (interface{}, error) {
+	c.expression(setExpr.Object)
+	c.expression(setExpr.Value)
+	c.setSource(setExpr.Name)
+	c.emitOpShort(bytecode.OP_SET_PROPERTY, c.identifierConstant(setExpr.Name.Lexeme))
+	return nil, nil
+}
+
+func (c *Compiler) VisitThis(thisExpr *expr.ThisExpr) (interface{}, error) {
+	c.namedVariable(thisExpr.Keyword, nil)
+	return nil, nil
+}
+
+func (c *Compiler) VisitSuper(superExpr *expr.SuperExpr) (interface{}, error) {
+	c.namedVariable(c.thisToken(superExpr.Keyword.Line), nil)
+	c.namedVariable(superExpr.Keyword, nil)
+	c.setSource(superExpr.Method)
+	c.emitOpShort(bytecode.OP_GET_SUPER, c.identifierConstant(superExpr.Method.Lexeme))
+	return nil, nil
+}
@@ -0,0 +1,276 @@
+package compiler_test
+
+import (
+	"bytes"
+	"golox/bytecode"
+	"golox/compiler"
+	"golox/parser"
+	"golox/scanner"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompiler_Expressions(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"print 1 + 2;", `== <script> ==
+0000    1 OP_CONSTANT         0 1
+0003    | OP_CONSTANT         1 2
+0006    | OP_ADD
+0007    | OP_PRINT
+0008    | OP_NIL
+0009    | OP_RETURN
+`},
+		{"!(1 <= 2);", `== <script> ==
+0000    1 OP_CONSTANT         0 1
+0003    | OP_CONSTANT         1 2
+0006    | OP_GREATER
+0007    | OP_NOT
+0008    | OP_NOT
+0009    | OP_POP
+0010    | OP_NIL
+0011    | OP_RETURN
+`},
+		{"print \"a${1}\";", `== <script> ==
+0000    1 OP_CONSTANT         0 "a"
+0003    | OP_CONSTANT         1 1
+0006    | OP_INTERPOLATE      2
+0008    | OP_PRINT
+0009    | OP_NIL
+0010    | OP_RETURN
+`},
+		{"nil or true;", `== <script> ==
+0000    1 OP_NIL
+0001    | OP_JUMP_IF_FALSE    1 -> 7
+0004    | OP_JUMP             4 -> 9
+0007    | OP_POP
+0008    | OP_TRUE
+0009    | OP_POP
+0010    | OP_NIL
+0011    | OP_RETURN
+`},
+		{"var a; a?.b ?? 1;", `== <script> ==
+0000    1 OP_NIL
+0001    | OP_DEFINE_GLOBAL    0 "a"
+0004    | OP_GET_GLOBAL       1 "a"
+0007    | OP_JUMP_IF_NIL      7 -> 13
+0010    | OP_GET_PROPERTY     2 "b"
+0013    | OP_JUMP_IF_NIL     13 -> 19
+0016    | OP_JUMP            16 -> 23
+0019    | OP_POP
+0020    | OP_CONSTANT         3 1
+0023    | OP_POP
+0024    | OP_NIL
+0025    | OP_RETURN
+`},
+		{"var a; a.b++;", `== <script> ==
+0000    1 OP_NIL
+0001    | OP_DEFINE_GLOBAL    0 "a"
+0004    | OP_GET_GLOBAL       1 "a"
+0007    | OP_DUP
+0008    | OP_GET_PROPERTY     2 "b"
+0011    | OP_SWAP
+0012    | OP_DUP
+0013    | OP_GET_PROPERTY     2 "b"
+0016    | OP_INCREMENT
+0017    | OP_SET_PROPERTY     2 "b"
+0020    | OP_POP
+0021    | OP_POP
+0022    | OP_NIL
+0023    | OP_RETURN
+`},
+	}
+
+	for _, test := range tests {
+		testDisassembly(t, test.input, test.expected)
+	}
+
+}
+
+func TestCompiler_Variables(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var a = 1;\na = a;", `== <script> ==
+0000    1 OP_CONSTANT         0 1
+0003    | OP_DEFINE_GLOBAL    1 "a"
+0006    2 OP_GET_GLOBAL       3 "a"
+0009    | OP_SET_GLOBAL       2 "a"
+0012    | OP_POP
+0013    | OP_NIL
+0014    | OP_RETURN
+`},
+		{"{ var a; a = a; }", `== <script> ==
+0000    1 OP_NIL
+0001    | OP_GET_LOCAL        1
+0003    | OP_SET_LOCAL        1
+0005    | OP_POP
+0006    | OP_POP
+0007    | OP_NIL
+0008    | OP_RETURN
+`},
+	}
+
+	for _, test := range tests {
+		testDisassembly(t, test.input, test.expected)
+	}
+
+}
+
+func TestCompiler_Closures(t *testing.T) {
+
+	input := `fun outer() {
+  var x = 1;
+  fun inner() { return x; }
+  return inner;
+}`
+	expected := `== <script> ==
+0000    1 OP_CLOSURE          0 <fn outer>
+0003    | OP_DEFINE_GLOBAL    1 "outer"
+0006    | OP_NIL
+0007    | OP_RETURN
+
+== <fn outer> ==
+0000    2 OP_CONSTANT         0 1
+0003    3 OP_CLOSURE          1 <fn inner>
+0006    |                     local 1
+0008    4 OP_GET_LOCAL        2
+0010    | OP_RETURN
+0011    | OP_NIL
+0012    | OP_RETURN
+
+== <fn inner> ==
+0000    3 OP_GET_UPVALUE      0
+0002    | OP_RETURN
+0003    | OP_NIL
+0004    | OP_RETURN
+`
+	testDisassembly(t, input, expected)
+
+}
+
+func TestCompiler_AnonymousFunctions(t *testing.T) {
+
+	input := "var f = (a) => a;"
+	expected := `== <script> ==
+0000    1 OP_CLOSURE          0 <fn anonymous>
+0003    | OP_DEFINE_GLOBAL    1 "f"
+0006    | OP_NIL
+0007    | OP_RETURN
+
+== <fn anonymous> ==
+0000    1 OP_GET_LOCAL        1
+0002    | OP_RETURN
+0003    | OP_NIL
+0004    | OP_RETURN
+`
+	testDisassembly(t, input, expected)
+
+}
+
+func TestCompiler_Methods(t *testing.T) {
+
+	input := `class A {
+  init() { this.x = 1; }
+  m() { this.m(); }
+}`
+	expected := `== <script> ==
+0000    1 OP_CLASS            0 "A"
+0003    | OP_DEFINE_GLOBAL    1 "A"
+0006    | OP_GET_GLOBAL       2 "A"
+0009    2 OP_CLOSURE          4 <fn init>
+0012    | OP_METHOD           3 "init"
+0015    3 OP_CLOSURE          6 <fn m>
+0018    | OP_METHOD           5 "m"
+0021    | OP_POP
+0022    | OP_NIL
+0023    | OP_RETURN
+
+== <fn init> ==
+0000    2 OP_GET_LOCAL        0
+0002    | OP_CONSTANT         0 1
+0005    | OP_SET_PROPERTY     1 "x"
+0008    | OP_POP
+0009    | OP_GET_LOCAL        0
+0011    | OP_RETURN
+
+== <fn m> ==
+0000    3 OP_GET_LOCAL        0
+0002    | OP_INVOKE        (0 args)    0 "m"
+0006    | OP_POP
+0007    | OP_NIL
+0008    | OP_RETURN
+`
+	testDisassembly(t, input, expected)
+
+}
+
+// TestCompiler_Exceptions checks that a return inside try statements drops
+// their handlers and runs the finally clause before returning.
+func TestCompiler_Exceptions(t *testing.T) {
+
+	input := `fun f() {
+  try {
+    return 1;
+  } catch (e) {
+    throw e;
+  } finally {
+    print 2;
+  }
+}`
+	expected := `== <script> ==
+0000    1 OP_CLOSURE          0 <fn f>
+0003    | OP_DEFINE_GLOBAL    1 "f"
+0006    | OP_NIL
+0007    | OP_RETURN
+
+== <fn f> ==
+0000    2 OP_TRY_FINALLY      0 -> 32
+0003    | OP_TRY              3 -> 20
+0006    3 OP_CONSTANT         0 1
+0009    | OP_END_TRY
+0010    | OP_END_TRY
+0011    | OP_CONSTANT         1 2
+0014    | OP_PRINT
+0015    | OP_RETURN
+0016    | OP_END_TRY
+0017    | OP_JUMP            17 -> 24
+0020    5 OP_GET_LOCAL        1
+0022    | OP_THROW
+0023    | OP_POP
+0024    | OP_END_TRY
+0025    | OP_CONSTANT         2 2
+0028    | OP_PRINT
+0029    | OP_JUMP            29 -> 37
+0032    | OP_CONSTANT         3 2
+0035    | OP_PRINT
+0036    | OP_THROW
+0037    | OP_NIL
+0038    | OP_RETURN
+`
+	testDisassembly(t, input, expected)
+
+}
+
+func testDisassembly(t *testing.T, input string, expected string) {
+
+	scanner := scanner.NewScanner(input)
+	tokens, errors := scanner.Scan()
+	require.Empty(t, errors)
+	parser := parser.NewParser(tokens)
+	statements, errors := parser.Parse()
+	require.Empty(t, errors)
+	function, errors := compiler.NewCompiler().Compile(statements)
+	require.Empty(t, errors)
+
+	out := &bytes.Buffer{}
+	bytecode.Disassemble(out, function)
+	require.Equal(t, expected, out.String())
+
+}
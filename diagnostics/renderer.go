@@ -0,0 +1,189 @@
+package diagnostics
+
+import (
+	"fmt"
+	loxerror "golox/error"
+	tkn "golox/token"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// MAX_EXCERPT_LINES bounds the source lines shown for one span; longer
+// spans show their first and last lines around an ellipsis.
+const MAX_EXCERPT_LINES = 5
+
+const (
+	colorReset = "\x1b[0m"
+	colorBold  = "\x1b[1m"
+	colorRed   = "\x1b[1;31m"
+	colorGreen = "\x1b[1;32m"
+	colorBlue  = "\x1b[1;34m"
+	colorCyan  = "\x1b[1;36m"
+)
+
+// Renderer formats errors for people. Each error gets a header with its
+// message, a file:line:column location, the source lines it covers with the
+// span underlined, and then its notes and hint:
+//
+//	error: Operands must be two numbers or two strings.
+//	 --> script.lox:2:9
+//	  |
+//	2 | print a + "x";
+//	  |         ^
+//	  = hint: ...
+//	  at add (script.lox:2)
+//	  at script (script.lox:5)
+type Renderer struct {
+	file  string
+	lines []string
+	color bool
+}
+
+// NewRenderer returns a renderer for errors in source, which was read from
+// file. file may be empty for code typed at the prompt, and source for a
+// compiled image, whose errors are then shown without excerpts.
+func NewRenderer(file, source string, color bool) *Renderer {
+	renderer := &Renderer{file: file, color: color}
+	if source != "" {
+		renderer.lines = strings.Split(source, "\n")
+	}
+	return renderer
+}
+
+func (r *Renderer) Render(w io.Writer, err error) {
+	loxErr, ok := err.(*loxerror.Error)
+	if !ok {
+		fmt.Fprintf(w, "%s %s\n", r.paint(colorRed, "error:"), r.paint(colorBold, err.Error()))
+		return
+	}
+
+	fmt.Fprintf(w, "%s %s\n", r.paint(colorRed, "error:"), r.paint(colorBold, loxErr.Message))
+	gutter := r.gutterWidth(loxErr)
+	r.excerpt(w, gutter, loxErr.Line, loxErr.Span, "^", colorRed)
+
+	for _, note := range loxErr.Notes {
+		if note.Span.IsZero() {
+			fmt.Fprintf(w, "%s %s %s %s\n", r.pad(gutter), r.paint(colorBlue, "="), r.paint(colorCyan, "note:"), note.Message)
+			continue
+		}
+		fmt.Fprintf(w, "%s %s\n", r.paint(colorCyan, "note:"), note.Message)
+		r.excerpt(w, gutter, note.Span.Start.Line, note.Span, "-", colorCyan)
+	}
+	if loxErr.Hint != "" {
+		fmt.Fprintf(w, "%s %s %s %s\n", r.pad(gutter), r.paint(colorBlue, "="), r.paint(colorGreen, "hint:"), loxErr.Hint)
+	}
+	r.trace(w, loxErr.Trace)
+}
+
+func (r *Renderer) trace(w io.Writer, trace []loxerror.Frame) {
+	for _, line := range loxerror.TraceLines(trace, r.file) {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+}
+
+// RenderAll renders errors one after another, separated by blank lines.
+func (r *Renderer) RenderAll(w io.Writer, errors []error) {
+	for i, err := range errors {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		r.Render(w, err)
+	}
+}
+
+// excerpt writes the location of span and the source lines it covers,
+// underlined with marker. Without a span it shows the whole of line.
+func (r *Renderer) excerpt(w io.Writer, gutter int, line int, span tkn.Span, marker string, color string) {
+	location := fmt.Sprintf("%d", line)
+	if !span.IsZero() {
+		line = span.Start.Line
+		location = fmt.Sprintf("%d:%d", span.Start.Line, span.Start.Column)
+	}
+	if r.file != "" {
+		location = r.file + ":" + location
+	}
+	fmt.Fprintf(w, "%s %s\n", r.pad(gutter)+r.paint(colorBlue, "-->"), location)
+	if line < 1 || line > len(r.lines) {
+		return
+	}
+
+	bar := r.paint(colorBlue, "|")
+	fmt.Fprintf(w, "%s %s\n", r.pad(gutter), bar)
+	if span.IsZero() {
+		fmt.Fprintf(w, "%s %s %s\n", r.paint(colorBlue, fmt.Sprintf("%*d", gutter, line)), bar, r.lines[line-1])
+		return
+	}
+
+	last := span.End.Line
+	if last > len(r.lines) {
+		last = len(r.lines)
+	}
+	for number := span.Start.Line; number <= last; number++ {
+		if last-span.Start.Line+1 > MAX_EXCERPT_LINES && number > span.Start.Line+1 && number < last-1 {
+			if number == span.Start.Line+2 {
+				fmt.Fprintf(w, "%s\n", r.paint(colorBlue, "..."))
+			}
+			continue
+		}
+		text := r.lines[number-1]
+		fmt.Fprintf(w, "%s %s %s\n", r.paint(colorBlue, fmt.Sprintf("%*d", gutter, number)), bar, text)
+
+		from := len(text) - len(strings.TrimLeft(text, " \t")) + 1
+		if number == span.Start.Line {
+			from = span.Start.Column
+		}
+		to := utf8.RuneCountInString(text) + 1
+		if number == span.End.Line {
+			to = span.End.Column
+		}
+		width := to - from
+		if width < 1 {
+			width = 1
+		}
+		fmt.Fprintf(w, "%s %s %s%s\n", r.pad(gutter), bar, indentation(text, from-1), r.paint(color, strings.Repeat(marker, width)))
+	}
+}
+
+// gutterWidth returns the width of the widest line number err shows.
+func (r *Renderer) gutterWidth(err *loxerror.Error) int {
+	widest := err.Line
+	if err.Span.End.Line > widest {
+		widest = err.Span.End.Line
+	}
+	for _, note := range err.Notes {
+		if note.Span.End.Line > widest {
+			widest = note.Span.End.Line
+		}
+	}
+	return len(fmt.Sprintf("%d", widest))
+}
+
+func (r *Renderer) pad(width int) string {
+	return strings.Repeat(" ", width)
+}
+
+func (r *Renderer) paint(color, text string) string {
+	if !r.color {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// indentation returns blanks as wide as the first n characters of text, keeping
+// its tabs so that markers line up with the source above them.
+func indentation(text string, n int) string {
+	chars := []rune(text)
+	if n > len(chars) {
+		n = len(chars)
+	}
+	var blanks strings.Builder
+	for i := 0; i < n; i++ {
+		if chars[i] == '\t' {
+			blanks.WriteByte('\t')
+		} else {
+			blanks.WriteByte(' ')
+		}
+	}
+	return blanks.String()
+}
@@ -0,0 +1,105 @@
+package diagnostics_test
+
+import (
+	"errors"
+	"golox/diagnostics"
+	loxerror "golox/error"
+	tkn "golox/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderer_Render(t *testing.T) {
+
+	source := "var a = 1;\n{\n\tvar a;\n\tvar a;\n}\nprint a +\n  \"x\";\n"
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"caret", &loxerror.Error{Line: 6, Message: "Bad operand.", Span: span(6, 7)},
+			"error: Bad operand.\n --> test.lox:6:7\n  |\n6 | print a +\n  |       ^\n"},
+		{"tabs", &loxerror.Error{Line: 4, Message: "Already declared.", Span: span(4, 6),
+			Notes: []loxerror.Note{{Message: "First declared here.", Span: span(3, 6)}}},
+			"error: Already declared.\n --> test.lox:4:6\n  |\n4 | \tvar a;\n  | \t    ^\nnote: First declared here.\n --> test.lox:3:6\n  |\n3 | \tvar a;\n  | \t    -\n"},
+		{"multiline", &loxerror.Error{Line: 6, Message: "Bad sum.", Span: tkn.Span{
+			Start: tkn.Position{Line: 6, Column: 7}, End: tkn.Position{Line: 7, Column: 6}}},
+			"error: Bad sum.\n --> test.lox:6:7\n  |\n6 | print a +\n  |       ^^^\n7 |   \"x\";\n  |   ^^^\n"},
+		{"hint", &loxerror.Error{Line: 1, Message: "Undefined.", Span: span(1, 5), Hint: "Declare it."},
+			"error: Undefined.\n --> test.lox:1:5\n  |\n1 | var a = 1;\n  |     ^\n  = hint: Declare it.\n"},
+		{"no span", &loxerror.Error{Line: 6, Message: "Runtime."},
+			"error: Runtime.\n --> test.lox:6\n  |\n6 | print a +\n"},
+		{"outside source", &loxerror.Error{Line: 40, Message: "Lost."},
+			"error: Lost.\n  --> test.lox:40\n"},
+		{"plain", errors.New("failed"), "error: failed\n"},
+		{"trace", &loxerror.Error{Line: 6, Message: "Runtime.", Trace: []loxerror.Frame{{Function: "f", Line: 6}, {Function: "script", File: "main.lox", Line: 1}}},
+			"error: Runtime.\n --> test.lox:6\n  |\n6 | print a +\n  at f (test.lox:6)\n  at script (main.lox:1)\n"},
+	}
+
+	for _, test := range tests {
+		var out strings.Builder
+		diagnostics.NewRenderer("test.lox", source, false).Render(&out, test.err)
+		require.Equal(t, test.expected, out.String(), test.name)
+	}
+
+}
+
+func TestRenderer_NoSource(t *testing.T) {
+
+	var out strings.Builder
+	diagnostics.NewRenderer("test.loxc", "", false).Render(&out, &loxerror.Error{Line: 1, Message: "Bad operand.", Span: span(1, 3)})
+	require.Equal(t, "error: Bad operand.\n --> test.loxc:1:3\n", out.String())
+
+}
+
+func TestRenderer_Unicode(t *testing.T) {
+
+	var out strings.Builder
+	err := &loxerror.Error{Line: 1, Message: "Undefined.", Span: tkn.Span{
+		Start: tkn.Position{Line: 1, Column: 13}, End: tkn.Position{Line: 1, Column: 14}}}
+	diagnostics.NewRenderer("test.lox", "print \"é\" + ü;", false).Render(&out, err)
+	require.Equal(t, "error: Undefined.\n --> test.lox:1:13\n  |\n1 | print \"é\" + ü;\n  |             ^\n", out.String())
+
+}
+
+func TestRenderer_RenderAll(t *testing.T) {
+
+	var out strings.Builder
+	diagnostics.NewRenderer("", "", false).RenderAll(&out, []error{errors.New("a"), errors.New("b")})
+	require.Equal(t, "error: a\n\nerror: b\n", out.String())
+
+}
+
+func TestRenderer_LongTrace(t *testing.T) {
+
+	trace := []loxerror.Frame{}
+	for i := 0; i < 30; i++ {
+		trace = append(trace, loxerror.Frame{Function: "f", Line: 1})
+	}
+	var out strings.Builder
+	diagnostics.NewRenderer("", "", false).Render(&out, &loxerror.Error{Line: 1, Message: "Stack overflow.", Trace: trace})
+	require.Equal(t, 2+loxerror.MAX_TRACE_FRAMES+1, strings.Count(out.String(), "\n"))
+	require.Contains(t, out.String(), "  ... 10 more frames\n")
+
+}
+
+func TestRenderer_Color(t *testing.T) {
+
+	var out strings.Builder
+	diagnostics.NewRenderer("", "print;", true).Render(&out, &loxerror.Error{Line: 1, Message: "Bad.", Span: span(1, 6)})
+	require.True(t, strings.HasPrefix(out.String(), "\x1b[1;31merror:\x1b[0m \x1b[1mBad.\x1b[0m\n \x1b[1;34m-->\x1b[0m 1:6\n"))
+	require.Contains(t, out.String(), "\x1b[1;31m^\x1b[0m")
+
+}
+
+// span returns the one-byte span at column on line. The renderer does not
+// use offsets, so they are left zero.
+func span(line, column int) tkn.Span {
+	return tkn.Span{
+		Start: tkn.Position{Line: line, Column: column},
+		End:   tkn.Position{Line: line, Column: column + 1},
+	}
+}
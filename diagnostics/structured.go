@@ -0,0 +1,226 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	loxerror "golox/error"
+	tkn "golox/token"
+	"io"
+)
+
+const SEVERITY_ERROR = "error"
+
+const SARIF_VERSION = "2.1.0"
+const SARIF_SCHEMA = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// Record is the machine-readable form of one error. Span is omitted when the
+// error has none, as for errors raised by the virtual machine. Trace is the
+// stack trace of runtime errors raised inside functions, innermost first.
+type Record struct {
+	Severity string           `json:"severity"`
+	Code     string           `json:"code"`
+	Message  string           `json:"message"`
+	File     string           `json:"file,omitempty"`
+	Line     int              `json:"line"`
+	Span     *tkn.Span        `json:"span,omitempty"`
+	Notes    []Note           `json:"notes,omitempty"`
+	Hint     string           `json:"hint,omitempty"`
+	Trace    []loxerror.Frame `json:"trace,omitempty"`
+}
+
+type Note struct {
+	Message string    `json:"message"`
+	Span    *tkn.Span `json:"span,omitempty"`
+}
+
+// NewRecord describes err, an error in file. Errors that are not Lox errors
+// get the unknown code and no location; Lox errors built without NewError
+// have no code and get the unknown one too.
+func NewRecord(file string, err error) Record {
+	loxErr, ok := err.(*loxerror.Error)
+	if !ok {
+		return Record{Severity: SEVERITY_ERROR, Code: loxerror.UNKNOWN_CODE, Message: err.Error(), File: file}
+	}
+	record := Record{
+		Severity: SEVERITY_ERROR,
+		Code:     loxErr.Code,
+		Message:  loxErr.Message,
+		File:     file,
+		Line:     loxErr.Line,
+		Span:     optionalSpan(loxErr.Span),
+		Hint:     loxErr.Hint,
+	}
+	if record.Code == "" {
+		record.Code = loxerror.UNKNOWN_CODE
+	}
+	for _, note := range loxErr.Notes {
+		record.Notes = append(record.Notes, Note{Message: note.Message, Span: optionalSpan(note.Span)})
+	}
+	for _, frame := range loxErr.Trace {
+		if frame.File == "" {
+			frame.File = file
+		}
+		record.Trace = append(record.Trace, frame)
+	}
+	return record
+}
+
+// WriteJSON writes errors as JSON lines, one record per error.
+func WriteJSON(w io.Writer, file string, errors []error) error {
+	encoder := json.NewEncoder(w)
+	for _, err := range errors {
+		if err := encoder.Encode(NewRecord(file, err)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSARIF writes errors as a SARIF log with a single run, which is
+// written even when there are no errors so that CI sees a clean result.
+func WriteSARIF(w io.Writer, file string, errors []error) error {
+	rules := []sarifRule{}
+	seen := map[string]bool{}
+	results := []sarifResult{}
+	for _, err := range errors {
+		record := NewRecord(file, err)
+		if !seen[record.Code] {
+			seen[record.Code] = true
+			description := loxerror.Description(record.Code)
+			if description == "" {
+				description = record.Message
+			}
+			rules = append(rules, sarifRule{ID: record.Code, ShortDescription: sarifMessage{Text: description}})
+		}
+		result := sarifResult{
+			RuleID:    record.Code,
+			Level:     record.Severity,
+			Message:   sarifMessage{Text: record.Message},
+			Locations: []sarifLocation{newSarifLocation(file, record.Line, record.Span, "")},
+		}
+		for _, note := range record.Notes {
+			result.RelatedLocations = append(result.RelatedLocations, newSarifLocation(file, 0, note.Span, note.Message))
+		}
+		if record.Hint != "" {
+			result.Properties = map[string]string{"hint": record.Hint}
+		}
+		if len(record.Trace) > 0 {
+			stack := sarifStack{Message: sarifMessage{Text: "Stack trace"}}
+			for _, frame := range record.Trace {
+				stack.Frames = append(stack.Frames, sarifStackFrame{Location: newSarifLocation(frame.File, frame.Line, nil, frame.Function)})
+			}
+			result.Stacks = []sarifStack{stack}
+		}
+		results = append(results, result)
+	}
+
+	log := sarifLog{
+		Schema:  SARIF_SCHEMA,
+		Version: SARIF_VERSION,
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "golox", Rules: rules}},
+			Results: results,
+		}},
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+func optionalSpan(span tkn.Span) *tkn.Span {
+	if span.IsZero() {
+		return nil
+	}
+	return &span
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID           string            `json:"ruleId"`
+	Level            string            `json:"level"`
+	Message          sarifMessage      `json:"message"`
+	Locations        []sarifLocation   `json:"locations"`
+	RelatedLocations []sarifLocation   `json:"relatedLocations,omitempty"`
+	Stacks           []sarifStack      `json:"stacks,omitempty"`
+	Properties       map[string]string `json:"properties,omitempty"`
+}
+
+type sarifStack struct {
+	Message sarifMessage      `json:"message"`
+	Frames  []sarifStackFrame `json:"frames"`
+}
+
+type sarifStackFrame struct {
+	Location sarifLocation `json:"location"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation *sarifArtifactLocation `json:"artifactLocation,omitempty"`
+	Region           *sarifRegion           `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// newSarifLocation locates span in file, or the whole of line when there is
+// no span. message labels related locations.
+func newSarifLocation(file string, line int, span *tkn.Span, message string) sarifLocation {
+	location := sarifLocation{}
+	if file != "" {
+		location.PhysicalLocation.ArtifactLocation = &sarifArtifactLocation{URI: file}
+	}
+	if span != nil {
+		location.PhysicalLocation.Region = &sarifRegion{
+			StartLine:   span.Start.Line,
+			StartColumn: span.Start.Column,
+			EndLine:     span.End.Line,
+			EndColumn:   span.End.Column,
+		}
+	} else if line > 0 {
+		location.PhysicalLocation.Region = &sarifRegion{StartLine: line}
+	}
+	if message != "" {
+		location.Message = &sarifMessage{Text: message}
+	}
+	return location
+}
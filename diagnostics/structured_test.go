@@ -0,0 +1,68 @@
+package diagnostics_test
+
+import (
+	"encoding/json"
+	"errors"
+	"golox/diagnostics"
+	loxerror "golox/error"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJSON(t *testing.T) {
+
+	errs := []error{
+		(&loxerror.Error{Line: 1, Message: loxerror.RESOLVER_ERROR_ALREADY_DECLARED, Code: "LOX0302", Span: span(1, 14)}).WithNote(span(1, 7), "First.").WithHint("Rename it."),
+		&loxerror.Error{Line: 2, Message: "Undefined variable 'b'.", Code: "LOX0501", Trace: []loxerror.Frame{{Function: "f", Line: 2}}},
+		errors.New("failed"),
+	}
+	var out strings.Builder
+	require.NoError(t, diagnostics.WriteJSON(&out, "test.lox", errs))
+
+	expected := `{"severity":"error","code":"LOX0302","message":"Already a variable with this name in this scope.","file":"test.lox","line":1,` +
+		`"span":{"start":{"line":1,"column":14,"offset":0},"end":{"line":1,"column":15,"offset":0}},` +
+		`"notes":[{"message":"First.","span":{"start":{"line":1,"column":7,"offset":0},"end":{"line":1,"column":8,"offset":0}}}],"hint":"Rename it."}
+{"severity":"error","code":"LOX0501","message":"Undefined variable 'b'.","file":"test.lox","line":2,"trace":[{"function":"f","file":"test.lox","line":2}]}
+{"severity":"error","code":"LOX0000","message":"failed","file":"test.lox","line":0}
+`
+	require.Equal(t, expected, out.String())
+
+}
+
+func TestWriteSARIF(t *testing.T) {
+
+	var out strings.Builder
+	require.NoError(t, diagnostics.WriteSARIF(&out, "test.lox", []error{
+		(&loxerror.Error{Line: 3, Message: "Undefined variable 'a'.", Code: "LOX0501", Span: span(3, 7)}).WithNote(span(1, 5), "Here."),
+		&loxerror.Error{Line: 4, Message: "Undefined variable 'b'.", Code: "LOX0501", Trace: []loxerror.Frame{{Function: "f", Line: 4}, {Function: "script", Line: 9}}},
+	}))
+
+	var log map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &log))
+	require.Equal(t, "2.1.0", log["version"])
+	run := log["runs"].([]interface{})[0].(map[string]interface{})
+	rules := run["tool"].(map[string]interface{})["driver"].(map[string]interface{})["rules"].([]interface{})
+	require.Equal(t, []interface{}{map[string]interface{}{"id": "LOX0501", "shortDescription": map[string]interface{}{"text": "Undefined variable '%s'."}}}, rules)
+
+	results := run["results"].([]interface{})
+	require.Len(t, results, 2)
+	first := results[0].(map[string]interface{})
+	require.Equal(t, "LOX0501", first["ruleId"])
+	require.Equal(t, "error", first["level"])
+	region := first["locations"].([]interface{})[0].(map[string]interface{})["physicalLocation"].(map[string]interface{})["region"]
+	require.Equal(t, map[string]interface{}{"startLine": 3.0, "startColumn": 7.0, "endLine": 3.0, "endColumn": 8.0}, region)
+	related := first["relatedLocations"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{"text": "Here."}, related["message"])
+	region = results[1].(map[string]interface{})["locations"].([]interface{})[0].(map[string]interface{})["physicalLocation"].(map[string]interface{})["region"]
+	require.Equal(t, map[string]interface{}{"startLine": 4.0}, region)
+	frames := results[1].(map[string]interface{})["stacks"].([]interface{})[0].(map[string]interface{})["frames"].([]interface{})
+	require.Len(t, frames, 2)
+	require.Equal(t, map[string]interface{}{"text": "f"}, frames[0].(map[string]interface{})["location"].(map[string]interface{})["message"])
+
+	out.Reset()
+	require.NoError(t, diagnostics.WriteSARIF(&out, "test.lox", nil))
+	require.Contains(t, out.String(), `"results": []`)
+
+}
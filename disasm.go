@@ -0,0 +1,21 @@
+package main
+
+import (
+	"golox/bytecode"
+	"os"
+)
+
+// runDisasm compiles a script, or loads a compiled image, and prints the
+// bytecode of every function in it.
+func runDisasm(args []string) error {
+	if len(args) != 1 {
+		usage()
+		os.Exit(EX_USAGE)
+	}
+	function, err := compileFile(args[0])
+	if err != nil || function == nil {
+		return err
+	}
+	bytecode.Disassemble(os.Stdout, function)
+	return nil
+}
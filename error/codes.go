@@ -0,0 +1,132 @@
+package loxerror
+
+// UNKNOWN_CODE is the code of messages that are not in the table below, such
+// as errors raised by native functions.
+const UNKNOWN_CODE = "LOX0000"
+
+// codes gives every message a stable code for tools that collect golox
+// diagnostics. The hundreds digit names the phase: 1 scanner, 2 parser,
+// 3 resolver, 4 compiler and 5 runtime. A code is never reused; append new
+// messages at the end of their phase.
+var codes = []struct {
+	code    string
+	message string
+}{
+	{"LOX0101", SCANNER_ERROR_UNEXPECTED_CHARACTER},
+	{"LOX0102", SCANNER_ERROR_UNTERMINATED_STRING},
+	{"LOX0103", SCANNER_ERROR_UNTERMINATED_COMMENT},
+	{"LOX0104", SCANNER_ERROR_INVALID_ESCAPE},
+	{"LOX0105", SCANNER_ERROR_INVALID_UNICODE_ESCAPE},
+	{"LOX0106", SCANNER_ERROR_MALFORMED_NUMBER},
+	{"LOX0107", SCANNER_ERROR_NUMBER_OUT_OF_RANGE},
+
+	{"LOX0201", PARSE_ERROR_MISSING_RIGHT_PAREN},
+	{"LOX0202", PARSE_ERROR_VARIABLE_EXPR_MISSING_NAME},
+	{"LOX0203", PARSE_ERROR_VARIABLE_EXPR_MISSING_SEMICOLON},
+	{"LOX0204", PARSE_ERROR_MISSING_EXPRESSION},
+	{"LOX0205", PARSE_ERROR_MISSING_CLASS_NAME},
+	{"LOX0206", PARSE_ERROR_MISSING_SUPERCLASS_NAME},
+	{"LOX0207", PARSE_ERROR_MISSING_CLASS_LEFT_BRACE},
+	{"LOX0208", PARSE_ERROR_MISSING_CLASS_RIGHT_BRACE},
+	{"LOX0209", PARSE_ERROR_MISSING_FUNCTION_NAME},
+	{"LOX0210", PARSE_ERROR_MISSING_FUNCTION_LEFT_PAREN},
+	{"LOX0211", PARSE_ERROR_MISSING_FUNCTION_LEFT_BRACE},
+	{"LOX0212", PARSE_ERROR_TOO_MANY_PARAMETERS},
+	{"LOX0213", PARSE_ERROR_MISSING_PARAMETER_NAME},
+	{"LOX0214", PARSE_ERROR_MISSING_PARAMETERS_RIGHT_PAREN},
+	{"LOX0215", PARSE_ERROR_MISSING_FOR_LEFT_PAREN},
+	{"LOX0216", PARSE_ERROR_MISSING_LOOP_CONDITION_SEMICOLON},
+	{"LOX0217", PARSE_ERROR_MISSING_FOR_RIGHT_PAREN},
+	{"LOX0218", PARSE_ERROR_MISSING_WHILE_LEFT_PAREN},
+	{"LOX0219", PARSE_ERROR_MISSING_WHILE_RIGHT_PAREN},
+	{"LOX0220", PARSE_ERROR_MISSING_IF_LEFT_PAREN},
+	{"LOX0221", PARSE_ERROR_MISSING_IF_RIGHT_PAREN},
+	{"LOX0222", PARSE_ERROR_MISSING_BLOCK_RIGHT_BRACE},
+	{"LOX0223", PARSE_ERROR_MISSING_RETURN_SEMICOLON},
+	{"LOX0224", PARSE_ERROR_MISSING_VALUE_SEMICOLON},
+	{"LOX0225", PARSE_ERROR_INVALID_ASSIGNMENT_TARGET},
+	{"LOX0226", PARSE_ERROR_MISSING_PROPERTY_NAME},
+	{"LOX0227", PARSE_ERROR_TOO_MANY_ARGUMENTS},
+	{"LOX0228", PARSE_ERROR_MISSING_ARGUMENTS_RIGHT_PAREN},
+	{"LOX0229", PARSE_ERROR_MISSING_SUPER_DOT},
+	{"LOX0230", PARSE_ERROR_MISSING_SUPER_METHOD},
+	{"LOX0231", PARSE_ERROR_MISSING_THROW_SEMICOLON},
+	{"LOX0232", PARSE_ERROR_MISSING_TRY_LEFT_BRACE},
+	{"LOX0233", PARSE_ERROR_MISSING_CATCH_LEFT_PAREN},
+	{"LOX0234", PARSE_ERROR_MISSING_CATCH_NAME},
+	{"LOX0235", PARSE_ERROR_MISSING_CATCH_RIGHT_PAREN},
+	{"LOX0236", PARSE_ERROR_MISSING_CATCH_LEFT_BRACE},
+	{"LOX0237", PARSE_ERROR_MISSING_FINALLY_LEFT_BRACE},
+	{"LOX0238", PARSE_ERROR_MISSING_CATCH_OR_FINALLY},
+	{"LOX0239", PARSE_ERROR_MISSING_INTERPOLATION_RIGHT_BRACE},
+	{"LOX0240", PARSE_ERROR_MISSING_CONDITIONAL_COLON},
+	{"LOX0241", PARSE_ERROR_MISSING_OPTIONAL_PROPERTY_NAME},
+	{"LOX0242", PARSE_ERROR_MISSING_LABELED_LOOP},
+	{"LOX0243", PARSE_ERROR_MISSING_BREAK_SEMICOLON},
+	{"LOX0244", PARSE_ERROR_MISSING_CONTINUE_SEMICOLON},
+	{"LOX0245", PARSE_ERROR_MISSING_ANONYMOUS_FUNCTION_LEFT_PAREN},
+
+	{"LOX0301", RESOLVER_ERROR_OWN_INITIALIZER},
+	{"LOX0302", RESOLVER_ERROR_ALREADY_DECLARED},
+	{"LOX0303", RESOLVER_ERROR_TOP_LEVEL_RETURN},
+	{"LOX0304", RESOLVER_ERROR_INITIALIZER_RETURN},
+	{"LOX0305", RESOLVER_ERROR_THIS_OUTSIDE_CLASS},
+	{"LOX0306", RESOLVER_ERROR_SUPER_OUTSIDE_CLASS},
+	{"LOX0307", RESOLVER_ERROR_SUPER_WITHOUT_SUPERCLASS},
+	{"LOX0308", RESOLVER_ERROR_INHERIT_ITSELF},
+	{"LOX0309", RESOLVER_ERROR_BREAK_OUTSIDE_LOOP},
+	{"LOX0310", RESOLVER_ERROR_CONTINUE_OUTSIDE_LOOP},
+	{"LOX0311", RESOLVER_ERROR_UNDEFINED_LABEL},
+	{"LOX0312", RESOLVER_ERROR_DUPLICATE_LABEL},
+
+	{"LOX0401", COMPILER_ERROR_TOO_MANY_CONSTANTS},
+	{"LOX0402", COMPILER_ERROR_TOO_MANY_LOCALS},
+	{"LOX0403", COMPILER_ERROR_TOO_MANY_UPVALUES},
+	{"LOX0404", COMPILER_ERROR_JUMP_TOO_LARGE},
+	{"LOX0405", COMPILER_ERROR_LOOP_TOO_LARGE},
+
+	{"LOX0501", RUNTIME_ERROR_UNDEFINED_VARIABLE},
+	{"LOX0502", RUNTIME_ERROR_UNDEFINED_PROPERTY},
+	{"LOX0503", RUNTIME_ERROR_OPERAND_NUMBER},
+	{"LOX0504", RUNTIME_ERROR_OPERANDS_NUMBERS},
+	{"LOX0505", RUNTIME_ERROR_OPERANDS_NUMBERS_OR_STRINGS},
+	{"LOX0506", RUNTIME_ERROR_NOT_CALLABLE},
+	{"LOX0507", RUNTIME_ERROR_ARITY},
+	{"LOX0508", RUNTIME_ERROR_INSTANCE_PROPERTIES},
+	{"LOX0509", RUNTIME_ERROR_INSTANCE_FIELDS},
+	{"LOX0510", RUNTIME_ERROR_SUPERCLASS},
+	{"LOX0511", RUNTIME_ERROR_STACK_OVERFLOW},
+	{"LOX0512", RUNTIME_ERROR_UNCAUGHT},
+	{"LOX0513", RUNTIME_ERROR_OPERAND_INTEGER},
+	{"LOX0514", RUNTIME_ERROR_OPERANDS_INTEGERS},
+	{"LOX0515", RUNTIME_ERROR_DIVISION_BY_ZERO},
+	{"LOX0516", RUNTIME_ERROR_NEGATIVE_SHIFT},
+}
+
+// codesByMessage indexes the table by message.
+var codesByMessage = map[string]string{}
+
+func init() {
+	for _, entry := range codes {
+		codesByMessage[entry.message] = entry.code
+	}
+}
+
+// code returns the code of message, which is one of the message constants
+// or format strings in the table, before formatting.
+func code(message string) string {
+	if code, ok := codesByMessage[message]; ok {
+		return code
+	}
+	return UNKNOWN_CODE
+}
+
+// Description returns the message, or format string, that code stands for.
+func Description(code string) string {
+	for _, entry := range codes {
+		if entry.code == code {
+			return entry.message
+		}
+	}
+	return ""
+}
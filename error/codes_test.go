@@ -0,0 +1,46 @@
+package loxerror_test
+
+import (
+	loxerror "golox/error"
+	tkn "golox/token"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCode(t *testing.T) {
+
+	tests := []struct {
+		message  string
+		args     []interface{}
+		expected string
+	}{
+		{loxerror.SCANNER_ERROR_UNEXPECTED_CHARACTER, nil, "LOX0101"},
+		{loxerror.SCANNER_ERROR_UNTERMINATED_COMMENT, nil, "LOX0103"},
+		{loxerror.SCANNER_ERROR_INVALID_ESCAPE, []interface{}{`\q`}, "LOX0104"},
+		{loxerror.SCANNER_ERROR_NUMBER_OUT_OF_RANGE, []interface{}{"1e400"}, "LOX0107"},
+		{loxerror.PARSE_ERROR_MISSING_RIGHT_PAREN, nil, "LOX0201"},
+		{loxerror.PARSE_ERROR_MISSING_CLASS_NAME, nil, "LOX0205"},
+		{loxerror.PARSE_ERROR_MISSING_FUNCTION_NAME, []interface{}{"class"}, "LOX0209"},
+		{loxerror.PARSE_ERROR_MISSING_CONDITIONAL_COLON, nil, "LOX0240"},
+		{loxerror.PARSE_ERROR_MISSING_LABELED_LOOP, nil, "LOX0242"},
+		{loxerror.PARSE_ERROR_MISSING_ANONYMOUS_FUNCTION_LEFT_PAREN, nil, "LOX0245"},
+		{loxerror.RESOLVER_ERROR_INHERIT_ITSELF, nil, "LOX0308"},
+		{loxerror.RESOLVER_ERROR_UNDEFINED_LABEL, []interface{}{"outer"}, "LOX0311"},
+		{loxerror.COMPILER_ERROR_LOOP_TOO_LARGE, nil, "LOX0405"},
+		{loxerror.RUNTIME_ERROR_UNDEFINED_VARIABLE, []interface{}{"a"}, "LOX0501"},
+		{loxerror.RUNTIME_ERROR_ARITY, []interface{}{1, 0}, "LOX0507"},
+		{loxerror.RUNTIME_ERROR_UNCAUGHT, []interface{}{"Stack overflow"}, "LOX0512"},
+		{loxerror.RUNTIME_ERROR_DIVISION_BY_ZERO, nil, "LOX0515"},
+		{"Undefined variable 'a'.", nil, loxerror.UNKNOWN_CODE},
+		{"%s", []interface{}{"Something else."}, loxerror.UNKNOWN_CODE},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, loxerror.NewError(1, "", test.message, test.args...).Code, test.message)
+		require.Equal(t, test.expected, loxerror.NewErrorFromToken(tkn.Token{Lexeme: "a"}, test.message, test.args...).Code, test.message)
+	}
+	require.Equal(t, "Uncaught exception: Stack overflow.", loxerror.NewError(1, "", loxerror.RUNTIME_ERROR_UNCAUGHT, "Stack overflow").Message)
+	require.Equal(t, loxerror.RUNTIME_ERROR_ARITY, loxerror.Description("LOX0507"))
+
+}
@@ -3,37 +3,258 @@ package loxerror
 import (
 	"fmt"
 	tkn "golox/token"
+	loxvalue "golox/value"
 )
 
 const PARSE_ERROR_MISSING_RIGHT_PAREN = "Expect ')' after expression."
 const PARSE_ERROR_VARIABLE_EXPR_MISSING_NAME = "Expect variable name."
 const PARSE_ERROR_VARIABLE_EXPR_MISSING_SEMICOLON = "Expect ';' after variable declaration."
 const PARSE_ERROR_MISSING_EXPRESSION = "Expect expression."
+const PARSE_ERROR_MISSING_CLASS_NAME = "Expect class name."
+const PARSE_ERROR_MISSING_SUPERCLASS_NAME = "Expect superclass name."
+const PARSE_ERROR_MISSING_CLASS_LEFT_BRACE = "Expect '{' before class body."
+const PARSE_ERROR_MISSING_CLASS_RIGHT_BRACE = "Expect '}' after class body."
+const PARSE_ERROR_MISSING_FUNCTION_NAME = "Expect %s name."
+const PARSE_ERROR_MISSING_FUNCTION_LEFT_PAREN = "Expect '(' after %s name."
+const PARSE_ERROR_MISSING_FUNCTION_LEFT_BRACE = "Expect '{' before %s body."
+const PARSE_ERROR_TOO_MANY_PARAMETERS = "Can't have more than 255 parameters."
+const PARSE_ERROR_MISSING_PARAMETER_NAME = "Expect parameter name."
+const PARSE_ERROR_MISSING_PARAMETERS_RIGHT_PAREN = "Expect ')' after parameters."
+const PARSE_ERROR_MISSING_FOR_LEFT_PAREN = "Expect '(' after 'for'."
+const PARSE_ERROR_MISSING_LOOP_CONDITION_SEMICOLON = "Expect ';' after loop condition."
+const PARSE_ERROR_MISSING_FOR_RIGHT_PAREN = "Expect ')' after for clauses."
+const PARSE_ERROR_MISSING_WHILE_LEFT_PAREN = "Expect '(' after 'while'."
+const PARSE_ERROR_MISSING_WHILE_RIGHT_PAREN = "Expect '(' after while condition."
+const PARSE_ERROR_MISSING_IF_LEFT_PAREN = "Expect '(' after 'if'."
+const PARSE_ERROR_MISSING_IF_RIGHT_PAREN = "Expect ')' after if condition."
+const PARSE_ERROR_MISSING_BLOCK_RIGHT_BRACE = "Expect '}' after block."
+const PARSE_ERROR_MISSING_RETURN_SEMICOLON = "Expect ';' after return value."
+const PARSE_ERROR_MISSING_VALUE_SEMICOLON = "Expect ';' after value."
+const PARSE_ERROR_INVALID_ASSIGNMENT_TARGET = "Invalid assignment target."
+const PARSE_ERROR_MISSING_PROPERTY_NAME = "Expect property name after '.'."
+const PARSE_ERROR_TOO_MANY_ARGUMENTS = "Can't have more than 255 arguments."
+const PARSE_ERROR_MISSING_ARGUMENTS_RIGHT_PAREN = "Expect ')' after arguments."
+const PARSE_ERROR_MISSING_SUPER_DOT = "Expect '.' after 'super'."
+const PARSE_ERROR_MISSING_SUPER_METHOD = "Expect superclass method name."
+const PARSE_ERROR_MISSING_THROW_SEMICOLON = "Expect ';' after thrown value."
+const PARSE_ERROR_MISSING_TRY_LEFT_BRACE = "Expect '{' after 'try'."
+const PARSE_ERROR_MISSING_CATCH_LEFT_PAREN = "Expect '(' after 'catch'."
+const PARSE_ERROR_MISSING_CATCH_NAME = "Expect catch variable name."
+const PARSE_ERROR_MISSING_CATCH_RIGHT_PAREN = "Expect ')' after catch variable."
+const PARSE_ERROR_MISSING_CATCH_LEFT_BRACE = "Expect '{' before catch body."
+const PARSE_ERROR_MISSING_FINALLY_LEFT_BRACE = "Expect '{' after 'finally'."
+const PARSE_ERROR_MISSING_CATCH_OR_FINALLY = "Expect 'catch' or 'finally' after try block."
+const PARSE_ERROR_MISSING_INTERPOLATION_RIGHT_BRACE = "Expect '}' after interpolated expression."
+const PARSE_ERROR_MISSING_CONDITIONAL_COLON = "Expect ':' after then branch of conditional expression."
+const PARSE_ERROR_MISSING_OPTIONAL_PROPERTY_NAME = "Expect property name or '(' after '?.'."
+const PARSE_ERROR_MISSING_LABELED_LOOP = "Expect loop after label."
+const PARSE_ERROR_MISSING_BREAK_SEMICOLON = "Expect ';' after 'break'."
+const PARSE_ERROR_MISSING_CONTINUE_SEMICOLON = "Expect ';' after 'continue'."
+const PARSE_ERROR_MISSING_ANONYMOUS_FUNCTION_LEFT_PAREN = "Expect '(' after 'fun'."
 
 const SCANNER_ERROR_UNEXPECTED_CHARACTER = "Unexpected character."
 const SCANNER_ERROR_UNTERMINATED_STRING = "Unterminated string."
+const SCANNER_ERROR_UNTERMINATED_COMMENT = "Unterminated block comment."
+const SCANNER_ERROR_INVALID_ESCAPE = "Invalid escape sequence '%s'."
+const SCANNER_ERROR_INVALID_UNICODE_ESCAPE = "Invalid Unicode escape '%s'."
+const SCANNER_ERROR_MALFORMED_NUMBER = "Malformed number '%s'."
+const SCANNER_ERROR_NUMBER_OUT_OF_RANGE = "Number '%s' is out of range."
 
+const RUNTIME_ERROR_UNDEFINED_VARIABLE = "Undefined variable '%s'."
+const RUNTIME_ERROR_UNDEFINED_PROPERTY = "Undefined property '%s'."
+const RUNTIME_ERROR_OPERAND_NUMBER = "Operand must be a number."
+const RUNTIME_ERROR_OPERANDS_NUMBERS = "Operands must be a numbers."
+const RUNTIME_ERROR_OPERANDS_NUMBERS_OR_STRINGS = "Operands must be two numbers or two strings."
+const RUNTIME_ERROR_NOT_CALLABLE = "Can only call functions and classes."
+const RUNTIME_ERROR_ARITY = "Expected %d arguments but got %d."
+const RUNTIME_ERROR_INSTANCE_PROPERTIES = "Only instances have properties."
+const RUNTIME_ERROR_INSTANCE_FIELDS = "Only instances have fields."
+const RUNTIME_ERROR_SUPERCLASS = "Superclass must be a class."
+const RUNTIME_ERROR_STACK_OVERFLOW = "Stack overflow."
+const RUNTIME_ERROR_UNCAUGHT = "Uncaught exception: %s."
+const RUNTIME_ERROR_OPERAND_INTEGER = "Operand must be an integer."
+const RUNTIME_ERROR_OPERANDS_INTEGERS = "Operands must be integers."
+const RUNTIME_ERROR_DIVISION_BY_ZERO = "Division by zero."
+const RUNTIME_ERROR_NEGATIVE_SHIFT = "Shift count must not be negative."
+
+const HINT_UNDEFINED_VARIABLE = "Declare it with 'var %s' first."
+const NOTE_DECLARED_HERE = "'%s' is declared here."
+const NOTE_ANONYMOUS_DECLARED_HERE = "The function is declared here."
+
+const RESOLVER_ERROR_OWN_INITIALIZER = "Can't read local variable in its own initializer."
+const RESOLVER_ERROR_ALREADY_DECLARED = "Already a variable with this name in this scope."
+const RESOLVER_ERROR_TOP_LEVEL_RETURN = "Can't return from top-level code."
+const RESOLVER_ERROR_INITIALIZER_RETURN = "Can't return a value from an initializer."
+const RESOLVER_ERROR_THIS_OUTSIDE_CLASS = "Can't use 'this' outside of a class."
+const RESOLVER_ERROR_SUPER_OUTSIDE_CLASS = "Can't use 'super' outside of a class."
+const RESOLVER_ERROR_SUPER_WITHOUT_SUPERCLASS = "Can't use 'super' in a class with no superclass."
+const RESOLVER_ERROR_INHERIT_ITSELF = "A class can't inherit from itself."
+const RESOLVER_ERROR_BREAK_OUTSIDE_LOOP = "Can't use 'break' outside of a loop."
+const RESOLVER_ERROR_CONTINUE_OUTSIDE_LOOP = "Can't use 'continue' outside of a loop."
+const RESOLVER_ERROR_UNDEFINED_LABEL = "No enclosing loop labeled '%s'."
+const RESOLVER_ERROR_DUPLICATE_LABEL = "Already a loop labeled '%s' around this one."
+
+const COMPILER_ERROR_TOO_MANY_CONSTANTS = "Too many constants in one chunk."
+const COMPILER_ERROR_TOO_MANY_LOCALS = "Too many local variables in function."
+const COMPILER_ERROR_TOO_MANY_UPVALUES = "Too many closure variables in function."
+const COMPILER_ERROR_JUMP_TOO_LARGE = "Too much code to jump over."
+const COMPILER_ERROR_LOOP_TOO_LARGE = "Loop body too large."
+
+// Error is a static or runtime error in a Lox program. Code is the stable
+// code of its message, which NewError looks up in codes.go. Span is the source
+// range to point at, when known, and File is set by the driver once it knows
+// which file the program came from. Notes point at related code and Hint
+// suggests a fix; both are only shown by the diagnostics renderer. Trace
+// lists the calls that were active when a runtime error happened inside a
+// function, innermost first; it is empty for errors in top-level code.
+// Thrown is the value of the throw statement that raised the error, if any.
 type Error struct {
 	Line    int
 	Where   string
 	Message string
+	Code    string
+	File    string
+	Span    tkn.Span
+	Notes   []Note
+	Hint    string
+	Trace   []Frame
+	Thrown  loxvalue.LoxValue
+}
+
+// Note is a secondary message about another place in the source, such as
+// "'a' is declared here."
+type Note struct {
+	Message string
+	Span    tkn.Span
+}
+
+// MAX_TRACE_FRAMES bounds the frames shown for a stack trace; deeper traces,
+// usually runaway recursion, show their innermost and outermost frames.
+const MAX_TRACE_FRAMES = 20
+
+// SCRIPT_FRAME names the outermost frame of a trace, the top-level code.
+const SCRIPT_FRAME = "script"
+
+// Frame is one call in a stack trace: the function and the line that was
+// running in it.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line"`
+}
+
+// String formats the frame as "at foo (script.lox:12)".
+func (f Frame) String() string {
+	if f.File == "" {
+		return fmt.Sprintf("at %s (line %d)", f.Function, f.Line)
+	}
+	return fmt.Sprintf("at %s (%s:%d)", f.Function, f.File, f.Line)
+}
+
+// TraceLines formats trace one frame per line, as shown to people. Frames
+// with no file are placed in file. Traces longer than MAX_TRACE_FRAMES lose
+// their middle frames to a "... N more frames" line.
+func TraceLines(trace []Frame, file string) []string {
+	lines := []string{}
+	for i, frame := range trace {
+		if len(trace) > MAX_TRACE_FRAMES && i >= MAX_TRACE_FRAMES/2 && i < len(trace)-MAX_TRACE_FRAMES/2 {
+			if i == MAX_TRACE_FRAMES/2 {
+				lines = append(lines, fmt.Sprintf("... %d more frames", len(trace)-MAX_TRACE_FRAMES))
+			}
+			continue
+		}
+		if frame.File == "" {
+			frame.File = file
+		}
+		lines = append(lines, frame.String())
+	}
+	return lines
 }
 
 func (e *Error) Error() string {
-	return fmt.Sprintf("[line %d] Error %s: %s", e.Line, e.Where, e.Message)
+	location := fmt.Sprintf("line %d", e.Line)
+	if e.File != "" {
+		location = fmt.Sprintf("%s:%d", e.File, e.Line)
+		if !e.Span.IsZero() {
+			location = fmt.Sprintf("%s:%d:%d", e.File, e.Span.Start.Line, e.Span.Start.Column)
+		}
+	}
+	return fmt.Sprintf("[%s] Error%s: %s", location, e.Where, e.Message)
 }
 
-func NewError(line int, where, message string) *Error {
-	return &Error{
+// NewError returns an error whose message is one of the constants above,
+// formatted with args when it is a format string. The error gets the code of
+// that constant, or UNKNOWN_CODE for any other message.
+func NewError(line int, where, message string, args ...interface{}) *Error {
+	err := &Error{
 		Line:    line,
 		Where:   where,
 		Message: message,
+		Code:    code(message),
+	}
+	if len(args) > 0 {
+		err.Message = fmt.Sprintf(message, args...)
 	}
+	return err
 }
 
-func NewErrorFromToken(token tkn.Token, message string) *Error {
+func NewErrorFromToken(token tkn.Token, message string, args ...interface{}) *Error {
+	where := " at '" + token.Lexeme + "'"
 	if token.Type == tkn.EOF {
-		return NewError(token.Line, " at end", message)
+		where = " at end"
+	}
+	err := NewError(token.Line, where, message, args...)
+	err.Span = token.Span
+	return err
+}
+
+// WithNote adds a note about the code at span and returns e.
+func (e *Error) WithNote(span tkn.Span, message string) *Error {
+	e.Notes = append(e.Notes, Note{Message: message, Span: span})
+	return e
+}
+
+// WithHint sets the hint and returns e.
+func (e *Error) WithHint(hint string) *Error {
+	e.Hint = hint
+	return e
+}
+
+// InFile records filename on every *Error in errors, and on the frames of
+// their traces, and returns errors.
+func InFile(errors []error, filename string) []error {
+	for _, err := range errors {
+		if err, ok := err.(*Error); ok {
+			err.File = filename
+			for i := range err.Trace {
+				err.Trace[i].File = filename
+			}
+		}
+	}
+	return errors
+}
+
+// Throw returns the error raised by throwing value on behalf of token.
+// Throwing an error value that was caught earlier raises the original error
+// again, keeping its location and trace.
+func Throw(token tkn.Token, value loxvalue.LoxValue) error {
+	if caught, ok := value.(*loxvalue.Error); ok && caught.Err != nil {
+		return caught.Err
+	}
+	err := NewErrorFromToken(token, RUNTIME_ERROR_UNCAUGHT, value.ToString())
+	err.Thrown = value
+	return err
+}
+
+// Caught returns the value a catch clause binds for err: the thrown value,
+// or an error value describing an error raised by golox itself.
+func Caught(err error) loxvalue.LoxValue {
+	loxErr, ok := err.(*Error)
+	if !ok {
+		return loxvalue.NewError(err.Error(), []string{}, err)
+	}
+	if loxErr.Thrown != nil {
+		return loxErr.Thrown
 	}
-	return NewError(token.Line, " at '" + token.Lexeme + "'", message)
+	return loxvalue.NewError(loxErr.Message, TraceLines(loxErr.Trace, loxErr.File), err)
 }
@@ -10,17 +10,31 @@ type ExprVisitor interface {
 	VisitUnary(element UnaryExpr) (interface{}, error)
 	VisitBinary(element BinaryExpr) (interface{}, error)
 	VisitGrouping(element GroupingExpr) (interface{}, error)
-	// VisitVariable(element VariableExpr) (interface{}, error)
-	// VisitAssing(element AssignExpr) (interface{}, error)
+	VisitVariable(element *VariableExpr) (interface{}, error)
+	VisitAssing(element *AssignExpr) (interface{}, error)
 	VisitLogical(element LogicalExpr) (interface{}, error)
+	VisitCall(element CallExpr) (interface{}, error)
+	VisitGet(element GetExpr) (interface{}, error)
+	VisitSet(element SetExpr) (interface{}, error)
+	VisitThis(element *ThisExpr) (interface{}, error)
+	VisitSuper(element *SuperExpr) (interface{}, error)
+	VisitInterpolation(element InterpolationExpr) (interface{}, error)
+	VisitConditional(element ConditionalExpr) (interface{}, error)
+	VisitUpdate(element UpdateExpr) (interface{}, error)
+	VisitFunction(element FunctionExpr) (interface{}, error)
 }
 
 type Expr interface {
 	Evaluate(visitor ExprVisitor) (interface{}, error)
 }
 
+// VariableExpr, AssignExpr, ThisExpr and SuperExpr are always handled by
+// pointer: the resolver records scope depths keyed by node identity, and two
+// references to the same name on one line would otherwise be indistinguishable.
+
 type LiteralExpr struct {
 	Value loxvalue.LoxValue
+	Span  tkn.Span
 }
 
 func (e LiteralExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
@@ -29,7 +43,8 @@ func (e LiteralExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
 
 type UnaryExpr struct {
 	Operator tkn.Token
-	Right     Expr
+	Right    Expr
+	Span     tkn.Span
 }
 
 func (e UnaryExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
@@ -38,6 +53,7 @@ func (e UnaryExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
 
 type GroupingExpr struct {
 	Expr Expr
+	Span tkn.Span
 }
 
 func (e GroupingExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
@@ -48,6 +64,7 @@ type BinaryExpr struct {
 	Operator tkn.Token
 	Left     Expr
 	Right    Expr
+	Span     tkn.Span
 }
 
 func (e BinaryExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
@@ -56,29 +73,144 @@ func (e BinaryExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
 
 type VariableExpr struct {
 	Name tkn.Token
+	Span tkn.Span
 }
 
-func (e VariableExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
-	return nil, nil
-	// return visitor.VisitVariable(e)
+func (e *VariableExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitVariable(e)
 }
 
 type AssignExpr struct {
-	Name tkn.Token
+	Name  tkn.Token
 	Right Expr
+	Span  tkn.Span
 }
 
-func (e AssignExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
-	return nil, nil
-	// return visitor.VisitAssing(e)
+func (e *AssignExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitAssing(e)
 }
 
 type LogicalExpr struct {
 	Operator tkn.Token
-	Left Expr
-	Right Expr
+	Left     Expr
+	Right    Expr
+	Span     tkn.Span
 }
 
 func (e LogicalExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitLogical(e)
-}
\ No newline at end of file
+}
+
+// CallExpr and GetExpr are Optional when written with '?.'. A nil callee or
+// object then skips the rest of the chain of calls and property accesses,
+// which evaluates to nil.
+type CallExpr struct {
+	Callee    Expr
+	Paren     tkn.Token
+	Arguments []Expr
+	Optional  bool
+	Span      tkn.Span
+}
+
+func (e CallExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitCall(e)
+}
+
+type GetExpr struct {
+	Object   Expr
+	Name     tkn.Token
+	Optional bool
+	Span     tkn.Span
+}
+
+func (e GetExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitGet(e)
+}
+
+type SetExpr struct {
+	Object Expr
+	Name   tkn.Token
+	Value  Expr
+	Span   tkn.Span
+}
+
+func (e SetExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitSet(e)
+}
+
+type ThisExpr struct {
+	Keyword tkn.Token
+	Span    tkn.Span
+}
+
+func (e *ThisExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitThis(e)
+}
+
+type SuperExpr struct {
+	Keyword tkn.Token
+	Method  tkn.Token
+	Span    tkn.Span
+}
+
+func (e *SuperExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitSuper(e)
+}
+
+// InterpolationExpr is a string literal with embedded expressions, such as
+// "a ${b} c". Strings holds the text around the expressions, so it has one
+// element more than Exprs.
+type InterpolationExpr struct {
+	Strings []string
+	Exprs   []Expr
+	Span    tkn.Span
+}
+
+func (e InterpolationExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitInterpolation(e)
+}
+
+// ConditionalExpr is the ternary operator, condition ? then : else.
+type ConditionalExpr struct {
+	Condition Expr
+	Question  tkn.Token
+	Then      Expr
+	Else      Expr
+	Span      tkn.Span
+}
+
+func (e ConditionalExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitConditional(e)
+}
+
+// UpdateExpr is a compound assignment such as a += 1, or an increment or
+// decrement such as a++ or --a.obj. Target is a variable or a property and
+// is evaluated once. Value is nil for increments and decrements, and a
+// Postfix one evaluates to the value Target had before.
+type UpdateExpr struct {
+	Target   Expr
+	Operator tkn.Token
+	Value    Expr
+	Postfix  bool
+	Span     tkn.Span
+}
+
+func (e UpdateExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitUpdate(e)
+}
+
+// FunctionExpr is an anonymous function, fun (a, b) { ... }, or its arrow
+// form (a, b) => a + b, whose body is a single return of the expression.
+// Keyword is the 'fun' or '=>' token. Body holds the []stmt.Stmt of the
+// function, which this package can't name because stmt depends on it; see
+// stmt.Declaration.
+type FunctionExpr struct {
+	Keyword tkn.Token
+	Params  []tkn.Token
+	Body    interface{}
+	Span    tkn.Span
+}
+
+func (e FunctionExpr) Evaluate(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitFunction(e)
+}
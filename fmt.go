@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"golox/printer"
+	"os"
+)
+
+// runFmt formats Lox source files. The canonical form is printed to standard
+// output unless -w is given, in which case changed files are rewritten in
+// place. A file that does not parse is reported and left untouched.
+func runFmt(args []string) error {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write the result back to the source files")
+	flags.Usage = usage
+	parseFlags(flags, args)
+	if flags.NArg() == 0 {
+		usage()
+		os.Exit(EX_USAGE)
+	}
+
+	for _, filename := range flags.Args() {
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return ioError("failed to read file: %v", err)
+		}
+		formatted, errors := printer.Format(string(content))
+		if len(errors) > 0 {
+			printErrors(filename, string(content), errors)
+			fail(EX_DATAERR)
+			continue
+		}
+		if !*write {
+			fmt.Print(formatted)
+			continue
+		}
+		if formatted == string(content) {
+			continue
+		}
+		if err := os.WriteFile(filename, []byte(formatted), 0644); err != nil {
+			return ioError("failed to write file: %v", err)
+		}
+	}
+	return nil
+}
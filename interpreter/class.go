@@ -0,0 +1,96 @@
+package interpreter
+
+import (
+	loxerror "golox/error"
+	tkn "golox/token"
+	loxvalue "golox/value"
+)
+
+type LoxClass struct {
+	Name       string
+	superclass *LoxClass
+	methods    map[string]*LoxFunction
+}
+
+func NewLoxClass(name string, superclass *LoxClass, methods map[string]*LoxFunction) *LoxClass {
+	return &LoxClass{
+		Name:       name,
+		superclass: superclass,
+		methods:    methods,
+	}
+}
+
+func (c *LoxClass) Type() int {
+	return loxvalue.CLASS
+}
+
+func (c *LoxClass) ToString() string {
+	return c.Name
+}
+
+// FindMethod looks name up on the class and then along its superclass chain.
+func (c *LoxClass) FindMethod(name string) *LoxFunction {
+	if method, ok := c.methods[name]; ok {
+		return method
+	}
+	if c.superclass != nil {
+		return c.superclass.FindMethod(name)
+	}
+	return nil
+}
+
+func (c *LoxClass) Arity() int {
+	initializer := c.FindMethod("init")
+	if initializer == nil {
+		return 0
+	}
+	return initializer.Arity()
+}
+
+// Call creates a new instance and runs its initializer, if the class has one.
+func (c *LoxClass) Call(arguments []loxvalue.LoxValue) (loxvalue.LoxValue, error) {
+	instance := NewLoxInstance(c)
+	initializer := c.FindMethod("init")
+	if initializer != nil {
+		_, err := initializer.Bind(instance).Call(arguments)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return instance, nil
+}
+
+type LoxInstance struct {
+	class  *LoxClass
+	fields map[string]loxvalue.LoxValue
+}
+
+func NewLoxInstance(class *LoxClass) *LoxInstance {
+	return &LoxInstance{
+		class:  class,
+		fields: make(map[string]loxvalue.LoxValue),
+	}
+}
+
+func (o *LoxInstance) Type() int {
+	return loxvalue.INSTANCE
+}
+
+func (o *LoxInstance) ToString() string {
+	return o.class.Name + " instance"
+}
+
+// Get returns a field if one is set, otherwise a method bound to the instance.
+func (o *LoxInstance) Get(name tkn.Token) (loxvalue.LoxValue, error) {
+	if value, ok := o.fields[name.Lexeme]; ok {
+		return value, nil
+	}
+	if method := o.class.FindMethod(name.Lexeme); method != nil {
+		return method.Bind(o), nil
+	}
+	return nil, loxerror.NewErrorFromToken(name, loxerror.RUNTIME_ERROR_UNDEFINED_PROPERTY, name.Lexeme)
+}
+
+func (o *LoxInstance) Set(name tkn.Token, value loxvalue.LoxValue) {
+	o.fields[name.Lexeme] = value
+}
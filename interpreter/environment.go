@@ -1,6 +1,7 @@
 package interpreter
 
 import (
+	"fmt"
 	loxerror "golox/error"
 	"golox/token"
 	loxvalue "golox/value"
@@ -36,7 +37,7 @@ func (env *Environment) Get(name token.Token) (loxvalue.LoxValue, error) {
 	if env.enclosing != nil {
 		return env.enclosing.Get(name)
 	}
-	return nil, loxerror.NewErrorFromToken(name, "Undefined variable '" + name.Lexeme + "'.")
+	return nil, undefinedVariable(name)
 }
 
 func (env *Environment) Assing(name token.Token, value loxvalue.LoxValue) error {
@@ -47,5 +48,28 @@ func (env *Environment) Assing(name token.Token, value loxvalue.LoxValue) error
 	if env.enclosing != nil {
 		return env.enclosing.Assing(name, value)
 	}
-	return loxerror.NewErrorFromToken(name, "Undefined variable '" + name.Lexeme + "'.")
-}
\ No newline at end of file
+	return undefinedVariable(name)
+}
+
+func (env *Environment) ancestor(distance int) *Environment {
+	environment := env
+	for i := 0; i < distance; i++ {
+		environment = environment.enclosing
+	}
+	return environment
+}
+
+// GetAt reads name from the environment distance hops up the chain, as
+// computed by the resolver.
+func (env *Environment) GetAt(distance int, name string) loxvalue.LoxValue {
+	return env.ancestor(distance).values[name]
+}
+
+func (env *Environment) AssignAt(distance int, name token.Token, value loxvalue.LoxValue) {
+	env.ancestor(distance).values[name.Lexeme] = value
+}
+
+func undefinedVariable(name token.Token) error {
+	err := loxerror.NewErrorFromToken(name, loxerror.RUNTIME_ERROR_UNDEFINED_VARIABLE, name.Lexeme)
+	return err.WithHint(fmt.Sprintf(loxerror.HINT_UNDEFINED_VARIABLE, name.Lexeme))
+}
@@ -0,0 +1,87 @@
+package interpreter
+
+import (
+	"golox/stmt"
+	loxvalue "golox/value"
+)
+
+// LoxFunction is a user-defined function together with the environment it
+// was declared in.
+type LoxFunction struct {
+	declaration   stmt.FunStmt
+	closure       *Environment
+	interpreter   *Interpreter
+	isInitializer bool
+}
+
+func NewLoxFunction(declaration stmt.FunStmt, closure *Environment, interpreter *Interpreter, isInitializer bool) *LoxFunction {
+	return &LoxFunction{
+		declaration:   declaration,
+		closure:       closure,
+		interpreter:   interpreter,
+		isInitializer: isInitializer,
+	}
+}
+
+// Bind returns a copy of the method whose closure defines "this" as instance.
+func (f *LoxFunction) Bind(instance *LoxInstance) *LoxFunction {
+	env := NewLocalEnv(f.closure)
+	env.Define("this", instance)
+	return NewLoxFunction(f.declaration, env, f.interpreter, f.isInitializer)
+}
+
+func (f *LoxFunction) Type() int {
+	return loxvalue.FUNCTION
+}
+
+func (f *LoxFunction) ToString() string {
+	return "<fn " + f.declaration.Name.Lexeme + ">"
+}
+
+func (f *LoxFunction) Arity() int {
+	return len(f.declaration.Params)
+}
+
+func (f *LoxFunction) Call(arguments []loxvalue.LoxValue) (loxvalue.LoxValue, error) {
+	env := NewLocalEnv(f.closure)
+	for i, param := range f.declaration.Params {
+		env.Define(param.Lexeme, arguments[i])
+	}
+	err := f.interpreter.executeBlock(f.declaration.Body, env)
+	ret, isReturn := err.(*returnValue)
+	if err != nil && !isReturn {
+		return nil, err
+	}
+	if f.isInitializer {
+		return f.closure.GetAt(0, "this"), nil
+	}
+	if isReturn {
+		return ret.value, nil
+	}
+	return &loxvalue.Nil{}, nil
+}
+
+// returnValue unwinds the Go call stack from a return statement up to the
+// enclosing LoxFunction.Call.
+type returnValue struct {
+	value loxvalue.LoxValue
+}
+
+func (r *returnValue) Error() string {
+	return "Can't return from top-level code."
+}
+
+// loopJump unwinds the Go call stack from a break or continue statement up
+// to the loop it leaves or restarts: the innermost one, or the one named
+// label.
+type loopJump struct {
+	label      string
+	isContinue bool
+}
+
+func (j *loopJump) Error() string {
+	if j.isContinue {
+		return "Can't use 'continue' outside of a loop."
+	}
+	return "Can't use 'break' outside of a loop."
+}
@@ -1,32 +1,359 @@
 package interpreter
 
 import (
+	"fmt"
 	loxerror "golox/error"
 	"golox/expr"
 	expression "golox/expr"
 	"golox/stmt"
 	tkn "golox/token"
 	loxvalue "golox/value"
+	"io"
+	"os"
+	"strings"
 )
 
+// ErrorPolicy decides whether Interpret keeps executing statements after one
+// of them fails.
+type ErrorPolicy int
+
+const (
+	StopOnError ErrorPolicy = iota
+	ContinueOnError
+)
+
+// Result records the outcome of a single top-level statement.
+type Result struct {
+	Statement stmt.Stmt
+	Value     loxvalue.LoxValue
+	Err       error
+}
+
 type Interpreter struct {
+	globals *Environment
 	env     *Environment
+	locals  map[expr.Expr]int
+	out     io.Writer
+	file    string
+	policy  ErrorPolicy
+	frames  []frame
+	Results []Result
+}
+
+// FRAMES_MAX bounds the depth of Lox calls, counting the script itself, as
+// in the VM.
+const FRAMES_MAX = 256
+
+// frame is an active call of a Lox function, made on line.
+type frame struct {
+	function string
+	line     int
 }
 
 func NewInterpreter() *Interpreter {
+	globals := NewGlobalEnv()
+	defineNatives(globals)
 	return &Interpreter{
-		env:     NewGlobalEnv(),
+		globals: globals,
+		env:     globals,
+		locals:  make(map[expr.Expr]int),
+		out:     os.Stdout,
+		policy:  StopOnError,
+		Results: []Result{},
+	}
+}
+
+// Define binds a global name, typically to a loxvalue.LoxCallable
+// implemented in Go.
+func (i *Interpreter) Define(name string, value loxvalue.LoxValue) {
+	i.globals.Define(name, value)
+}
+
+// Resolve records the scope depths computed by the resolver. Expressions
+// without an entry are looked up in the global environment.
+func (i *Interpreter) Resolve(locals map[expr.Expr]int) {
+	for e, depth := range locals {
+		i.locals[e] = depth
+	}
+}
+
+// SetOutput redirects the output of print statements.
+func (i *Interpreter) SetOutput(out io.Writer) {
+	i.out = out
+}
+
+// SetFile names the file the program came from in stack traces.
+func (i *Interpreter) SetFile(file string) {
+	i.file = file
+}
+
+func (i *Interpreter) SetErrorPolicy(policy ErrorPolicy) {
+	i.policy = policy
+}
+
+// Interpret executes statements in order and records one Result per executed
+// statement in Results. With StopOnError the first failing statement ends the
+// run; with ContinueOnError every statement is attempted.
+func (i *Interpreter) Interpret(statements []stmt.Stmt) {
+	i.Results = []Result{}
+	for _, statement := range statements {
+		value, err := i.execute(statement)
+		i.Results = append(i.Results, Result{
+			Statement: statement,
+			Value:     value,
+			Err:       err,
+		})
+		if err != nil && i.policy == StopOnError {
+			return
+		}
+	}
+}
+
+func (i *Interpreter) execute(statement stmt.Stmt) (loxvalue.LoxValue, error) {
+	value, err := statement.Accept(i)
+	if err != nil {
+		return nil, err
+	}
+	loxValue, _ := value.(loxvalue.LoxValue)
+	return loxValue, nil
+}
+
+func (i *Interpreter) VisitPrintStatement(printStmt stmt.PrintStmt) (interface{}, error) {
+	value, err := i.Evaluate(printStmt.E)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(i.out, value.ToString())
+	return nil, nil
+}
+
+func (i *Interpreter) VisitVariableStatement(variableStmt stmt.VarStmt) (interface{}, error) {
+	var value loxvalue.LoxValue = &loxvalue.Nil{}
+	if variableStmt.Initializer != nil {
+		initializer, err := i.Evaluate(variableStmt.Initializer)
+		if err != nil {
+			return nil, err
+		}
+		value = initializer
+	}
+	i.env.Define(variableStmt.Name.Lexeme, value)
+	return nil, nil
+}
+
+func (i *Interpreter) VisitBlockStatement(blockStmt stmt.BlockStmt) (interface{}, error) {
+	return nil, i.executeBlock(blockStmt.Statements, NewLocalEnv(i.env))
+}
+
+// executeBlock runs statements in env and restores the current environment
+// afterwards, whether or not a statement failed.
+func (i *Interpreter) executeBlock(statements []stmt.Stmt, env *Environment) error {
+	previous := i.env
+	i.env = env
+	defer func() {
+		i.env = previous
+	}()
+	for _, statement := range statements {
+		_, err := i.execute(statement)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Interpreter) VisitIfStatement(ifStmt stmt.IfStmt) (interface{}, error) {
+	condition, err := i.Evaluate(ifStmt.Condition)
+	if err != nil {
+		return nil, err
+	}
+	if loxvalue.IsTruthy(condition) {
+		return i.execute(ifStmt.ThenBrnach)
+	}
+	if ifStmt.ElseBranch != nil {
+		return i.execute(ifStmt.ElseBranch)
+	}
+	return nil, nil
+}
+
+func (i *Interpreter) VisitWhileStatement(whileStmt stmt.WhileStmt) (interface{}, error) {
+	for {
+		condition, err := i.Evaluate(whileStmt.Condition)
+		if err != nil {
+			return nil, err
+		}
+		if !loxvalue.IsTruthy(condition) {
+			return nil, nil
+		}
+		_, err = i.execute(whileStmt.Body)
+		if jump, ok := jumpTo(err, whileStmt.Label); ok {
+			if !jump.isContinue {
+				return nil, nil
+			}
+		} else if err != nil {
+			return nil, err
+		}
 	}
 }
 
-// func (i *Interpreter) Interpret(statements []stmt.Stmt) {
-// 	for _, statement := range statements {
-// 		_, err := i.execute(statement)
-// 		if err != nil {
-// 			return 
-// 		}
-// 	}
-// }
+// jumpTo returns err as a break or continue of the loop with label. Other
+// errors, including jumps to an enclosing loop, keep unwinding.
+func jumpTo(err error, label *tkn.Token) (*loopJump, bool) {
+	jump, ok := err.(*loopJump)
+	if !ok {
+		return nil, false
+	}
+	if jump.label != "" && (label == nil || label.Lexeme != jump.label) {
+		return nil, false
+	}
+	return jump, true
+}
+
+// VisitForStatement runs the loop in its own scope so that a variable declared
+// by the initializer is local to the loop.
+func (i *Interpreter) VisitForStatement(forStmt stmt.ForStmt) (interface{}, error) {
+	previous := i.env
+	i.env = NewLocalEnv(i.env)
+	defer func() {
+		i.env = previous
+	}()
+	if forStmt.Initializer != nil {
+		_, err := i.execute(forStmt.Initializer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for {
+		if forStmt.Condition != nil {
+			condition, err := i.Evaluate(forStmt.Condition)
+			if err != nil {
+				return nil, err
+			}
+			if !loxvalue.IsTruthy(condition) {
+				return nil, nil
+			}
+		}
+		_, err := i.execute(forStmt.Body)
+		if jump, ok := jumpTo(err, forStmt.Label); ok {
+			if !jump.isContinue {
+				return nil, nil
+			}
+		} else if err != nil {
+			return nil, err
+		}
+		if forStmt.Increment != nil {
+			_, err = i.Evaluate(forStmt.Increment)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (i *Interpreter) VisitFunctionStatement(funStmt stmt.FunStmt) (interface{}, error) {
+	function := NewLoxFunction(funStmt, i.env, i, false)
+	i.env.Define(funStmt.Name.Lexeme, function)
+	return nil, nil
+}
+
+func (i *Interpreter) VisitClassStatement(classStmt stmt.ClassStmt) (interface{}, error) {
+
+	var superclass *LoxClass
+	if classStmt.Superclass != nil {
+		value, err := i.Evaluate(classStmt.Superclass)
+		if err != nil {
+			return nil, err
+		}
+		class, ok := value.(*LoxClass)
+		if !ok {
+			return nil, loxerror.NewErrorFromToken(classStmt.Superclass.Name, loxerror.RUNTIME_ERROR_SUPERCLASS)
+		}
+		superclass = class
+	}
+
+	i.env.Define(classStmt.Name.Lexeme, &loxvalue.Nil{})
+
+	env := i.env
+	if superclass != nil {
+		env = NewLocalEnv(i.env)
+		env.Define("super", superclass)
+	}
+
+	methods := make(map[string]*LoxFunction)
+	for _, method := range classStmt.Methods {
+		methods[method.Name.Lexeme] = NewLoxFunction(method, env, i, method.Name.Lexeme == "init")
+	}
+
+	class := NewLoxClass(classStmt.Name.Lexeme, superclass, methods)
+	return nil, i.env.Assing(classStmt.Name, class)
+
+}
+
+func (i *Interpreter) VisitReturnStatement(returnStmt stmt.ReturnStmt) (interface{}, error) {
+	var value loxvalue.LoxValue = &loxvalue.Nil{}
+	if returnStmt.Value != nil {
+		result, err := i.Evaluate(returnStmt.Value)
+		if err != nil {
+			return nil, err
+		}
+		value = result
+	}
+	return nil, &returnValue{value: value}
+}
+
+func (i *Interpreter) VisitBreakStatement(breakStmt stmt.BreakStmt) (interface{}, error) {
+	return nil, &loopJump{label: labelName(breakStmt.Label)}
+}
+
+func (i *Interpreter) VisitContinueStatement(continueStmt stmt.ContinueStmt) (interface{}, error) {
+	return nil, &loopJump{label: labelName(continueStmt.Label), isContinue: true}
+}
+
+func labelName(label *tkn.Token) string {
+	if label == nil {
+		return ""
+	}
+	return label.Lexeme
+}
+
+func (i *Interpreter) VisitThrowStatement(throwStmt stmt.ThrowStmt) (interface{}, error) {
+	value, err := i.Evaluate(throwStmt.Value)
+	if err != nil {
+		return nil, err
+	}
+	return nil, loxerror.Throw(throwStmt.Keyword, value)
+}
+
+// VisitTryStatement runs the catch clause for any error raised by the body
+// except a return, break or continue, which only unwind the call or loop.
+// The finally clause always runs; an error it raises replaces the one that
+// was unwinding.
+func (i *Interpreter) VisitTryStatement(tryStmt stmt.TryStmt) (interface{}, error) {
+	_, err := i.execute(tryStmt.Body)
+	if err != nil && !isControlFlow(err) && tryStmt.Catch != nil {
+		if loxErr, ok := err.(*loxerror.Error); ok && loxErr.Trace == nil && len(i.frames) > 0 {
+			loxErr.Trace = i.trace(loxErr.Line)
+		}
+		env := NewLocalEnv(i.env)
+		env.Define(tryStmt.CatchName.Lexeme, loxerror.Caught(err))
+		err = i.executeBlock([]stmt.Stmt{tryStmt.Catch}, env)
+	}
+	if tryStmt.Finally != nil {
+		if _, finallyErr := i.execute(tryStmt.Finally); finallyErr != nil {
+			return nil, finallyErr
+		}
+	}
+	return nil, err
+}
+
+// isControlFlow reports whether err is a return, break or continue rather
+// than an error of the program.
+func isControlFlow(err error) bool {
+	switch err.(type) {
+	case *returnValue, *loopJump:
+		return true
+	}
+	return false
+}
 
 func (i *Interpreter) VisitLiteral(expr expression.LiteralExpr) (interface{}, error) {
 	return expr.Value, nil
@@ -57,6 +384,12 @@ func (i *Interpreter) VisitLogical(LogicalExpr expr.LogicalExpr) (interface{}, e
 		if !loxvalue.IsTruthy(leftValue) {
 			return leftValue, nil
 		}
+
+	case tkn.QUESTION_QUESTION:
+
+		if leftValue.Type() != loxvalue.NIL {
+			return leftValue, nil
+		}
 	}
 	return i.Evaluate(LogicalExpr.Right)
 
@@ -99,6 +432,12 @@ func (i *Interpreter) VisitUnary(expr expr.UnaryExpr) (interface{}, error) {
 		result = number.Minus()
 	case tkn.BANG:
 		result = loxvalue.NewBoolean(!loxvalue.IsTruthy(right))
+	case tkn.TILDE:
+		number, ok := right.(*loxvalue.Number)
+		if !ok || !number.IsInteger() {
+			return nil, loxerror.NewErrorFromToken(expr.Operator, loxerror.RUNTIME_ERROR_OPERAND_INTEGER)
+		}
+		result = number.BitNot()
 	}
 	//todo: error
 	return result, nil
@@ -116,10 +455,16 @@ func (i *Interpreter) VisitBinary(expr expr.BinaryExpr) (interface{}, error) {
 		return nil, err
 	}
 
-	switch expr.Operator.Type {
+	return binary(expr.Operator, left, right)
+}
+
+// binary applies a binary operator to its evaluated operands.
+func binary(operator tkn.Token, left loxvalue.LoxValue, right loxvalue.LoxValue) (loxvalue.LoxValue, error) {
+
+	switch operator.Type {
 	case tkn.MINUS:
 
-		left, right, err := checkNumberOperands(expr.Operator, left, right)
+		left, right, err := checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
@@ -127,7 +472,7 @@ func (i *Interpreter) VisitBinary(expr expr.BinaryExpr) (interface{}, error) {
 
 	case tkn.SLASH:
 
-		left, right, err := checkNumberOperands(expr.Operator, left, right)
+		left, right, err := checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
@@ -135,15 +480,41 @@ func (i *Interpreter) VisitBinary(expr expr.BinaryExpr) (interface{}, error) {
 
 	case tkn.STAR:
 
-		left, right, err := checkNumberOperands(expr.Operator, left, right)
+		left, right, err := checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
 		return left.Multiply(right), nil
 
+	case tkn.TILDE_SLASH, tkn.PERCENT:
+
+		left, right, err := checkNumberOperands(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		if right.Value == 0 {
+			return nil, loxerror.NewErrorFromToken(operator, loxerror.RUNTIME_ERROR_DIVISION_BY_ZERO)
+		}
+		if operator.Type == tkn.PERCENT {
+			return left.Modulo(right), nil
+		}
+		return left.FloorDivide(right), nil
+
+	case tkn.STAR_STAR:
+
+		left, right, err := checkNumberOperands(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return left.Power(right), nil
+
+	case tkn.AMPERSAND, tkn.PIPE, tkn.CARET, tkn.LESS_LESS, tkn.GREATER_GREATER:
+
+		return binaryBitwise(operator, left, right)
+
 	case tkn.PLUS:
 
-		result, err := binaryPlus(expr.Operator, left, right)
+		result, err := binaryPlus(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
@@ -151,7 +522,7 @@ func (i *Interpreter) VisitBinary(expr expr.BinaryExpr) (interface{}, error) {
 
 	case tkn.GREATER:
 
-		left, right, err := checkNumberOperands(expr.Operator, left, right)
+		left, right, err := checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
@@ -159,7 +530,7 @@ func (i *Interpreter) VisitBinary(expr expr.BinaryExpr) (interface{}, error) {
 
 	case tkn.GREATER_EQUAL:
 
-		left, right, err := checkNumberOperands(expr.Operator, left, right)
+		left, right, err := checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
@@ -167,7 +538,7 @@ func (i *Interpreter) VisitBinary(expr expr.BinaryExpr) (interface{}, error) {
 
 	case tkn.LESS:
 
-		left, right, err := checkNumberOperands(expr.Operator, left, right)
+		left, right, err := checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
@@ -175,7 +546,7 @@ func (i *Interpreter) VisitBinary(expr expr.BinaryExpr) (interface{}, error) {
 
 	case tkn.LESS_EQUAL:
 
-		left, right, err := checkNumberOperands(expr.Operator, left, right)
+		left, right, err := checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
@@ -197,10 +568,303 @@ func (i *Interpreter) VisitBinary(expr expr.BinaryExpr) (interface{}, error) {
 	return nil, nil
 }
 
+func (i *Interpreter) VisitVariable(expr *expr.VariableExpr) (interface{}, error) {
+	return i.lookUpVariable(expr.Name, expr)
+}
+
+func (i *Interpreter) VisitAssing(expr *expr.AssignExpr) (interface{}, error) {
+	value, err := i.Evaluate(expr.Right)
+	if err != nil {
+		return nil, err
+	}
+	if distance, ok := i.locals[expr]; ok {
+		i.env.AssignAt(distance, expr.Name, value)
+		return value, nil
+	}
+	err = i.globals.Assing(expr.Name, value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (i *Interpreter) VisitUpdate(expr expr.UpdateExpr) (interface{}, error) {
+	switch target := expr.Target.(type) {
+	case *expression.VariableExpr:
+		old, err := i.lookUpVariable(target.Name, target)
+		if err != nil {
+			return nil, err
+		}
+		value, err := i.update(expr, old)
+		if err != nil {
+			return nil, err
+		}
+		if distance, ok := i.locals[target]; ok {
+			i.env.AssignAt(distance, target.Name, value)
+		} else if err := i.globals.Assing(target.Name, value); err != nil {
+			return nil, err
+		}
+		return updated(expr, old, value), nil
+	case expression.GetExpr:
+		object, err := i.Evaluate(target.Object)
+		if err != nil {
+			return nil, err
+		}
+		instance, ok := object.(*LoxInstance)
+		if !ok {
+			return nil, loxerror.NewErrorFromToken(target.Name, loxerror.RUNTIME_ERROR_INSTANCE_PROPERTIES)
+		}
+		old, err := instance.Get(target.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := i.update(expr, old)
+		if err != nil {
+			return nil, err
+		}
+		instance.Set(target.Name, value)
+		return updated(expr, old, value), nil
+	}
+	return nil, nil
+}
+
+// update returns the new value of the target of expr, which is old.
+func (i *Interpreter) update(expr expression.UpdateExpr, old loxvalue.LoxValue) (loxvalue.LoxValue, error) {
+	if expr.Value == nil {
+		number, err := checkNumberOperand(expr.Operator, old)
+		if err != nil {
+			return nil, err
+		}
+		if expr.Operator.Type == tkn.PLUS_PLUS {
+			return number.Add(&loxvalue.Number{Value: 1}), nil
+		}
+		return number.Subtract(&loxvalue.Number{Value: 1}), nil
+	}
+	operand, err := i.Evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	operator := expr.Operator
+	operator.Type = tkn.BinaryOperator(operator.Type)
+	return binary(operator, old, operand)
+}
+
+// updated returns the value of expr: the old value of its target for a
+// postfix increment or decrement, and the new one otherwise.
+func updated(expr expression.UpdateExpr, old loxvalue.LoxValue, value loxvalue.LoxValue) loxvalue.LoxValue {
+	if expr.Postfix {
+		return old
+	}
+	return value
+}
+
+func (i *Interpreter) lookUpVariable(name tkn.Token, expr expression.Expr) (loxvalue.LoxValue, error) {
+	if distance, ok := i.locals[expr]; ok {
+		return i.env.GetAt(distance, name.Lexeme), nil
+	}
+	return i.globals.Get(name)
+}
+
+func (i *Interpreter) VisitFunction(expr expr.FunctionExpr) (interface{}, error) {
+	return NewLoxFunction(stmt.Declaration(expr), i.env, i, false), nil
+}
+
+func (i *Interpreter) VisitConditional(expr expr.ConditionalExpr) (interface{}, error) {
+	condition, err := i.Evaluate(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+	if loxvalue.IsTruthy(condition) {
+		return i.Evaluate(expr.Then)
+	}
+	return i.Evaluate(expr.Else)
+}
+
+// chain evaluates the object or callee of a property access or call. When
+// it is itself an access or call that a '?.' short-circuited, skipped is
+// true and the rest of the chain must be skipped as well.
+func (i *Interpreter) chain(e expression.Expr) (value loxvalue.LoxValue, skipped bool, err error) {
+	switch e := e.(type) {
+	case expression.GetExpr:
+		return i.get(e)
+	case expression.CallExpr:
+		return i.call(e)
+	}
+	value, err = i.Evaluate(e)
+	return value, false, err
+}
+
+// skips reports whether a '?.' short-circuits on value.
+func skips(optional bool, value loxvalue.LoxValue) bool {
+	return optional && value.Type() == loxvalue.NIL
+}
+
+func (i *Interpreter) VisitCall(expr expr.CallExpr) (interface{}, error) {
+	value, _, err := i.call(expr)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (i *Interpreter) call(expr expression.CallExpr) (loxvalue.LoxValue, bool, error) {
+
+	callee, skipped, err := i.chain(expr.Callee)
+	if err != nil {
+		return nil, false, err
+	}
+	if skipped || skips(expr.Optional, callee) {
+		return &loxvalue.Nil{}, true, nil
+	}
+
+	arguments := []loxvalue.LoxValue{}
+	for _, argument := range expr.Arguments {
+		value, err := i.Evaluate(argument)
+		if err != nil {
+			return nil, false, err
+		}
+		arguments = append(arguments, value)
+	}
+
+	function, ok := callee.(loxvalue.LoxCallable)
+	if !ok {
+		return nil, false, loxerror.NewErrorFromToken(expr.Paren, loxerror.RUNTIME_ERROR_NOT_CALLABLE)
+	}
+	if len(arguments) != function.Arity() {
+		err := loxerror.NewErrorFromToken(expr.Paren, loxerror.RUNTIME_ERROR_ARITY, function.Arity(), len(arguments))
+		if declared, ok := function.(*LoxFunction); ok {
+			name := declared.declaration.Name
+			note := fmt.Sprintf(loxerror.NOTE_DECLARED_HERE, name.Lexeme)
+			if name.Lexeme == stmt.ANONYMOUS {
+				note = loxerror.NOTE_ANONYMOUS_DECLARED_HERE
+			}
+			err.WithNote(name.Span, note)
+		}
+		return nil, false, err
+	}
+
+	name, traced := frameName(function)
+	if !traced {
+		value, err := function.Call(arguments)
+		return value, false, err
+	}
+	if len(i.frames) == FRAMES_MAX-1 {
+		return nil, false, loxerror.NewErrorFromToken(expr.Paren, loxerror.RUNTIME_ERROR_STACK_OVERFLOW)
+	}
+	i.frames = append(i.frames, frame{function: name, line: expr.Paren.Line})
+	value, err := function.Call(arguments)
+	if err, ok := err.(*loxerror.Error); ok && err.Trace == nil {
+		err.Trace = i.trace(err.Line)
+	}
+	i.frames = i.frames[:len(i.frames)-1]
+	return value, false, err
+
+}
+
+// frameName returns the name a call of function has in stack traces.
+// Natives run no Lox code and get no frame; calling a class runs its
+// initializer.
+func frameName(function loxvalue.LoxCallable) (string, bool) {
+	switch function := function.(type) {
+	case *LoxFunction:
+		return function.declaration.Name.Lexeme, true
+	case *LoxClass:
+		return "init", true
+	}
+	return "", false
+}
+
+// trace returns the active calls, innermost first, for an error raised on
+// line in the innermost one.
+func (i *Interpreter) trace(line int) []loxerror.Frame {
+	trace := []loxerror.Frame{}
+	for n := len(i.frames) - 1; n >= 0; n-- {
+		trace = append(trace, loxerror.Frame{Function: i.frames[n].function, File: i.file, Line: line})
+		line = i.frames[n].line
+	}
+	return append(trace, loxerror.Frame{Function: loxerror.SCRIPT_FRAME, File: i.file, Line: line})
+}
+
+func (i *Interpreter) VisitGet(expr expr.GetExpr) (interface{}, error) {
+	value, _, err := i.get(expr)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (i *Interpreter) get(expr expression.GetExpr) (loxvalue.LoxValue, bool, error) {
+	object, skipped, err := i.chain(expr.Object)
+	if err != nil {
+		return nil, false, err
+	}
+	if skipped || skips(expr.Optional, object) {
+		return &loxvalue.Nil{}, true, nil
+	}
+	if caught, ok := object.(*loxvalue.Error); ok {
+		if value, ok := caught.Get(expr.Name.Lexeme); ok {
+			return value, false, nil
+		}
+		return nil, false, loxerror.NewErrorFromToken(expr.Name, loxerror.RUNTIME_ERROR_UNDEFINED_PROPERTY, expr.Name.Lexeme)
+	}
+	instance, ok := object.(*LoxInstance)
+	if !ok {
+		return nil, false, loxerror.NewErrorFromToken(expr.Name, loxerror.RUNTIME_ERROR_INSTANCE_PROPERTIES)
+	}
+	value, err := instance.Get(expr.Name)
+	return value, false, err
+}
+
+func (i *Interpreter) VisitSet(expr expr.SetExpr) (interface{}, error) {
+	object, err := i.Evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+	instance, ok := object.(*LoxInstance)
+	if !ok {
+		return nil, loxerror.NewErrorFromToken(expr.Name, loxerror.RUNTIME_ERROR_INSTANCE_FIELDS)
+	}
+	value, err := i.Evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	instance.Set(expr.Name, value)
+	return value, nil
+}
+
+func (i *Interpreter) VisitThis(expr *expr.ThisExpr) (interface{}, error) {
+	return i.lookUpVariable(expr.Keyword, expr)
+}
+
+func (i *Interpreter) VisitSuper(expr *expr.SuperExpr) (interface{}, error) {
+	distance := i.locals[expr]
+	superclass := i.env.GetAt(distance, "super").(*LoxClass)
+	object := i.env.GetAt(distance-1, "this")
+	method := superclass.FindMethod(expr.Method.Lexeme)
+	if method == nil {
+		return nil, loxerror.NewErrorFromToken(expr.Method, loxerror.RUNTIME_ERROR_UNDEFINED_PROPERTY, expr.Method.Lexeme)
+	}
+	return method.Bind(object.(*LoxInstance)), nil
+}
+
 func (i *Interpreter) VisitGrouping(expr expr.GroupingExpr) (interface{}, error) {
 	return i.Evaluate(expr.Expr)
 }
 
+func (i *Interpreter) VisitInterpolation(expr expr.InterpolationExpr) (interface{}, error) {
+	var text strings.Builder
+	text.WriteString(expr.Strings[0])
+	for index, part := range expr.Exprs {
+		value, err := i.Evaluate(part)
+		if err != nil {
+			return nil, err
+		}
+		text.WriteString(value.ToString())
+		text.WriteString(expr.Strings[index+1])
+	}
+	return loxvalue.NewString(text.String()), nil
+}
+
 func binaryPlus(operator tkn.Token, left loxvalue.LoxValue, right loxvalue.LoxValue) (loxvalue.LoxValue, error) {
 
 	if left.Type() == loxvalue.NUMBER && right.Type() == loxvalue.NUMBER {
@@ -215,20 +879,45 @@ func binaryPlus(operator tkn.Token, left loxvalue.LoxValue, right loxvalue.LoxVa
 		return leftStr.Concat(rightStr), nil
 	}
 
-	return nil, loxerror.NewErrorFromToken(operator, "Operands must be two numbers or two strings.")
+	return nil, loxerror.NewErrorFromToken(operator, loxerror.RUNTIME_ERROR_OPERANDS_NUMBERS_OR_STRINGS)
 
 }
 
+func binaryBitwise(operator tkn.Token, left loxvalue.LoxValue, right loxvalue.LoxValue) (loxvalue.LoxValue, error) {
+
+	leftNum, leftOk := left.(*loxvalue.Number)
+	rightNum, rightOk := right.(*loxvalue.Number)
+	if !leftOk || !rightOk || !leftNum.IsInteger() || !rightNum.IsInteger() {
+		return nil, loxerror.NewErrorFromToken(operator, loxerror.RUNTIME_ERROR_OPERANDS_INTEGERS)
+	}
+
+	switch operator.Type {
+	case tkn.AMPERSAND:
+		return leftNum.BitAnd(rightNum), nil
+	case tkn.PIPE:
+		return leftNum.BitOr(rightNum), nil
+	case tkn.CARET:
+		return leftNum.BitXor(rightNum), nil
+	}
+	if rightNum.Value < 0 {
+		return nil, loxerror.NewErrorFromToken(operator, loxerror.RUNTIME_ERROR_NEGATIVE_SHIFT)
+	}
+	if operator.Type == tkn.LESS_LESS {
+		return leftNum.ShiftLeft(rightNum), nil
+	}
+	return leftNum.ShiftRight(rightNum), nil
+}
+
 func checkNumberOperand(operator tkn.Token, v loxvalue.LoxValue) (*loxvalue.Number, error) {
 	if v.Type() == loxvalue.NUMBER {
 		return v.(*loxvalue.Number), nil
 	}
-	return nil, loxerror.NewErrorFromToken(operator, "Operand must be a number.")
+	return nil, loxerror.NewErrorFromToken(operator, loxerror.RUNTIME_ERROR_OPERAND_NUMBER)
 }
 
 func checkNumberOperands(operator tkn.Token, left loxvalue.LoxValue, right loxvalue.LoxValue) (*loxvalue.Number, *loxvalue.Number, error) {
 	if left.Type() == loxvalue.NUMBER && right.Type() == loxvalue.NUMBER {
 		return left.(*loxvalue.Number), right.(*loxvalue.Number), nil
 	}
-	return nil, nil, loxerror.NewErrorFromToken(operator, "Operands must be a numbers.")
+	return nil, nil, loxerror.NewErrorFromToken(operator, loxerror.RUNTIME_ERROR_OPERANDS_NUMBERS)
 }
@@ -0,0 +1,490 @@
+package interpreter_test
+
+import (
+	"bytes"
+	loxerror "golox/error"
+	"golox/interpreter"
+	"golox/parser"
+	"golox/resolver"
+	"golox/scanner"
+	tkn "golox/token"
+	loxvalue "golox/value"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpreter_PrintStatements(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"print 1 + 2;", "3\n"},
+		{"print \"foo\" + \"bar\";", "foobar\n"},
+		{"print !nil;", "true\n"},
+		{"print 1; print 2;", "1\n2\n"},
+		{"if (1 < 2) print \"then\"; else print \"else\";", "then\n"},
+		{"if (nil) print \"then\"; else print \"else\";", "else\n"},
+		{"{ print 1; print 2; }", "1\n2\n"},
+		{"print 7 ~/ 2; print -7 ~/ 2; print 7.5 ~/ 2;", "3\n-4\n3\n"},
+		{"print 7 % 3; print -7 % 3; print 7 % -3; print 5.5 % 2;", "1\n2\n-2\n1.5\n"},
+		{"print 2 ** 10; print 2 ** 3 ** 2; print -2 ** 2; print 2 ** -1;", "1024\n512\n-4\n0.5\n"},
+		{"print 6 & 3; print 6 | 3; print 6 ^ 3; print ~5;", "2\n7\n5\n-6\n"},
+		{"print 1 << 10; print -16 >> 2; print 1 + 2 << 1; print 5 & 1 == 1;", "1024\n-4\n6\ntrue\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestInterpreter_Interpolation(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var name = \"Ann\"; var n = 2; print \"Hello ${name}, you have ${n * 2} items\";", "Hello Ann, you have 4 items\n"},
+		{"print \"${nil} ${true} ${1.5} ${clock == clock}\";", "nil true 1.5 true\n"},
+		{"print \"a${\"b${1 + 1}c\"}d\";", "ab2cd\n"},
+		{"fun f() {} class A {} print \"${f} ${A} ${A()}\";", "<fn f> A A instance\n"},
+		{"print \"\\${x} $1\";", "${x} $1\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestInterpreter_Variables(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var a; print a;", "nil\n"},
+		{"var a = 1; print a;", "1\n"},
+		{"var a = 1; a = 2; print a;", "2\n"},
+		{"var a; var b; a = b = 3; print a + b;", "6\n"},
+		{"var a = 1; { var a = 2; print a; } print a;", "2\n1\n"},
+		{"var a = \"global\"; { fun show() { print a; } show(); var a = \"block\"; show(); }", "global\nglobal\n"},
+		{"var a = 1; { a = 2; } print a;", "2\n"},
+		{"var a = \"outer\"; { var b = a + \"!\"; print b; }", "outer!\n"},
+		{"var i = 0; while (i < 3) { print i; i = i + 1; }", "0\n1\n2\n"},
+		{"for (var i = 0; i < 2; i = i + 1) print i;", "0\n1\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestInterpreter_VariableErrors(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected *loxerror.Error
+	}{
+		{"print a;", &loxerror.Error{Line: 1, Where: " at 'a'", Message: "Undefined variable 'a'.", Code: "LOX0501", Span: span(7, 1), Hint: "Declare it with 'var a' first."}},
+		{"a = 1;", &loxerror.Error{Line: 1, Where: " at 'a'", Message: "Undefined variable 'a'.", Code: "LOX0501", Span: span(1, 1), Hint: "Declare it with 'var a' first."}},
+		{"{ var a = 1; } print a;", &loxerror.Error{Line: 1, Where: " at 'a'", Message: "Undefined variable 'a'.", Code: "LOX0501", Span: span(22, 1), Hint: "Declare it with 'var a' first."}},
+	}
+
+	for _, test := range tests {
+		i, _ := interpret(t, test.input)
+		require.Equal(t, test.expected, i.Results[len(i.Results)-1].Err)
+	}
+
+}
+
+func TestInterpreter_NilOperators(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"class A { init() { this.b = nil; } m(x) { return x * 2; } } var a = A(); var z; print z?.b; print z?.b.c(1).d; print a?.m(2); print a.b?.c;", "nil\nnil\n4\nnil\n"},
+		{"var f; fun g(x) { return x + 1; } print f?.(1); print g?.(1);", "nil\n2\n"},
+		{"var calls = 0; fun arg() { calls = calls + 1; } var z; z?.m(arg()); print calls;", "0\n"},
+		{"print nil ?? 1; print false ?? 1; print 0 ?? 1;", "1\nfalse\n0\n"},
+		{"print 1 < 2 ? \"yes\" : \"no\"; print nil ? 1 : false ? 2 : 3;", "yes\n3\n"},
+		{"var a; print a ?? false ? 1 : 2; a = true ? 3 : 4; print a;", "2\n3\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestInterpreter_UpdateOperators(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var i = 0; print i++; print i; print ++i; print i--; print --i;", "0\n1\n2\n2\n0\n"},
+		{"var i = 1; i += 10; print i; i -= 3; print i; i *= 4; print i; i /= 8; print i; i %= 3; print i;", "11\n8\n32\n4\n1\n"},
+		{"var s = \"a\"; s += \"b\"; print s; print s += \"c\";", "ab\nabc\n"},
+		{"class C {} var c = C(); c.n = 1; var calls = 0; fun get() { calls += 1; return c; } print get().n++; print ++get().n; get().n *= 5; print c.n; print calls;", "1\n3\n15\n3\n"},
+		{"fun f() { var x = 1; fun g() { x += 1; return x++; } return g; } var g = f(); print g(); print g();", "2\n4\n"},
+		{"{ var l = 5; l++; ++l; print l; print - -l; print - --l; }", "7\n7\n-6\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestInterpreter_OperatorErrors(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected *loxerror.Error
+	}{
+		{"1 ~/ 0;", &loxerror.Error{Line: 1, Where: " at '~/'", Message: loxerror.RUNTIME_ERROR_DIVISION_BY_ZERO, Code: "LOX0515", Span: span(3, 2)}},
+		{"1 % 0;", &loxerror.Error{Line: 1, Where: " at '%'", Message: loxerror.RUNTIME_ERROR_DIVISION_BY_ZERO, Code: "LOX0515", Span: span(3, 1)}},
+		{"\"a\" ** 2;", &loxerror.Error{Line: 1, Where: " at '**'", Message: loxerror.RUNTIME_ERROR_OPERANDS_NUMBERS, Code: "LOX0504", Span: span(5, 2)}},
+		{"1.5 & 1;", &loxerror.Error{Line: 1, Where: " at '&'", Message: loxerror.RUNTIME_ERROR_OPERANDS_INTEGERS, Code: "LOX0514", Span: span(5, 1)}},
+		{"~nil;", &loxerror.Error{Line: 1, Where: " at '~'", Message: loxerror.RUNTIME_ERROR_OPERAND_INTEGER, Code: "LOX0513", Span: span(1, 1)}},
+		{"1 << -1;", &loxerror.Error{Line: 1, Where: " at '<<'", Message: loxerror.RUNTIME_ERROR_NEGATIVE_SHIFT, Code: "LOX0516", Span: span(3, 2)}},
+		{"var s = \"a\"; s++;", &loxerror.Error{Line: 1, Where: " at '++'", Message: loxerror.RUNTIME_ERROR_OPERAND_NUMBER, Code: "LOX0503", Span: span(15, 2)}},
+		{"var s = \"a\"; --s;", &loxerror.Error{Line: 1, Where: " at '--'", Message: loxerror.RUNTIME_ERROR_OPERAND_NUMBER, Code: "LOX0503", Span: span(14, 2)}},
+		{"var n = 1; n %= 0;", &loxerror.Error{Line: 1, Where: " at '%='", Message: loxerror.RUNTIME_ERROR_DIVISION_BY_ZERO, Code: "LOX0515", Span: span(14, 2)}},
+		{"var a; a.b += 1;", &loxerror.Error{Line: 1, Where: " at 'b'", Message: loxerror.RUNTIME_ERROR_INSTANCE_PROPERTIES, Code: "LOX0508", Span: span(10, 1)}},
+	}
+
+	for _, test := range tests {
+		i, _ := interpret(t, test.input)
+		require.Equal(t, test.expected, i.Results[len(i.Results)-1].Err)
+	}
+
+}
+
+func TestInterpreter_BlockRestoresEnvironmentOnError(t *testing.T) {
+
+	i, out := newInterpreter()
+	run(t, i, "var a = \"global\"; { var a = \"local\"; -a; }")
+	require.Error(t, i.Results[1].Err)
+
+	run(t, i, "print a;")
+	require.NoError(t, i.Results[0].Err)
+	require.Equal(t, "global\n", out.String())
+
+}
+
+func TestInterpreter_Functions(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fun f() { print 1; } f();", "1\n"},
+		{"fun f() {} print f();", "nil\n"},
+		{"fun f() { return; print 1; } print f();", "nil\n"},
+		{"fun add(a, b) { return a + b; } print add(1, 2);", "3\n"},
+		{"fun f() {} print f;", "<fn f>\n"},
+		{"print clock;", "<native fn>\n"},
+		{"fun fib(n) { if (n < 2) return n; return fib(n - 1) + fib(n - 2); } print fib(10);", "55\n"},
+		{"fun f() { while (true) { return \"done\"; } } print f();", "done\n"},
+		{`fun makeCounter() {
+			var i = 0;
+			fun count() { i = i + 1; return i; }
+			return count;
+		}
+		var counter = makeCounter();
+		counter();
+		print counter();`, "2\n"},
+		{"var add = fun (a, b) { return a + b; }; print add(1, 2);", "3\n"},
+		{"var mul = (a, b) => a * b; print mul(3, 4); print (() => 42)();", "12\n42\n"},
+		{"print fun () {}; print (x) => x;", "<fn anonymous>\n<fn anonymous>\n"},
+		{"fun (x) { print x; }(5);", "5\n"},
+		{"fun apply(f, v) { return f(v); } print apply((x) => x + 1, 10);", "11\n"},
+		{"fun counter() { var n = 0; return () => ++n; } var c = counter(); c(); print c();", "2\n"},
+		{"var curry = (a) => (b) => a + b; print curry(1)(2);", "3\n"},
+		{"class A { init() { this.v = 7; } get() { return () => this.v; } } print A().get()();", "7\n"},
+		{"var a = 1; print (a); print (a) + 1;", "1\n2\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestInterpreter_CallErrors(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected *loxerror.Error
+	}{
+		{"\"foo\"();", &loxerror.Error{Line: 1, Where: " at ')'", Message: "Can only call functions and classes.", Code: "LOX0506", Span: span(7, 1)}},
+		{"fun f(a) {} f();", &loxerror.Error{Line: 1, Where: " at ')'", Message: "Expected 1 arguments but got 0.", Code: "LOX0507", Span: span(15, 1),
+			Notes: []loxerror.Note{{Message: "'f' is declared here.", Span: span(5, 1)}}}},
+		{"var f = (a) => a; f();", &loxerror.Error{Line: 1, Where: " at ')'", Message: "Expected 1 arguments but got 0.", Code: "LOX0507", Span: span(21, 1),
+			Notes: []loxerror.Note{{Message: "The function is declared here.", Span: span(13, 2)}}}},
+		{"clock(1);", &loxerror.Error{Line: 1, Where: " at ')'", Message: "Expected 0 arguments but got 1.", Code: "LOX0507", Span: span(8, 1)}},
+	}
+
+	for _, test := range tests {
+		i, _ := interpret(t, test.input)
+		require.Equal(t, test.expected, i.Results[len(i.Results)-1].Err)
+	}
+
+}
+
+func TestInterpreter_StackTrace(t *testing.T) {
+
+	input := `fun inner(a) {
+  return a + 1;
+}
+fun outer() {
+  return inner("x");
+}
+class A {
+  init() {
+    outer();
+  }
+}
+A();`
+	i, _ := interpret(t, input)
+	err := i.Results[len(i.Results)-1].Err.(*loxerror.Error)
+	require.Equal(t, loxerror.RUNTIME_ERROR_OPERANDS_NUMBERS_OR_STRINGS, err.Message)
+	require.Equal(t, []loxerror.Frame{
+		{Function: "inner", Line: 2},
+		{Function: "outer", Line: 5},
+		{Function: "init", Line: 9},
+		{Function: "script", Line: 12},
+	}, err.Trace)
+
+	i, _ = interpret(t, "fun f() { return nil + 1; }\nvar a = f;\na();")
+	require.Equal(t, []loxerror.Frame{{Function: "f", Line: 1}, {Function: "script", Line: 3}}, i.Results[2].Err.(*loxerror.Error).Trace)
+
+	i, _ = interpret(t, "var f = () => nil + 1;\nf();")
+	require.Equal(t, []loxerror.Frame{{Function: "anonymous", Line: 1}, {Function: "script", Line: 2}}, i.Results[1].Err.(*loxerror.Error).Trace)
+
+}
+
+func TestInterpreter_StackOverflow(t *testing.T) {
+
+	i, _ := interpret(t, "fun f(n) { return f(n + 1); }\nf(0);")
+	err := i.Results[len(i.Results)-1].Err.(*loxerror.Error)
+	require.Equal(t, loxerror.RUNTIME_ERROR_STACK_OVERFLOW, err.Message)
+	require.Len(t, err.Trace, interpreter.FRAMES_MAX)
+
+	testOutput(t, "fun f(n) { return f(n + 1); } try { f(0); } catch (e) { print e.message; }", "Stack overflow.\n")
+
+}
+
+func TestInterpreter_Loops(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"for (var i = 0; i < 5; i++) { if (i == 1) continue; if (i == 3) break; print i; }", "0\n2\n"},
+		{"var i = 0; while (true) { i++; if (i < 3) continue; print i; break; }", "3\n"},
+		{"outer: for (var i = 0; i < 3; i++) for (var j = 0; j < 3; j++) { if (j == 1) continue outer; if (i == 2) break outer; print \"${i}${j}\"; }", "00\n10\n"},
+		{"var fs = \"\"; for (var i = 0; i < 3; i++) { var x = i; fun f() { return x; } if (i == 1) continue; fs = fs + \"${f()}\"; } print fs;", "02\n"},
+		{"fun f() { outer: while (true) { while (true) { return 1; } } } print f();", "1\n"},
+		{"while (true) { try { break; } finally { print \"finally\"; } } print \"after\";", "finally\nafter\n"},
+		{"for (var i = 0; i < 2; i++) { try { continue; } catch (e) { print \"caught\"; } finally { print i; } }", "0\n1\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestInterpreter_Exceptions(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"try { throw \"boom\"; } catch (e) { print e; }", "boom\n"},
+		{"try { print 1; } catch (e) { print e; } print 2;", "1\n2\n"},
+		{"try { print a; } catch (e) { print e.message; }", "Undefined variable 'a'.\n"},
+		{"try { 1 + nil; } catch (e) { print e.message; print e.stack; }", "Operands must be two numbers or two strings.\n\n"},
+		{"fun f() { return -\"x\"; }\ntry { f(); } catch (e) { print e.stack; }", "at f (line 1)\nat script (line 2)\n"},
+		{"try { print 1; } finally { print \"finally\"; }", "1\nfinally\n"},
+		{"var a = 0; try { a = 1; } catch (e) { a = 2; } finally { a = a + 10; } print a;", "11\n"},
+		{"try { try { throw 1; } finally { print \"inner\"; } } catch (e) { print e + 1; }", "inner\n2\n"},
+		{"try { try { throw 1; } catch (e) { throw e + 1; } } catch (e) { print e; }", "2\n"},
+		{"fun f() { try { return 1; } finally { print \"finally\"; } } print f();", "finally\n1\n"},
+		{"fun f() { try { try { return 1; } finally { print 2; } } finally { print 3; } } print f();", "2\n3\n1\n"},
+		{"fun f() { try { return 1; } finally { return 2; } } print f();", "2\n"},
+		{"fun f() { var g; try { var a = \"captured\"; fun h() { return a; } g = h; throw nil; } catch (e) {} return g; } print f()();", "captured\n"},
+		{"class A { init() { throw this; } } try { A(); } catch (e) { print e; }", "A instance\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestInterpreter_UncaughtException(t *testing.T) {
+
+	i, _ := interpret(t, "fun f() {\n  throw \"boom\";\n}\nf();")
+	err := i.Results[len(i.Results)-1].Err.(*loxerror.Error)
+	require.Equal(t, "Uncaught exception: boom.", err.Message)
+	require.Equal(t, loxvalue.NewString("boom"), err.Thrown)
+	require.Equal(t, []loxerror.Frame{{Function: "f", Line: 2}, {Function: "script", Line: 4}}, err.Trace)
+
+	i, _ = interpret(t, "fun f() { print a; }\ntry { f(); } catch (e) { throw e; }")
+	err = i.Results[len(i.Results)-1].Err.(*loxerror.Error)
+	require.Equal(t, "Undefined variable 'a'.", err.Message)
+	require.Equal(t, []loxerror.Frame{{Function: "f", Line: 1}, {Function: "script", Line: 2}}, err.Trace)
+
+}
+
+func TestInterpreter_Classes(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"class Foo {} print Foo;", "Foo\n"},
+		{"class Foo {} print Foo();", "Foo instance\n"},
+		{"class Foo {} var foo = Foo(); foo.bar = 1; print foo.bar;", "1\n"},
+		{"class Foo { bar() { return \"bar\"; } } print Foo().bar();", "bar\n"},
+		{"class Foo { bar() { return this.baz; } } var foo = Foo(); foo.baz = 2; print foo.bar();", "2\n"},
+		{"class Foo { bar() { return this; } } var m = Foo().bar; print m();", "Foo instance\n"},
+		{"class Point { init(x, y) { this.x = x; this.y = y; } } var p = Point(1, 2); print p.x + p.y;", "3\n"},
+		{"class Foo { init() { return; } } var foo = Foo(); print foo.init();", "Foo instance\n"},
+		{"class A { hi() { return \"A\"; } } class B < A {} print B().hi();", "A\n"},
+		{`class A { method() { return "A method"; } }
+		class B < A { method() { return "B method"; } test() { return super.method(); } }
+		class C < B {}
+		print C().test();`, "A method\n"},
+		{"class A { init(n) { this.n = n; } } class B < A { init() { super.init(7); } } print B().n;", "7\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestInterpreter_ClassErrors(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected *loxerror.Error
+	}{
+		{"var a = 1; a.b;", &loxerror.Error{Line: 1, Where: " at 'b'", Message: "Only instances have properties.", Code: "LOX0508", Span: span(14, 1)}},
+		{"var a = 1; a.b = 2;", &loxerror.Error{Line: 1, Where: " at 'b'", Message: "Only instances have fields.", Code: "LOX0509", Span: span(14, 1)}},
+		{"class Foo {} Foo().bar;", &loxerror.Error{Line: 1, Where: " at 'bar'", Message: "Undefined property 'bar'.", Code: "LOX0502", Span: span(20, 3)}},
+		{"var A = 1; class B < A {}", &loxerror.Error{Line: 1, Where: " at 'A'", Message: "Superclass must be a class.", Code: "LOX0510", Span: span(22, 1)}},
+		{"class Foo { init(a) {} } Foo();", &loxerror.Error{Line: 1, Where: " at ')'", Message: "Expected 1 arguments but got 0.", Code: "LOX0507", Span: span(30, 1)}},
+	}
+
+	for _, test := range tests {
+		i, _ := interpret(t, test.input)
+		require.Equal(t, test.expected, i.Results[len(i.Results)-1].Err)
+	}
+
+}
+
+func TestInterpreter_NativeFunctions(t *testing.T) {
+
+	i, out := newInterpreter()
+	i.Define("twice", loxvalue.NewNativeFunction("twice", 1, func(arguments []loxvalue.LoxValue) (loxvalue.LoxValue, error) {
+		n := arguments[0].(*loxvalue.Number)
+		return &loxvalue.Number{Value: n.Value * 2}, nil
+	}))
+	run(t, i, "print twice(21);")
+
+	require.NoError(t, i.Results[0].Err)
+	require.Equal(t, "42\n", out.String())
+
+}
+
+func TestInterpreter_Results(t *testing.T) {
+
+	i, _ := interpret(t, "1 + 2; \"foo\";")
+	require.Len(t, i.Results, 2)
+	require.Equal(t, "3", i.Results[0].Value.ToString())
+	require.Equal(t, "foo", i.Results[1].Value.ToString())
+	require.NoError(t, i.Results[0].Err)
+
+}
+
+func TestInterpreter_StopOnError(t *testing.T) {
+
+	i, out := interpret(t, "print 1; -\"foo\"; print 2;")
+	require.Equal(t, "1\n", out.String())
+	require.Len(t, i.Results, 2)
+	require.Equal(t, &loxerror.Error{Line: 1, Where: " at '-'", Message: "Operand must be a number.", Code: "LOX0503", Span: span(10, 1)}, i.Results[1].Err)
+
+}
+
+func TestInterpreter_ContinueOnError(t *testing.T) {
+
+	i, out := newInterpreter()
+	i.SetErrorPolicy(interpreter.ContinueOnError)
+	run(t, i, "print 1; -\"foo\"; print 2;")
+
+	require.Equal(t, "1\n2\n", out.String())
+	require.Len(t, i.Results, 3)
+	require.Error(t, i.Results[1].Err)
+
+}
+
+func newInterpreter() (*interpreter.Interpreter, *bytes.Buffer) {
+
+	out := &bytes.Buffer{}
+	i := interpreter.NewInterpreter()
+	i.SetOutput(out)
+	return i, out
+
+}
+
+func run(t *testing.T, i *interpreter.Interpreter, input string) {
+
+	scanner := scanner.NewScanner(input)
+	tokens, errors := scanner.Scan()
+	require.Empty(t, errors)
+	parser := parser.NewParser(tokens)
+	statements, errors := parser.Parse()
+	require.Empty(t, errors)
+	resolver := resolver.NewResolver()
+	locals, errors := resolver.Resolve(statements)
+	require.Empty(t, errors)
+	i.Resolve(locals)
+	i.Interpret(statements)
+
+}
+
+func interpret(t *testing.T, input string) (*interpreter.Interpreter, *bytes.Buffer) {
+
+	i, out := newInterpreter()
+	run(t, i, input)
+	return i, out
+
+}
+
+func testOutput(t *testing.T, input string, expected string) {
+
+	i, out := interpret(t, input)
+	for _, result := range i.Results {
+		require.NoError(t, result.Err)
+	}
+	require.Equal(t, expected, out.String())
+
+}
+
+// span returns the span of length bytes starting at column on line 1.
+func span(column, length int) tkn.Span {
+	return tkn.Span{
+		Start: tkn.Position{Line: 1, Column: column, Offset: column - 1},
+		End:   tkn.Position{Line: 1, Column: column + length, Offset: column - 1 + length},
+	}
+}
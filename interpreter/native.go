@@ -0,0 +1,11 @@
+package interpreter
+
+import (
+	loxvalue "golox/value"
+)
+
+func defineNatives(env *Environment) {
+	for _, native := range loxvalue.Natives() {
+		env.Define(native.Name, native)
+	}
+}
@@ -2,10 +2,17 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
-	"golox/interpreter"
+	"golox/bytecode"
+	"golox/diagnostics"
+	"golox/expr"
 	"golox/parser"
+	"golox/resolver"
 	"golox/scanner"
+	"golox/stmt"
+	"io"
 	"os"
 )
 
@@ -13,76 +20,228 @@ import (
 const (
 	EX_OK          = 0   // successful termination
 	EX_USAGE       = 64  // command line usage error
+	EX_DATAERR     = 65  // the script failed to scan, parse, resolve or compile
+	EX_SOFTWARE    = 70  // the script failed at runtime, or golox itself did
+	EX_IOERR       = 74  // a file could not be read or written
 )
 
+// status is the exit status of the run so far. Failures only ever raise it,
+// so golox exits with the worst of them; the codes above are ordered by
+// severity.
+var status = EX_OK
+
+func fail(code int) {
+	if code > status {
+		status = code
+	}
+}
+
+// statusError is an error that ends golox with a particular exit status.
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// ioError reports a file that could not be read or written.
+func ioError(format string, args ...interface{}) error {
+	return &statusError{status: EX_IOERR, err: fmt.Errorf(format, args...)}
+}
+
+// color reports whether diagnostics are printed with ANSI colors.
+var color bool
+
+// diagnosticsFormat is how errors are reported: "text" for people, or
+// "json" and "sarif" for tools.
+var diagnosticsFormat string
+
 func main() {
 	var err error
-	args := os.Args[1:]
+	backendName := flag.String("backend", "ast", "execution backend: \"ast\" (tree-walking interpreter) or \"vm\" (bytecode virtual machine)")
+	colorName := flag.String("color", "auto", "color diagnostics: \"auto\", \"always\" or \"never\"")
+	flag.StringVar(&diagnosticsFormat, "diagnostics", "text", "error format: \"text\", \"json\" (one record per line) or \"sarif\"")
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	flag.Usage = usage
+	parseFlags(flag.CommandLine, os.Args[1:])
+	args := flag.Args()
+	color, err = colorEnabled(*colorName)
+	if err == nil && diagnosticsFormat != "text" && diagnosticsFormat != "json" && diagnosticsFormat != "sarif" {
+		err = fmt.Errorf("unknown diagnostics format %q", diagnosticsFormat)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		usage()
+		os.Exit(EX_USAGE)
+	}
+	if len(args) > 0 {
+		var command func([]string) error
+		switch args[0] {
+		case "compile":
+			command = runCompile
+		case "disasm":
+			command = runDisasm
+		case "fmt":
+			command = runFmt
+		case "ast":
+			command = runAst
+		}
+		if command != nil {
+			exit(command(args[1:]))
+		}
+	}
 	if len(args) > 1 {
-		fmt.Println("Usage: golox [script]")
+		usage()
+		os.Exit(EX_USAGE)
+	}
+	backend, err := NewBackend(*backendName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		usage()
 		os.Exit(EX_USAGE)
 	}
 	if len(args) == 1 {
-		err = runFile(args[0])
-	} else {
-		err = runPrompt()
+		exit(runFile(args[0], backend))
 	}
+	exit(runPrompt(backend))
+}
+
+// parseFlags parses the flags of golox or of one of its commands. The flag
+// package has already reported a bad flag and printed the usage; that is a
+// usage error, while -h only asked for the usage.
+func parseFlags(flags *flag.FlagSet, args []string) {
+	err := flags.Parse(args)
+	if err == flag.ErrHelp {
+		os.Exit(EX_OK)
+	}
+	if err != nil {
+		os.Exit(EX_USAGE)
+	}
+}
+
+// exit reports err, if any, and ends golox with the worst status seen.
+// Errors without a status of their own are failures of golox itself.
+func exit(err error) {
 	if err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
+		var withStatus *statusError
+		if errors.As(err, &withStatus) {
+			fail(withStatus.status)
+		} else {
+			fail(EX_SOFTWARE)
+		}
 	}
+	os.Exit(status)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: golox [-backend=ast|vm] [-color=auto|always|never] [-diagnostics=text|json|sarif] [script]")
+	fmt.Fprintln(os.Stderr, "       golox compile [-o output.loxc] script")
+	fmt.Fprintln(os.Stderr, "       golox disasm script")
+	fmt.Fprintln(os.Stderr, "       golox fmt [-w] script...")
+	fmt.Fprintln(os.Stderr, "       golox ast [-json] script")
 }
 
-func runFile(filename string) error {
+func runFile(filename string, backend Backend) error {
 	content, err := os.ReadFile(filename)
 	if err != nil {
-		return fmt.Errorf("failed to read file: %v", err)
+		return ioError("failed to read file: %v", err)
+	}
+	if bytecode.IsImage(content) {
+		return runImage(filename, content)
 	}
-	interpreter := interpreter.NewInterpreter()
-	Run(string(content), interpreter)
+	fail(Run(filename, string(content), backend))
 	return nil
 }
 
-func runPrompt() error {
+func runPrompt(backend Backend) error {
 	reader := bufio.NewReader(os.Stdin)
-	interpreter := interpreter.NewInterpreter()
 	for {
 		fmt.Print("> ")
 		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			fmt.Println()
+			return nil
+		}
 		if err != nil {
-			return err
+			return ioError("failed to read input: %v", err)
 		}
-		Run(line, interpreter)
+		Run("", line, backend)
 	}
 }
 
-func Run(source string, interpreter *interpreter.Interpreter) {
+// Run executes source, which was read from filename, on backend, reports
+// any errors and returns the exit status they amount to. filename is empty
+// for code typed at the prompt.
+func Run(filename, source string, backend Backend) int {
+	statements, locals, errors := parse(source)
+	if len(errors) > 0 {
+		printErrors(filename, source, errors)
+		return EX_DATAERR
+	}
+	status, errors := backend.Execute(filename, statements, locals)
+	printErrors(filename, source, errors)
+	return status
+}
 
-	hasError := false
+// parse runs the front end shared by every command: scanning, parsing and
+// resolving. Scanner and parser errors are reported together; the resolver
+// only runs on a program that parsed cleanly.
+func parse(source string) ([]stmt.Stmt, map[expr.Expr]int, []error) {
 	scanner := scanner.NewScanner(source)
-	tokens, errors := scanner.Scan()
+	tokens, scanErrors := scanner.Scan()
+	parser := parser.NewParser(tokens)
+	statements, parseErrors := parser.Parse()
+	errors := append(scanErrors, parseErrors...)
 	if len(errors) > 0 {
-		printErrors(errors)
-		hasError = true
+		return nil, nil, errors
 	}
-	parser := parser.NewParser(tokens)
-	statements, errors := parser.Parse()
+	resolver := resolver.NewResolver()
+	locals, errors := resolver.Resolve(statements)
 	if len(errors) > 0 {
-		printErrors(errors)
-		hasError = true
+		return nil, nil, errors
 	}
-	if hasError {
-		return
+	return statements, locals, nil
+}
+
+// printErrors reports errors in source, the program read from filename, on
+// standard error in the format chosen with -diagnostics. Text shows excerpts
+// of the lines the errors point at.
+func printErrors(filename, source string, errors []error) {
+	var err error
+	switch diagnosticsFormat {
+	case "json":
+		err = diagnostics.WriteJSON(os.Stderr, filename, errors)
+	case "sarif":
+		err = diagnostics.WriteSARIF(os.Stderr, filename, errors)
+	default:
+		diagnostics.NewRenderer(filename, source, color).RenderAll(os.Stderr, errors)
 	}
-	interpreter.Interpret(statements)
-	for _, statement := range interpreter.Results {
-		if statement.Err != nil {
-			fmt.Println(statement.Err.Error())
-		}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
 	}
 }
 
-func printErrors(errors []error) {
-	for _, err := range errors {
-		fmt.Println(err.Error())
+// colorEnabled resolves the -color flag. "auto" colors diagnostics only when
+// standard error is a terminal and NO_COLOR is not set.
+func colorEnabled(name string) (bool, error) {
+	switch name {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if _, ok := os.LookupEnv("NO_COLOR"); ok {
+			return false, nil
+		}
+		info, err := os.Stderr.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
 	}
-}
\ No newline at end of file
+	return false, fmt.Errorf("unknown color mode %q", name)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"golox/bytecode"
+	"golox/diagnostics"
+	loxerror "golox/error"
+	tkn "golox/token"
+	loxvalue "golox/value"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileFile(t *testing.T) {
+
+	function, err := compileFile("testdata/ok.lox")
+	require.NoError(t, err)
+	require.NotNil(t, function)
+	require.Equal(t, EX_OK, status)
+
+}
+
+func TestReadImage_Corrupt(t *testing.T) {
+
+	content, err := os.ReadFile("testdata/truncated.loxc")
+	require.NoError(t, err)
+	require.True(t, bytecode.IsImage(content))
+
+	_, err = readImage(content)
+	require.ErrorIs(t, err, bytecode.ErrTruncated)
+	require.Equal(t, EX_DATAERR, err.(*statusError).status)
+
+}
+
+func TestImageFault(t *testing.T) {
+
+	recovered := func(f func()) (r interface{}) {
+		defer func() { r = recover() }()
+		f()
+		return nil
+	}
+	var stack []loxvalue.LoxValue
+	var value loxvalue.LoxValue = loxvalue.NewBoolean(true)
+	var fields map[string]int
+
+	require.True(t, imageFault(recovered(func() { _ = stack[len(stack)-1] })))
+	require.True(t, imageFault(recovered(func() { _ = value.(*loxvalue.Number) })))
+	require.False(t, imageFault(recovered(func() { fields["a"] = 1 })))
+	require.False(t, imageFault(recovered(func() { panic("bug") })))
+
+}
+
+func TestWriteImage(t *testing.T) {
+
+	output := filepath.Join(t.TempDir(), "ok.loxc")
+	function, err := compileFile("testdata/ok.lox")
+	require.NoError(t, err)
+	require.NoError(t, writeImage(output, function))
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	_, err = readImage(content)
+	require.NoError(t, err)
+
+	unencodable := bytecode.NewFunction("")
+	unencodable.Chunk.AddConstant(loxvalue.NewBoolean(true))
+	err = writeImage(output, unencodable)
+	require.ErrorContains(t, err, "failed to write image")
+	require.Equal(t, EX_IOERR, err.(*statusError).status)
+	kept, err := os.ReadFile(output)
+	require.NoError(t, err)
+	require.Equal(t, content, kept)
+	entries, err := os.ReadDir(filepath.Dir(output))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+}
+
+func TestRunImage_StackUnderflow(t *testing.T) {
+
+	function := bytecode.NewFunction("")
+	function.Chunk.WriteOp(bytecode.OP_POP, 1, tkn.Span{})
+	function.Chunk.WriteOp(bytecode.OP_POP, 1, tkn.Span{})
+	function.Chunk.WriteOp(bytecode.OP_RETURN, 1, tkn.Span{})
+	image := &bytes.Buffer{}
+	require.NoError(t, bytecode.WriteImage(image, function))
+
+	err := runImage("underflow.loxc", image.Bytes())
+	require.ErrorContains(t, err, "invalid compiled image")
+	require.Equal(t, EX_DATAERR, err.(*statusError).status)
+
+}
+
+func TestBackends_SameDiagnostics(t *testing.T) {
+
+	recursion := "fun f(n) {\n  if (n == 0) print x;\n  f(n - 1);\n}\n"
+	tests := []struct {
+		source   string
+		expected []string
+	}{
+		{"print a;", []string{"Undefined variable 'a'.", "Declare it with 'var a' first."}},
+		{"a = 1;", []string{"Undefined variable 'a'.", "Declare it with 'var a' first."}},
+		{"fun f(a) {}\nf();", []string{"Expected 1 arguments but got 0.", "'f' is declared here."}},
+		{"var f = (a) => a;\nf();", []string{"The function is declared here."}},
+		{"class A { m(a) {} }\nvar m = A().m;\nm();", []string{"'m' is declared here."}},
+		{recursion + "f(30);", []string{"at f (test.lox:2)", "at script (test.lox:5)", "... 12 more frames"}},
+		{recursion + "try { f(30); } catch (e) { print e.stack; }", []string{"at f (test.lox:2)", "at script (test.lox:5)", "... 12 more frames"}},
+	}
+
+	run := func(name, source string) string {
+		backend, err := NewBackend(name)
+		require.NoError(t, err)
+		out := &bytes.Buffer{}
+		switch backend := backend.(type) {
+		case *treeWalker:
+			backend.interpreter.SetOutput(out)
+		case *bytecodeVM:
+			backend.vm.SetOutput(out)
+		}
+		statements, locals, errors := parse(source)
+		require.Empty(t, errors)
+		_, errors = backend.Execute("test.lox", statements, locals)
+		diagnostics.NewRenderer("test.lox", source, false).RenderAll(out, errors)
+		return out.String()
+	}
+
+	for _, test := range tests {
+		ast := run("ast", test.source)
+		require.Equal(t, ast, run("vm", test.source), test.source)
+		for _, expected := range test.expected {
+			require.Contains(t, ast, expected, test.source)
+		}
+	}
+
+	// Method calls compile to a single instruction located at the method
+	// name, so the VM points there rather than at the parenthesis.
+	invoke := "class A { m(a) {} }\nA().m();"
+	for _, name := range []string{"ast", "vm"} {
+		require.Contains(t, run(name, invoke), "note: 'm' is declared here.\n --> test.lox:1:11")
+	}
+
+	stack := strings.Split(strings.TrimSpace(run("vm", tests[len(tests)-1].source)), "\n")
+	require.Len(t, stack, loxerror.MAX_TRACE_FRAMES+1)
+
+}
@@ -5,9 +5,12 @@ import (
 	"golox/expr"
 	"golox/stmt"
 	tkn "golox/token"
-	loxvalue "golox/value"
+	"strings"
 )
 
+// MAX_ARGUMENTS bounds the number of parameters and call arguments.
+const MAX_ARGUMENTS = 255
+
 type Parser struct {
 	tokens   []tkn.Token
 	position int
@@ -45,6 +48,13 @@ func (p *Parser) check(tokenType tkn.TokenType) bool {
 	return p.peek().Type == tokenType
 }
 
+func (p *Parser) checkNext(tokenType tkn.TokenType) bool {
+	if p.isAtEnd() {
+		return false
+	}
+	return p.tokens[p.position+1].Type == tokenType
+}
+
 func (p *Parser) advance() {
 	if !p.isAtEnd() {
 		p.position++
@@ -72,10 +82,21 @@ func (p *Parser) match(tokens ...tkn.TokenType) bool {
 	return false
 }
 
+// spanFrom returns the span from the start of token to the end of the last
+// consumed token.
+func (p *Parser) spanFrom(start tkn.Token) tkn.Span {
+	return tkn.Span{Start: start.Span.Start, End: p.previous().Span.End}
+}
+
 func (p *Parser) declaration() (stmt.Stmt, error) {
 	var stmt stmt.Stmt
 	var err error
-	if p.match(tkn.VAR) {
+	if p.match(tkn.CLASS) {
+		stmt, err = p.classDeclaration()
+	} else if p.check(tkn.FUN) && !p.checkNext(tkn.LEFT_PAREN) {
+		p.advance()
+		stmt, err = p.function("function")
+	} else if p.match(tkn.VAR) {
 		stmt, err =  p.varDeclaration()
 	} else {
 		stmt, err = p.statement()
@@ -87,8 +108,130 @@ func (p *Parser) declaration() (stmt.Stmt, error) {
 	return stmt, nil
 }
 
+func (p *Parser) classDeclaration() (stmt.Stmt, error) {
+
+	start := p.previous()
+	err := p.consume(tkn.IDENTIFIER, loxerror.PARSE_ERROR_MISSING_CLASS_NAME)
+	if err != nil {
+		return nil, err
+	}
+	name := p.previous()
+
+	var superclass *expr.VariableExpr
+	if p.match(tkn.LESS) {
+		err = p.consume(tkn.IDENTIFIER, loxerror.PARSE_ERROR_MISSING_SUPERCLASS_NAME)
+		if err != nil {
+			return nil, err
+		}
+		superclass = &expr.VariableExpr{
+			Name: p.previous(),
+			Span: p.previous().Span,
+		}
+	}
+
+	err = p.consume(tkn.LEFT_BRACE, loxerror.PARSE_ERROR_MISSING_CLASS_LEFT_BRACE)
+	if err != nil {
+		return nil, err
+	}
+	leftBrace := p.previous()
+
+	methods := []stmt.FunStmt{}
+	for !p.check(tkn.RIGHT_BRACE) && !p.isAtEnd() {
+		method, err := p.function("method")
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, method)
+	}
+
+	err = p.consumeClosing(tkn.RIGHT_BRACE, loxerror.PARSE_ERROR_MISSING_CLASS_RIGHT_BRACE, leftBrace)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.ClassStmt{
+		Name: name,
+		Superclass: superclass,
+		Methods: methods,
+		Span: p.spanFrom(start),
+		Doc: start.Doc,
+	}, nil
+
+}
+
+func (p *Parser) function(kind string) (stmt.FunStmt, error) {
+
+	// Methods start at their name, functions at the 'fun' keyword.
+	start := p.peek()
+	if kind == "function" {
+		start = p.previous()
+	}
+	err := p.consume(tkn.IDENTIFIER, loxerror.PARSE_ERROR_MISSING_FUNCTION_NAME, kind)
+	if err != nil {
+		return stmt.FunStmt{}, err
+	}
+	name := p.previous()
+
+	err = p.consume(tkn.LEFT_PAREN, loxerror.PARSE_ERROR_MISSING_FUNCTION_LEFT_PAREN, kind)
+	if err != nil {
+		return stmt.FunStmt{}, err
+	}
+	parameters, err := p.parameters()
+	if err != nil {
+		return stmt.FunStmt{}, err
+	}
+
+	err = p.consume(tkn.LEFT_BRACE, loxerror.PARSE_ERROR_MISSING_FUNCTION_LEFT_BRACE, kind)
+	if err != nil {
+		return stmt.FunStmt{}, err
+	}
+	body, err := p.block()
+	if err != nil {
+		return stmt.FunStmt{}, err
+	}
+
+	return stmt.FunStmt{
+		Name: name,
+		Params: parameters,
+		Body: body,
+		Span: p.spanFrom(start),
+		Doc: start.Doc,
+	}, nil
+
+}
+
+// parameters parses a parameter list after its '('.
+func (p *Parser) parameters() ([]tkn.Token, error) {
+
+	leftParen := p.previous()
+	parameters := []tkn.Token{}
+	if !p.check(tkn.RIGHT_PAREN) {
+		for {
+			if len(parameters) >= MAX_ARGUMENTS {
+				return nil, loxerror.NewErrorFromToken(p.peek(), loxerror.PARSE_ERROR_TOO_MANY_PARAMETERS)
+			}
+			err := p.consume(tkn.IDENTIFIER, loxerror.PARSE_ERROR_MISSING_PARAMETER_NAME)
+			if err != nil {
+				return nil, err
+			}
+			parameters = append(parameters, p.previous())
+			if !p.match(tkn.COMMA) {
+				break
+			}
+		}
+	}
+
+	err := p.consumeClosing(tkn.RIGHT_PAREN, loxerror.PARSE_ERROR_MISSING_PARAMETERS_RIGHT_PAREN, leftParen)
+	if err != nil {
+		return nil, err
+	}
+	return parameters, nil
+
+}
+
 func (p *Parser) varDeclaration() (stmt.Stmt, error) {
 
+	start := p.previous()
 	err := p.consume(tkn.IDENTIFIER, loxerror.PARSE_ERROR_VARIABLE_EXPR_MISSING_NAME)
 	if err != nil {
 		return nil, err
@@ -111,12 +254,23 @@ func (p *Parser) varDeclaration() (stmt.Stmt, error) {
 	return stmt.VarStmt{
 		Name: name,
 		Initializer: initializer,
+		Span: p.spanFrom(start),
+		Doc: start.Doc,
 	}, nil
 
 }
 
 func (p *Parser) statement() (stmt.Stmt, error) {
 	
+	if p.check(tkn.IDENTIFIER) && p.checkNext(tkn.COLON) {
+		return p.labeledStatement()
+	}
+	if p.match(tkn.BREAK) {
+		return p.breakStatement()
+	}
+	if p.match(tkn.CONTINUE) {
+		return p.continueStatement()
+	}
 	if p.match(tkn.FOR) {
 		return p.forStatement()
 	}
@@ -126,20 +280,111 @@ func (p *Parser) statement() (stmt.Stmt, error) {
 	if p.match(tkn.PRINT) {
 		return p.printStatement()
 	}
+	if p.match(tkn.RETURN) {
+		return p.returnStatement()
+	}
 	if p.match(tkn.LEFT_BRACE) {
 		return p.blockStatement()
 	}
 	if p.match(tkn.WHILE) {
 		return p.while()
 	}
+	if p.match(tkn.THROW) {
+		return p.throwStatement()
+	}
+	if p.match(tkn.TRY) {
+		return p.tryStatement()
+	}
 	return p.expressionStatement()
 
 }
 
+// labeledStatement parses a loop preceded by a label, "name: while ...".
+func (p *Parser) labeledStatement() (stmt.Stmt, error) {
+
+	label := p.peek()
+	p.advance()
+	p.advance()
+
+	var loop stmt.Stmt
+	var err error
+	if p.match(tkn.WHILE) {
+		loop, err = p.while()
+	} else if p.match(tkn.FOR) {
+		loop, err = p.forStatement()
+	} else {
+		return nil, loxerror.NewErrorFromToken(p.peek(), loxerror.PARSE_ERROR_MISSING_LABELED_LOOP)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch l := loop.(type) {
+	case stmt.WhileStmt:
+		l.Label = &label
+		l.Span = p.spanFrom(label)
+		return l, nil
+	case stmt.ForStmt:
+		l.Label = &label
+		l.Span = p.spanFrom(label)
+		return l, nil
+	}
+	return loop, nil
+
+}
+
+func (p *Parser) breakStatement() (stmt.Stmt, error) {
+
+	keyword := p.previous()
+	label, err := p.jumpLabel(loxerror.PARSE_ERROR_MISSING_BREAK_SEMICOLON)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.BreakStmt{
+		Keyword: keyword,
+		Label: label,
+		Span: p.spanFrom(keyword),
+	}, nil
+
+}
+
+func (p *Parser) continueStatement() (stmt.Stmt, error) {
+
+	keyword := p.previous()
+	label, err := p.jumpLabel(loxerror.PARSE_ERROR_MISSING_CONTINUE_SEMICOLON)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ContinueStmt{
+		Keyword: keyword,
+		Label: label,
+		Span: p.spanFrom(keyword),
+	}, nil
+
+}
+
+// jumpLabel parses the optional label and the semicolon that end a break or
+// continue statement.
+func (p *Parser) jumpLabel(message string) (*tkn.Token, error) {
+
+	var label *tkn.Token
+	if p.match(tkn.IDENTIFIER) {
+		name := p.previous()
+		label = &name
+	}
+	err := p.consume(tkn.SEMICOLON, message)
+	if err != nil {
+		return nil, err
+	}
+	return label, nil
+
+}
+
 func (p *Parser) forStatement() (stmt.Stmt, error) {
 
+	start := p.previous()
 	var err error
-	err = p.consume(tkn.LEFT_PAREN, "Expect '(' after 'for'.");
+	err = p.consume(tkn.LEFT_PAREN, loxerror.PARSE_ERROR_MISSING_FOR_LEFT_PAREN);
 	if err != nil {
 		return nil, err
 	}
@@ -163,7 +408,7 @@ func (p *Parser) forStatement() (stmt.Stmt, error) {
 			return nil, err
 		}
 	}
-    err = p.consume(tkn.SEMICOLON, "Expect ';' after loop condition.");
+    err = p.consume(tkn.SEMICOLON, loxerror.PARSE_ERROR_MISSING_LOOP_CONDITION_SEMICOLON);
 	if err != nil {
 		return nil, err
 	}
@@ -175,7 +420,7 @@ func (p *Parser) forStatement() (stmt.Stmt, error) {
 			return nil, err
 		}
 	}
-	err = p.consume(tkn.RIGHT_PAREN, "Expect ')' after for clauses.");
+	err = p.consume(tkn.RIGHT_PAREN, loxerror.PARSE_ERROR_MISSING_FOR_RIGHT_PAREN);
 	if err != nil {
 		return nil, err
 	}
@@ -185,46 +430,26 @@ func (p *Parser) forStatement() (stmt.Stmt, error) {
 		return nil, err
 	}
 
-	if increment != nil {
-		body = stmt.BlockStmt{
-			Statements: []stmt.Stmt{
-				body, stmt.ExprStmt{E: increment},
-			},
-		}
-	}
-
-	if condition == nil {
-		condition = expr.LiteralExpr{
-			Value: loxvalue.Boolean{Value: true},
-		}
-	}
-
-	body = stmt.WhileStmt{
-		Condition: condition,
-		Body: body,
-	}
-
-	if initializer != nil {
-		body = stmt.BlockStmt{
-			Statements: []stmt.Stmt{
-				initializer, body,
-			},
-		}
-	}
-
-	return body, nil
+	return stmt.ForStmt{
+		Initializer: initializer,
+		Condition:   condition,
+		Increment:   increment,
+		Body:        body,
+		Span:        p.spanFrom(start),
+	}, nil
 
 }
 
 
 func (p *Parser) while() (stmt.Stmt, error) {
 
-	p.consume(tkn.LEFT_PAREN, "Expect '(' after 'while'.")
+	start := p.previous()
+	p.consume(tkn.LEFT_PAREN, loxerror.PARSE_ERROR_MISSING_WHILE_LEFT_PAREN)
 	condition, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
-	p.consume(tkn.RIGHT_PAREN, "Expect '(' after while condition.")
+	p.consume(tkn.RIGHT_PAREN, loxerror.PARSE_ERROR_MISSING_WHILE_RIGHT_PAREN)
 	body, err := p.statement()
 	if err != nil {
 		return nil, err
@@ -232,18 +457,20 @@ func (p *Parser) while() (stmt.Stmt, error) {
 	return stmt.WhileStmt{
 		Condition: condition,
 		Body: body,
+		Span: p.spanFrom(start),
 	}, nil
 
 }
 
 func (p *Parser) ifStatement() (stmt.Stmt, error) {
 
-	p.consume(tkn.LEFT_PAREN, "Expect '(' after 'if'.")
+	start := p.previous()
+	p.consume(tkn.LEFT_PAREN, loxerror.PARSE_ERROR_MISSING_IF_LEFT_PAREN)
 	condition, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
-	p.consume(tkn.RIGHT_PAREN, "Expect ')' after if condition.")
+	p.consume(tkn.RIGHT_PAREN, loxerror.PARSE_ERROR_MISSING_IF_RIGHT_PAREN)
 
 	thenBranch, err := p.statement()
 	if err != nil {
@@ -262,12 +489,29 @@ func (p *Parser) ifStatement() (stmt.Stmt, error) {
 		Condition: condition,
 		ThenBrnach: thenBranch,
 		ElseBranch: elseBranch,
+		Span: p.spanFrom(start),
 	},nil
 
 }
 
 func (p *Parser) blockStatement() (stmt.Stmt, error) {
 
+	start := p.previous()
+	statements, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	
+	return stmt.BlockStmt{
+		Statements: statements,
+		Span: p.spanFrom(start),
+	}, nil
+
+}
+
+func (p *Parser) block() ([]stmt.Stmt, error) {
+
+	leftBrace := p.previous()
 	statements := []stmt.Stmt{}
 
 	for !p.check(tkn.RIGHT_BRACE) && !p.isAtEnd() {
@@ -278,46 +522,151 @@ func (p *Parser) blockStatement() (stmt.Stmt, error) {
 		statements = append(statements, statement)
 	}
 
-	err := p.consume(tkn.RIGHT_BRACE, "Expect '}' after block.")
+	err := p.consumeClosing(tkn.RIGHT_BRACE, loxerror.PARSE_ERROR_MISSING_BLOCK_RIGHT_BRACE, leftBrace)
 	if err != nil {
 		return nil, err
 	}
-	
-	return stmt.BlockStmt{
-		Statements: statements,
+
+	return statements, nil
+
+}
+
+func (p *Parser) returnStatement() (stmt.Stmt, error) {
+
+	keyword := p.previous()
+	var value expr.Expr
+	var err error
+	if !p.check(tkn.SEMICOLON) {
+		value, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = p.consume(tkn.SEMICOLON, loxerror.PARSE_ERROR_MISSING_RETURN_SEMICOLON)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.ReturnStmt{
+		Keyword: keyword,
+		Value: value,
+		Span: p.spanFrom(keyword),
 	}, nil
 
 }
 
+func (p *Parser) throwStatement() (stmt.Stmt, error) {
+
+	keyword := p.previous()
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	err = p.consume(tkn.SEMICOLON, loxerror.PARSE_ERROR_MISSING_THROW_SEMICOLON)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.ThrowStmt{
+		Keyword: keyword,
+		Value: value,
+		Span: p.spanFrom(keyword),
+	}, nil
+
+}
+
+func (p *Parser) tryStatement() (stmt.Stmt, error) {
+
+	keyword := p.previous()
+	err := p.consume(tkn.LEFT_BRACE, loxerror.PARSE_ERROR_MISSING_TRY_LEFT_BRACE)
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.blockStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	tryStmt := stmt.TryStmt{
+		Keyword: keyword,
+		Body: body.(stmt.BlockStmt),
+	}
+	if p.match(tkn.CATCH) {
+		err = p.consume(tkn.LEFT_PAREN, loxerror.PARSE_ERROR_MISSING_CATCH_LEFT_PAREN)
+		if err != nil {
+			return nil, err
+		}
+		err = p.consume(tkn.IDENTIFIER, loxerror.PARSE_ERROR_MISSING_CATCH_NAME)
+		if err != nil {
+			return nil, err
+		}
+		tryStmt.CatchName = p.previous()
+		err = p.consume(tkn.RIGHT_PAREN, loxerror.PARSE_ERROR_MISSING_CATCH_RIGHT_PAREN)
+		if err != nil {
+			return nil, err
+		}
+		err = p.consume(tkn.LEFT_BRACE, loxerror.PARSE_ERROR_MISSING_CATCH_LEFT_BRACE)
+		if err != nil {
+			return nil, err
+		}
+		tryStmt.Catch, err = p.blockStatement()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.match(tkn.FINALLY) {
+		err = p.consume(tkn.LEFT_BRACE, loxerror.PARSE_ERROR_MISSING_FINALLY_LEFT_BRACE)
+		if err != nil {
+			return nil, err
+		}
+		tryStmt.Finally, err = p.blockStatement()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if tryStmt.Catch == nil && tryStmt.Finally == nil {
+		return nil, loxerror.NewErrorFromToken(p.peek(), loxerror.PARSE_ERROR_MISSING_CATCH_OR_FINALLY)
+	}
+
+	tryStmt.Span = p.spanFrom(keyword)
+	return tryStmt, nil
+
+}
+
 func (p *Parser) printStatement() (stmt.Stmt, error) {
 
+	start := p.previous()
 	e, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
-	err = p.consume(tkn.SEMICOLON, "Expect ';' after value.")
+	err = p.consume(tkn.SEMICOLON, loxerror.PARSE_ERROR_MISSING_VALUE_SEMICOLON)
 	if err != nil {
 		return nil, err
 	}
 	
 	return stmt.PrintStmt{
 		E: e,
+		Span: p.spanFrom(start),
 	}, nil
 
 }
 
 func (p *Parser) expressionStatement() (stmt.Stmt, error) {
 	
+	start := p.peek()
 	e, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
-	err = p.consume(tkn.SEMICOLON, "Expect ';' after value.")
+	err = p.consume(tkn.SEMICOLON, loxerror.PARSE_ERROR_MISSING_VALUE_SEMICOLON)
 	if err != nil {
 		return nil, err
 	}
 	return stmt.ExprStmt{
 		E: e,
+		Span: p.spanFrom(start),
 	}, nil
 
 }
@@ -326,8 +675,77 @@ func (p *Parser) expression() (expr.Expr, error) {
 	return p.assignment()
 }
 
+// conditional parses the ternary operator. Like in C, the then branch may be
+// any expression and the else branch groups to the right.
+func (p *Parser) conditional() (expr.Expr, error) {
+
+	start := p.peek()
+	condition, err := p.coalesce()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.match(tkn.QUESTION) {
+		return condition, nil
+	}
+
+	question := p.previous()
+	thenBranch, err := p.assignment()
+	if err != nil {
+		return nil, err
+	}
+	err = p.consume(tkn.COLON, loxerror.PARSE_ERROR_MISSING_CONDITIONAL_COLON)
+	if err != nil {
+		return nil, err
+	}
+	elseBranch, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+
+	return expr.ConditionalExpr{
+		Condition: condition,
+		Question: question,
+		Then: thenBranch,
+		Else: elseBranch,
+		Span: p.spanFrom(start),
+	}, nil
+
+}
+
+func (p *Parser) coalesce() (expr.Expr, error) {
+
+	start := p.peek()
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(tkn.QUESTION_QUESTION) {
+
+		operator := p.previous()
+
+		right, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+
+		e = expr.LogicalExpr{
+			Operator: operator,
+			Left: e,
+			Right: right,
+			Span: p.spanFrom(start),
+		}
+
+	}
+
+	return e, nil
+
+}
+
 func (p *Parser) or() (expr.Expr, error) {
 	
+	start := p.peek()
 	and, err := p.and()
 	if err != nil {
 		return nil, err
@@ -346,6 +764,7 @@ func (p *Parser) or() (expr.Expr, error) {
 			Operator: operator,
 			Left: and,
 			Right: right,
+			Span: p.spanFrom(start),
 		}
 
 	}
@@ -356,6 +775,7 @@ func (p *Parser) or() (expr.Expr, error) {
 
 func (p *Parser) and() (expr.Expr, error) {
 	
+	start := p.peek()
 	e, err := p.equality()
 	if err != nil {
 		return nil, err
@@ -373,7 +793,8 @@ func (p *Parser) and() (expr.Expr, error) {
 		e = expr.LogicalExpr{
 			Operator: operator,
 			Left: e,
-			Right: right, 
+			Right: right,
+			Span: p.spanFrom(start),
 		}
 
 	}
@@ -384,7 +805,8 @@ func (p *Parser) and() (expr.Expr, error) {
 
 func (p *Parser) assignment() (expr.Expr, error) {
 	
-	e, err := p.or()
+	start := p.peek()
+	e, err := p.conditional()
 	if err != nil {
 		return nil, err
 	}
@@ -397,23 +819,65 @@ func (p *Parser) assignment() (expr.Expr, error) {
 			return nil, err
 		}
 
-		varExpr, ok := e.(expr.VariableExpr)
+		varExpr, ok := e.(*expr.VariableExpr)
 		if ok {
-			assignExpr := expr.AssignExpr{
+			assignExpr := &expr.AssignExpr{
 				Name: varExpr.Name,
 				Right: rightAssignment,
+				Span: p.spanFrom(start),
 			}
 			return assignExpr, nil		
 		}
 
-		return nil, loxerror.NewErrorFromToken(equals, "Invalid assignment target.")
+		getExpr, ok := e.(expr.GetExpr)
+		if ok && !getExpr.Optional {
+			return expr.SetExpr{
+				Object: getExpr.Object,
+				Name: getExpr.Name,
+				Value: rightAssignment,
+				Span: p.spanFrom(start),
+			}, nil
+		}
+
+		return nil, loxerror.NewErrorFromToken(equals, loxerror.PARSE_ERROR_INVALID_ASSIGNMENT_TARGET)
 	} 
+
+	if p.match(tkn.PLUS_EQUAL, tkn.MINUS_EQUAL, tkn.STAR_EQUAL, tkn.SLASH_EQUAL, tkn.PERCENT_EQUAL) {
+
+		operator := p.previous()
+		value, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		if !isAssignable(e) {
+			return nil, loxerror.NewErrorFromToken(operator, loxerror.PARSE_ERROR_INVALID_ASSIGNMENT_TARGET)
+		}
+		return expr.UpdateExpr{
+			Target: e,
+			Operator: operator,
+			Value: value,
+			Span: p.spanFrom(start),
+		}, nil
+	}
 	return e, nil
 
 }
 
+// isAssignable reports whether e may be the target of a compound assignment
+// or an increment.
+func isAssignable(e expr.Expr) bool {
+	switch e := e.(type) {
+	case *expr.VariableExpr:
+		return true
+	case expr.GetExpr:
+		return !e.Optional
+	}
+	return false
+}
+
 func (p *Parser) equality() (expr.Expr, error) {
 
+	start := p.peek()
 	e, err := p.comparison()
 	if err != nil {
 		return nil, err
@@ -430,6 +894,7 @@ func (p *Parser) equality() (expr.Expr, error) {
 			Operator: operator,
 			Left:     e,
 			Right:    right,
+			Span:     p.spanFrom(start),
 		}
 	}
 
@@ -438,7 +903,8 @@ func (p *Parser) equality() (expr.Expr, error) {
 }
 
 func (p *Parser) comparison() (expr.Expr, error) {
-	e, err := p.term()
+	start := p.peek()
+	e, err := p.bitOr()
 	if err != nil {
 		return nil, err
 	}
@@ -446,7 +912,7 @@ func (p *Parser) comparison() (expr.Expr, error) {
 	for p.match(tkn.GREATER, tkn.GREATER_EQUAL, tkn.LESS, tkn.LESS_EQUAL) {
 		operator := p.previous()
 
-		right, err := p.term()
+		right, err := p.bitOr()
 		if err != nil {
 			return nil, err
 		}
@@ -455,6 +921,52 @@ func (p *Parser) comparison() (expr.Expr, error) {
 			Operator: operator,
 			Left:     e,
 			Right:    right,
+			Span:     p.spanFrom(start),
+		}
+	}
+
+	return e, nil
+}
+
+// The bitwise operators bind tighter than comparisons, so a & 1 == 0 tests
+// the low bit of a. From loosest to tightest they are |, ^, & and the shifts.
+
+func (p *Parser) bitOr() (expr.Expr, error) {
+	return p.binary(p.bitXor, tkn.PIPE)
+}
+
+func (p *Parser) bitXor() (expr.Expr, error) {
+	return p.binary(p.bitAnd, tkn.CARET)
+}
+
+func (p *Parser) bitAnd() (expr.Expr, error) {
+	return p.binary(p.shift, tkn.AMPERSAND)
+}
+
+func (p *Parser) shift() (expr.Expr, error) {
+	return p.binary(p.term, tkn.LESS_LESS, tkn.GREATER_GREATER)
+}
+
+// binary parses a left-associative chain of operand expressions joined by
+// any of the operators.
+func (p *Parser) binary(operand func() (expr.Expr, error), operators ...tkn.TokenType) (expr.Expr, error) {
+	start := p.peek()
+	e, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(operators...) {
+		operator := p.previous()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		e = expr.BinaryExpr{
+			Operator: operator,
+			Left:     e,
+			Right:    right,
+			Span:     p.spanFrom(start),
 		}
 	}
 
@@ -463,6 +975,7 @@ func (p *Parser) comparison() (expr.Expr, error) {
 
 func (p *Parser) term() (expr.Expr, error) {
 
+	start := p.peek()
 	e, err := p.factor()
 	if err != nil {
 		return nil, err
@@ -480,6 +993,7 @@ func (p *Parser) term() (expr.Expr, error) {
 			Operator: operator,
 			Left:     e,
 			Right:    right,
+			Span:     p.spanFrom(start),
 		}
 	}
 
@@ -489,12 +1003,13 @@ func (p *Parser) term() (expr.Expr, error) {
 
 func (p *Parser) factor() (expr.Expr, error) {
 
+	start := p.peek()
 	e, err := p.unary()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.match(tkn.SLASH, tkn.STAR) {
+	for p.match(tkn.SLASH, tkn.STAR, tkn.TILDE_SLASH, tkn.PERCENT) {
 		operator := p.previous()
 		
 		right, err := p.unary()
@@ -506,6 +1021,7 @@ func (p *Parser) factor() (expr.Expr, error) {
 			Operator: operator,
 			Left:     e,
 			Right:    right,
+			Span:     p.spanFrom(start),
 		}
 	}
 
@@ -515,7 +1031,7 @@ func (p *Parser) factor() (expr.Expr, error) {
 
 func (p *Parser) unary() (expr.Expr, error) {
 
-	if p.match(tkn.BANG, tkn.MINUS) {
+	if p.match(tkn.BANG, tkn.MINUS, tkn.TILDE) {
 		operator := p.previous()
 		uexp, err := p.unary()
 		if err != nil {
@@ -524,41 +1040,244 @@ func (p *Parser) unary() (expr.Expr, error) {
 		e := expr.UnaryExpr{
 			Operator: operator,
 			Right:    uexp,
+			Span:     p.spanFrom(operator),
 		}
 		return e, nil
 	}
-	return p.primary()
+	return p.exponent()
+
+}
+
+// exponent parses "**", which binds tighter than a unary operator on its
+// left and groups to the right: -2 ** 2 is -(2 ** 2) and 2 ** 3 ** 2 is
+// 2 ** (3 ** 2).
+func (p *Parser) exponent() (expr.Expr, error) {
+
+	start := p.peek()
+	e, err := p.update()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(tkn.STAR_STAR) {
+		operator := p.previous()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		e = expr.BinaryExpr{
+			Operator: operator,
+			Left:     e,
+			Right:    right,
+			Span:     p.spanFrom(start),
+		}
+	}
+
+	return e, nil
+
+}
+
+// update parses prefix and postfix increments and decrements, whose
+// operand is a call or property access rather than any unary expression.
+func (p *Parser) update() (expr.Expr, error) {
+
+	if p.match(tkn.PLUS_PLUS, tkn.MINUS_MINUS) {
+		operator := p.previous()
+		target, err := p.call()
+		if err != nil {
+			return nil, err
+		}
+		if !isAssignable(target) {
+			return nil, loxerror.NewErrorFromToken(operator, loxerror.PARSE_ERROR_INVALID_ASSIGNMENT_TARGET)
+		}
+		return expr.UpdateExpr{
+			Target: target,
+			Operator: operator,
+			Span: p.spanFrom(operator),
+		}, nil
+	}
+
+	start := p.peek()
+	e, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(tkn.PLUS_PLUS, tkn.MINUS_MINUS) {
+		operator := p.previous()
+		if !isAssignable(e) {
+			return nil, loxerror.NewErrorFromToken(operator, loxerror.PARSE_ERROR_INVALID_ASSIGNMENT_TARGET)
+		}
+		e = expr.UpdateExpr{
+			Target: e,
+			Operator: operator,
+			Postfix: true,
+			Span: p.spanFrom(start),
+		}
+	}
+
+	return e, nil
+
+}
+
+func (p *Parser) call() (expr.Expr, error) {
+
+	start := p.peek()
+	e, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if p.match(tkn.LEFT_PAREN) {
+			e, err = p.finishCall(e, start, false)
+			if err != nil {
+				return nil, err
+			}
+		} else if p.match(tkn.QUESTION_DOT) {
+			if p.match(tkn.LEFT_PAREN) {
+				e, err = p.finishCall(e, start, true)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			err = p.consume(tkn.IDENTIFIER, loxerror.PARSE_ERROR_MISSING_OPTIONAL_PROPERTY_NAME)
+			if err != nil {
+				return nil, err
+			}
+			e = expr.GetExpr{
+				Object: e,
+				Name: p.previous(),
+				Optional: true,
+				Span: p.spanFrom(start),
+			}
+		} else if p.match(tkn.DOT) {
+			err = p.consume(tkn.IDENTIFIER, loxerror.PARSE_ERROR_MISSING_PROPERTY_NAME)
+			if err != nil {
+				return nil, err
+			}
+			e = expr.GetExpr{
+				Object: e,
+				Name: p.previous(),
+				Span: p.spanFrom(start),
+			}
+		} else {
+			break
+		}
+	}
+
+	return e, nil
+
+}
+
+func (p *Parser) finishCall(callee expr.Expr, start tkn.Token, optional bool) (expr.Expr, error) {
+
+	leftParen := p.previous()
+	arguments := []expr.Expr{}
+	if !p.check(tkn.RIGHT_PAREN) {
+		for {
+			if len(arguments) >= MAX_ARGUMENTS {
+				return nil, loxerror.NewErrorFromToken(p.peek(), loxerror.PARSE_ERROR_TOO_MANY_ARGUMENTS)
+			}
+			argument, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			arguments = append(arguments, argument)
+			if !p.match(tkn.COMMA) {
+				break
+			}
+		}
+	}
+
+	err := p.consumeClosing(tkn.RIGHT_PAREN, loxerror.PARSE_ERROR_MISSING_ARGUMENTS_RIGHT_PAREN, leftParen)
+	if err != nil {
+		return nil, err
+	}
+
+	return expr.CallExpr{
+		Callee: callee,
+		Paren: p.previous(),
+		Arguments: arguments,
+		Optional: optional,
+		Span: p.spanFrom(start),
+	}, nil
 
 }
 
 func (p *Parser) primary() (expr.Expr, error) {
-	
+
+	if p.continuesString() {
+		return nil, loxerror.NewErrorFromToken(p.peek(), loxerror.PARSE_ERROR_MISSING_EXPRESSION)
+	}
+
 	if p.match(tkn.NUMBER, tkn.STRING, tkn.TRUE, tkn.FALSE, tkn.NIL) {
 		e := expr.LiteralExpr{
 			Value: p.previous().Literal,
+			Span: p.previous().Span,
 		}
 		return e, nil
 	}
 
+	if p.match(tkn.INTERPOLATION) {
+		return p.interpolation()
+	}
+
+	if p.match(tkn.SUPER) {
+		keyword := p.previous()
+		err := p.consume(tkn.DOT, loxerror.PARSE_ERROR_MISSING_SUPER_DOT)
+		if err != nil {
+			return nil, err
+		}
+		err = p.consume(tkn.IDENTIFIER, loxerror.PARSE_ERROR_MISSING_SUPER_METHOD)
+		if err != nil {
+			return nil, err
+		}
+		return &expr.SuperExpr{
+			Keyword: keyword,
+			Method: p.previous(),
+			Span: p.spanFrom(keyword),
+		}, nil
+	}
+
+	if p.match(tkn.THIS) {
+		return &expr.ThisExpr{
+			Keyword: p.previous(),
+			Span: p.previous().Span,
+		}, nil
+	}
+
 	if p.match(tkn.IDENTIFIER) {
-		return expr.VariableExpr{
+		return &expr.VariableExpr{
 			Name: p.previous(),
+			Span: p.previous().Span,
 		}, nil
 	}
 
+	if p.match(tkn.FUN) {
+		return p.anonymousFunction()
+	}
+
+	if p.isArrowFunction() {
+		return p.arrowFunction()
+	}
+
 	if p.match(tkn.LEFT_PAREN) {
+		start := p.previous()
 		e, err := p.expression()
 		if err != nil {
 			return nil, err
 		}
 
-		err = p.consume(tkn.RIGHT_PAREN, loxerror.PARSE_ERROR_MISSING_RIGHT_PAREN)
+		err = p.consumeClosing(tkn.RIGHT_PAREN, loxerror.PARSE_ERROR_MISSING_RIGHT_PAREN, start)
 		if err != nil {
 			return nil, err
 		}
 
 		return expr.GroupingExpr{
 			Expr: e,
+			Span: p.spanFrom(start),
 		}, nil
 
 	}
@@ -566,13 +1285,142 @@ func (p *Parser) primary() (expr.Expr, error) {
 	return nil, loxerror.NewErrorFromToken(p.peek(), loxerror.PARSE_ERROR_MISSING_EXPRESSION)
 }
 
-func (p *Parser) consume(tokenType tkn.TokenType, message string) error {
+// anonymousFunction parses fun (a, b) { ... } after the 'fun'.
+func (p *Parser) anonymousFunction() (expr.Expr, error) {
+
+	keyword := p.previous()
+	err := p.consume(tkn.LEFT_PAREN, loxerror.PARSE_ERROR_MISSING_ANONYMOUS_FUNCTION_LEFT_PAREN)
+	if err != nil {
+		return nil, err
+	}
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.consume(tkn.LEFT_BRACE, loxerror.PARSE_ERROR_MISSING_FUNCTION_LEFT_BRACE, "function")
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	return expr.FunctionExpr{
+		Keyword: keyword,
+		Params: parameters,
+		Body: body,
+		Span: p.spanFrom(keyword),
+	}, nil
+
+}
+
+// isArrowFunction reports whether the next tokens start an arrow function:
+// a parenthesized list of names followed by '=>'. Anything else in the
+// parentheses makes them a grouping.
+func (p *Parser) isArrowFunction() bool {
+	if !p.check(tkn.LEFT_PAREN) {
+		return false
+	}
+	position := p.position + 1
+	if p.tokens[position].Type != tkn.RIGHT_PAREN {
+		for {
+			if p.tokens[position].Type != tkn.IDENTIFIER {
+				return false
+			}
+			position++
+			if p.tokens[position].Type != tkn.COMMA {
+				break
+			}
+			position++
+		}
+		if p.tokens[position].Type != tkn.RIGHT_PAREN {
+			return false
+		}
+	}
+	return p.tokens[position+1].Type == tkn.ARROW
+}
+
+// arrowFunction parses (a, b) => a + b, whose body returns the expression.
+func (p *Parser) arrowFunction() (expr.Expr, error) {
+
+	start := p.peek()
+	p.advance()
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+	p.advance()
+	arrow := p.previous()
+
+	bodyStart := p.peek()
+	body, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	return expr.FunctionExpr{
+		Keyword: arrow,
+		Params: parameters,
+		Body: []stmt.Stmt{stmt.ReturnStmt{Keyword: arrow, Value: body, Span: p.spanFrom(bodyStart)}},
+		Span: p.spanFrom(start),
+	}, nil
+
+}
+
+// interpolation parses a string with embedded expressions after its first part.
+func (p *Parser) interpolation() (expr.Expr, error) {
+	start := p.previous()
+	interpolation := expr.InterpolationExpr{}
+	for {
+		opening := p.previous()
+		interpolation.Strings = append(interpolation.Strings, opening.Literal.ToString())
+		if opening.Type == tkn.STRING {
+			break
+		}
+		e, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		interpolation.Exprs = append(interpolation.Exprs, e)
+		if !p.continuesString() {
+			err := loxerror.NewErrorFromToken(p.peek(), loxerror.PARSE_ERROR_MISSING_INTERPOLATION_RIGHT_BRACE)
+			dollar := opening.Span
+			dollar.Start = tkn.Position{Line: dollar.End.Line, Column: dollar.End.Column - 2, Offset: dollar.End.Offset - 2}
+			return nil, err.WithNote(dollar, "To match this '${'.")
+		}
+		p.advance()
+	}
+	interpolation.Span = p.spanFrom(start)
+	return interpolation, nil
+}
+
+// continuesString reports whether the next token is the rest of a string
+// after an embedded expression, which starts with the closing "}".
+func (p *Parser) continuesString() bool {
+	next := p.peek()
+	return (next.Type == tkn.STRING || next.Type == tkn.INTERPOLATION) && strings.HasPrefix(next.Lexeme, "}")
+}
+
+// consumeClosing consumes the bracket that closes opening. The error for a
+// missing bracket notes where the opening one is.
+func (p *Parser) consumeClosing(tokenType tkn.TokenType, message string, opening tkn.Token) error {
+	if p.check(tokenType) {
+		p.advance()
+		return nil
+	}
+	err := loxerror.NewErrorFromToken(p.peek(), message)
+	return err.WithNote(opening.Span, "To match this '"+opening.Lexeme+"'.")
+}
+
+func (p *Parser) consume(tokenType tkn.TokenType, message string, args ...interface{}) error {
 	if (p.check(tokenType)) {
 		p.advance()
 		return nil
 	}
 	
-	return loxerror.NewErrorFromToken(p.peek(), message)
+	return loxerror.NewErrorFromToken(p.peek(), message, args...)
 }
 
 func (p *Parser) synchronize() {
@@ -583,15 +1431,9 @@ func (p *Parser) synchronize() {
 			return
 		}
 		switch (p.peek().Type) {
-			case tkn.CLASS:
-        	case tkn.FUN:
-        	case tkn.VAR:
-        	case tkn.FOR:
-        	case tkn.IF:
-        	case tkn.WHILE:
-        	case tkn.PRINT:
-        	case tkn.RETURN:
-          		return;
+		case tkn.CLASS, tkn.FUN, tkn.VAR, tkn.FOR, tkn.IF, tkn.WHILE, tkn.PRINT,
+			tkn.RETURN, tkn.THROW, tkn.TRY, tkn.BREAK, tkn.CONTINUE:
+			return
 		}
 		p.advance()
 	}
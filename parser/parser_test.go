@@ -1,6 +1,7 @@
 package parser_test
 
 import (
+	"fmt"
 	loxerror "golox/error"
 	"golox/expr"
 	"golox/parser"
@@ -96,6 +97,102 @@ func TestParser_LogicalExpressions(t *testing.T) {
                 Right:    expr.LiteralExpr{Value: loxvalue.NewBoolean(false)},
             },
         }},
+		{"a ?? b or c;", stmt.ExprStmt{
+			E: expr.LogicalExpr{
+				Left:     &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "a", nil, 1)},
+				Operator: tkn.NewToken(tkn.QUESTION_QUESTION, "??", nil, 1),
+				Right: expr.LogicalExpr{
+					Left:     &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "b", nil, 1)},
+					Operator: tkn.NewToken(tkn.OR, "or", nil, 1),
+					Right:    &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "c", nil, 1)},
+				},
+			},
+		}},
+	}
+
+	for _, test := range tests {
+		testExpression(t, test.input, test.expected)
+	}
+
+}
+
+func TestParser_ConditionalExpressions(t *testing.T) {
+
+	tests := []struct {
+		input   	string
+		expected	stmt.Stmt
+	}{
+		{"a ? 1 : b ? 2 : 3;", stmt.ExprStmt{
+			E: expr.ConditionalExpr{
+				Condition: &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "a", nil, 1)},
+				Question:  tkn.NewToken(tkn.QUESTION, "?", nil, 1),
+				Then:      expr.LiteralExpr{Value: &loxvalue.Number{Value: 1}},
+				Else: expr.ConditionalExpr{
+					Condition: &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "b", nil, 1)},
+					Question:  tkn.NewToken(tkn.QUESTION, "?", nil, 1),
+					Then:      expr.LiteralExpr{Value: &loxvalue.Number{Value: 2}},
+					Else:      expr.LiteralExpr{Value: &loxvalue.Number{Value: 3}},
+				},
+			},
+		}},
+		{"x = a ? b = 1 : 2;", stmt.ExprStmt{
+			E: &expr.AssignExpr{
+				Name: tkn.NewToken(tkn.IDENTIFIER, "x", nil, 1),
+				Right: expr.ConditionalExpr{
+					Condition: &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "a", nil, 1)},
+					Question:  tkn.NewToken(tkn.QUESTION, "?", nil, 1),
+					Then: &expr.AssignExpr{
+						Name:  tkn.NewToken(tkn.IDENTIFIER, "b", nil, 1),
+						Right: expr.LiteralExpr{Value: &loxvalue.Number{Value: 1}},
+					},
+					Else: expr.LiteralExpr{Value: &loxvalue.Number{Value: 2}},
+				},
+			},
+		}},
+	}
+
+	for _, test := range tests {
+		testExpression(t, test.input, test.expected)
+	}
+
+}
+
+func TestParser_UpdateExpressions(t *testing.T) {
+
+	tests := []struct {
+		input   	string
+		expected	stmt.Stmt
+	}{
+		{"a -= b += 1;", stmt.ExprStmt{
+			E: expr.UpdateExpr{
+				Target:   &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "a", nil, 1)},
+				Operator: tkn.NewToken(tkn.MINUS_EQUAL, "-=", nil, 1),
+				Value: expr.UpdateExpr{
+					Target:   &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "b", nil, 1)},
+					Operator: tkn.NewToken(tkn.PLUS_EQUAL, "+=", nil, 1),
+					Value:    expr.LiteralExpr{Value: &loxvalue.Number{Value: 1}},
+				},
+			},
+		}},
+		{"-a.b++;", stmt.ExprStmt{
+			E: expr.UnaryExpr{
+				Operator: tkn.NewToken(tkn.MINUS, "-", nil, 1),
+				Right: expr.UpdateExpr{
+					Target: expr.GetExpr{
+						Object: &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "a", nil, 1)},
+						Name:   tkn.NewToken(tkn.IDENTIFIER, "b", nil, 1),
+					},
+					Operator: tkn.NewToken(tkn.PLUS_PLUS, "++", nil, 1),
+					Postfix:  true,
+				},
+			},
+		}},
+		{"--a;", stmt.ExprStmt{
+			E: expr.UpdateExpr{
+				Target:   &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "a", nil, 1)},
+				Operator: tkn.NewToken(tkn.MINUS_MINUS, "--", nil, 1),
+			},
+		}},
 	}
 
 	for _, test := range tests {
@@ -123,6 +220,49 @@ func TestParser_GroupingExpressions(t *testing.T) {
 
 }
 
+func TestParser_InterpolationExpressions(t *testing.T) {
+
+	tests := []struct {
+		input   	string
+		expected	stmt.Stmt
+	}{
+		{"\"a ${b} c ${\"${1}\"}\";", stmt.ExprStmt{
+			E: expr.InterpolationExpr{
+				Strings: []string{"a ", " c ", ""},
+				Exprs: []expr.Expr{
+					&expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "b", nil, 1)},
+					expr.InterpolationExpr{
+						Strings: []string{"", ""},
+						Exprs: []expr.Expr{expr.LiteralExpr{Value: &loxvalue.Number{Value: 1}}},
+					},
+				},
+			},
+		}},
+	}
+
+	for _, test := range tests {
+		testExpression(t, test.input, test.expected)
+	}
+
+}
+
+func TestParser_InterpolationErrors(t *testing.T) {
+
+	tests := []struct {
+		input   	string
+		expected	*loxerror.Error
+	}{
+		{"print \"a ${b;", &loxerror.Error{Line: 1, Where: " at ';'", Message: loxerror.PARSE_ERROR_MISSING_INTERPOLATION_RIGHT_BRACE, Code: "LOX0239", Span: span(13, 1),
+			Notes: []loxerror.Note{{Message: "To match this '${'.", Span: span(10, 2)}}}},
+		{"print \"${}\";", &loxerror.Error{Line: 1, Where: " at '}\"'", Message: loxerror.PARSE_ERROR_MISSING_EXPRESSION, Code: "LOX0204", Span: span(10, 2)}},
+	}
+
+	for _, test := range tests {
+		testExpressionError(t, test.input, test.expected)
+	}
+
+}
+
 func TestParser_VariableExpressions(t *testing.T) {
 	
 	tests := []struct {
@@ -130,13 +270,13 @@ func TestParser_VariableExpressions(t *testing.T) {
 		expected	stmt.Stmt
 	}{
 		{"a;", stmt.ExprStmt{
-            E: expr.VariableExpr{
+            E: &expr.VariableExpr{
                 Name: tkn.NewToken(tkn.IDENTIFIER, "a", nil, 1),
             },
         }},
 
         {"a = 42;", stmt.ExprStmt{
-            E: expr.AssignExpr{
+            E: &expr.AssignExpr{
                 Name:  tkn.NewToken(tkn.IDENTIFIER, "a", nil, 1),
                 Right: expr.LiteralExpr{Value:  &loxvalue.Number{Value: 42}},
             },
@@ -159,13 +299,329 @@ func TestParser_VariableExpressions(t *testing.T) {
 
 }
 
+func TestParser_CallExpressions(t *testing.T) {
+
+	tests := []struct {
+		input   	string
+		expected	stmt.Stmt
+	}{
+		{"f();", stmt.ExprStmt{
+			E: expr.CallExpr{
+				Callee: &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "f", nil, 1)},
+				Paren: tkn.NewToken(tkn.RIGHT_PAREN, ")", nil, 1),
+				Arguments: []expr.Expr{},
+			},
+		}},
+
+		{"f(1)(2);", stmt.ExprStmt{
+			E: expr.CallExpr{
+				Callee: expr.CallExpr{
+					Callee: &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "f", nil, 1)},
+					Paren: tkn.NewToken(tkn.RIGHT_PAREN, ")", nil, 1),
+					Arguments: []expr.Expr{expr.LiteralExpr{Value: &loxvalue.Number{Value: 1}}},
+				},
+				Paren: tkn.NewToken(tkn.RIGHT_PAREN, ")", nil, 1),
+				Arguments: []expr.Expr{expr.LiteralExpr{Value: &loxvalue.Number{Value: 2}}},
+			},
+		}},
+
+		{"a?.b.c?.();", stmt.ExprStmt{
+			E: expr.CallExpr{
+				Callee: expr.GetExpr{
+					Object: expr.GetExpr{
+						Object:   &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "a", nil, 1)},
+						Name:     tkn.NewToken(tkn.IDENTIFIER, "b", nil, 1),
+						Optional: true,
+					},
+					Name: tkn.NewToken(tkn.IDENTIFIER, "c", nil, 1),
+				},
+				Paren: tkn.NewToken(tkn.RIGHT_PAREN, ")", nil, 1),
+				Arguments: []expr.Expr{},
+				Optional: true,
+			},
+		}},
+	}
+
+	for _, test := range tests {
+		testExpression(t, test.input, test.expected)
+	}
+
+}
+
+func TestParser_FunctionStatements(t *testing.T) {
+
+	tests := []struct {
+		input   	string
+		expected	stmt.Stmt
+	}{
+		{"fun f(a, b) { return a; }", stmt.FunStmt{
+			Name: tkn.NewToken(tkn.IDENTIFIER, "f", nil, 1),
+			Params: []tkn.Token{
+				tkn.NewToken(tkn.IDENTIFIER, "a", nil, 1),
+				tkn.NewToken(tkn.IDENTIFIER, "b", nil, 1),
+			},
+			Body: []stmt.Stmt{
+				stmt.ReturnStmt{
+					Keyword: tkn.NewToken(tkn.RETURN, "return", nil, 1),
+					Value: &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "a", nil, 1)},
+				},
+			},
+		}},
+
+		{"fun f() { return; }", stmt.FunStmt{
+			Name: tkn.NewToken(tkn.IDENTIFIER, "f", nil, 1),
+			Params: []tkn.Token{},
+			Body: []stmt.Stmt{
+				stmt.ReturnStmt{
+					Keyword: tkn.NewToken(tkn.RETURN, "return", nil, 1),
+				},
+			},
+		}},
+
+		{"/// Doc.\n/* not doc */ fun f() {}", stmt.FunStmt{
+			Name: tkn.NewToken(tkn.IDENTIFIER, "f", nil, 2),
+			Params: []tkn.Token{},
+			Body: []stmt.Stmt{},
+			Doc: "Doc.",
+		}},
+	}
+
+	for _, test := range tests {
+		testExpression(t, test.input, test.expected)
+	}
+
+}
+
+func TestParser_FunctionExpressions(t *testing.T) {
+
+	a := tkn.NewToken(tkn.IDENTIFIER, "a", nil, 1)
+	arrow := tkn.NewToken(tkn.ARROW, "=>", nil, 1)
+	tests := []struct {
+		input   	string
+		expected	stmt.Stmt
+	}{
+		{"fun (a) { return a; };", stmt.ExprStmt{E: expr.FunctionExpr{
+			Keyword: tkn.NewToken(tkn.FUN, "fun", nil, 1),
+			Params: []tkn.Token{a},
+			Body: []stmt.Stmt{
+				stmt.ReturnStmt{Keyword: tkn.NewToken(tkn.RETURN, "return", nil, 1), Value: &expr.VariableExpr{Name: a}},
+			},
+		}}},
+		{"(a, b) => a;", stmt.ExprStmt{E: expr.FunctionExpr{
+			Keyword: arrow,
+			Params: []tkn.Token{a, tkn.NewToken(tkn.IDENTIFIER, "b", nil, 1)},
+			Body: []stmt.Stmt{stmt.ReturnStmt{Keyword: arrow, Value: &expr.VariableExpr{Name: a}}},
+		}}},
+		{"() => 1;", stmt.ExprStmt{E: expr.FunctionExpr{
+			Keyword: arrow,
+			Params: []tkn.Token{},
+			Body: []stmt.Stmt{stmt.ReturnStmt{Keyword: arrow, Value: expr.LiteralExpr{Value: &loxvalue.Number{Value: 1}}}},
+		}}},
+		{"(a);", stmt.ExprStmt{E: expr.GroupingExpr{Expr: &expr.VariableExpr{Name: a}}}},
+	}
+
+	for _, test := range tests {
+		testExpression(t, test.input, test.expected)
+	}
+
+}
+
+func TestParser_FunctionExpressionErrors(t *testing.T) {
+
+	tests := []struct {
+		input   	string
+		expected	*loxerror.Error
+	}{
+		{"var f = fun a() {};", &loxerror.Error{Line: 1, Where: " at 'a'", Message: loxerror.PARSE_ERROR_MISSING_ANONYMOUS_FUNCTION_LEFT_PAREN, Code: "LOX0245", Span: span(13, 1)}},
+		{"var f = fun () return 1;", &loxerror.Error{Line: 1, Where: " at 'return'", Message: fmt.Sprintf(loxerror.PARSE_ERROR_MISSING_FUNCTION_LEFT_BRACE, "function"), Code: "LOX0211", Span: span(16, 6)}},
+		{"var f = () =>;", &loxerror.Error{Line: 1, Where: " at ';'", Message: loxerror.PARSE_ERROR_MISSING_EXPRESSION, Code: "LOX0204", Span: span(14, 1)}},
+	}
+
+	for _, test := range tests {
+		testExpressionError(t, test.input, test.expected)
+	}
+
+}
+
+func TestParser_ClassStatements(t *testing.T) {
+
+	tests := []struct {
+		input   	string
+		expected	stmt.Stmt
+	}{
+		{"class A < B { m() { this.x = super.m; } }", stmt.ClassStmt{
+			Name: tkn.NewToken(tkn.IDENTIFIER, "A", nil, 1),
+			Superclass: &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "B", nil, 1)},
+			Methods: []stmt.FunStmt{
+				{
+					Name: tkn.NewToken(tkn.IDENTIFIER, "m", nil, 1),
+					Params: []tkn.Token{},
+					Body: []stmt.Stmt{
+						stmt.ExprStmt{
+							E: expr.SetExpr{
+								Object: &expr.ThisExpr{Keyword: tkn.NewToken(tkn.THIS, "this", nil, 1)},
+								Name: tkn.NewToken(tkn.IDENTIFIER, "x", nil, 1),
+								Value: &expr.SuperExpr{
+									Keyword: tkn.NewToken(tkn.SUPER, "super", nil, 1),
+									Method: tkn.NewToken(tkn.IDENTIFIER, "m", nil, 1),
+								},
+							},
+						},
+					},
+				},
+			},
+		}},
+	}
+
+	for _, test := range tests {
+		testExpression(t, test.input, test.expected)
+	}
+
+}
+
+func TestParser_ExceptionStatements(t *testing.T) {
+
+	tests := []struct {
+		input   	string
+		expected	stmt.Stmt
+	}{
+		{"throw a;", stmt.ThrowStmt{
+			Keyword: tkn.NewToken(tkn.THROW, "throw", nil, 1),
+			Value: &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "a", nil, 1)},
+		}},
+		{"try {} catch (e) { print e; }", stmt.TryStmt{
+			Keyword: tkn.NewToken(tkn.TRY, "try", nil, 1),
+			Body: stmt.BlockStmt{Statements: []stmt.Stmt{}},
+			CatchName: tkn.NewToken(tkn.IDENTIFIER, "e", nil, 1),
+			Catch: stmt.BlockStmt{Statements: []stmt.Stmt{
+				stmt.PrintStmt{E: &expr.VariableExpr{Name: tkn.NewToken(tkn.IDENTIFIER, "e", nil, 1)}},
+			}},
+		}},
+		{"try {} finally {}", stmt.TryStmt{
+			Keyword: tkn.NewToken(tkn.TRY, "try", nil, 1),
+			Body: stmt.BlockStmt{Statements: []stmt.Stmt{}},
+			Finally: stmt.BlockStmt{Statements: []stmt.Stmt{}},
+		}},
+	}
+
+	for _, test := range tests {
+		testExpression(t, test.input, test.expected)
+	}
+
+}
+
+func TestParser_ExceptionErrors(t *testing.T) {
+
+	tests := []struct {
+		input   	string
+		expected	*loxerror.Error
+	}{
+		{"throw 1", &loxerror.Error{Line: 1, Where: " at end", Message: loxerror.PARSE_ERROR_MISSING_THROW_SEMICOLON, Code: "LOX0231", Span: span(8, 0)}},
+		{"try print 1;", &loxerror.Error{Line: 1, Where: " at 'print'", Message: loxerror.PARSE_ERROR_MISSING_TRY_LEFT_BRACE, Code: "LOX0232", Span: span(5, 5)}},
+		{"try {} catch e {}", &loxerror.Error{Line: 1, Where: " at 'e'", Message: loxerror.PARSE_ERROR_MISSING_CATCH_LEFT_PAREN, Code: "LOX0233", Span: span(14, 1)}},
+		{"try {} catch () {}", &loxerror.Error{Line: 1, Where: " at ')'", Message: loxerror.PARSE_ERROR_MISSING_CATCH_NAME, Code: "LOX0234", Span: span(15, 1)}},
+		{"try {} catch (e {}", &loxerror.Error{Line: 1, Where: " at '{'", Message: loxerror.PARSE_ERROR_MISSING_CATCH_RIGHT_PAREN, Code: "LOX0235", Span: span(17, 1)}},
+		{"try {} catch (e) print e;", &loxerror.Error{Line: 1, Where: " at 'print'", Message: loxerror.PARSE_ERROR_MISSING_CATCH_LEFT_BRACE, Code: "LOX0236", Span: span(18, 5)}},
+		{"try {} finally print 1;", &loxerror.Error{Line: 1, Where: " at 'print'", Message: loxerror.PARSE_ERROR_MISSING_FINALLY_LEFT_BRACE, Code: "LOX0237", Span: span(16, 5)}},
+		{"try {} print 1;", &loxerror.Error{Line: 1, Where: " at 'print'", Message: loxerror.PARSE_ERROR_MISSING_CATCH_OR_FINALLY, Code: "LOX0238", Span: span(8, 5)}},
+	}
+
+	for _, test := range tests {
+		testExpressionError(t, test.input, test.expected)
+	}
+
+}
+
+func TestParser_SynchronizeAtExceptionStatements(t *testing.T) {
+
+	tests := []struct {
+		input    	string
+		expected 	stmt.Stmt
+		errors   	[]error
+	}{
+		{"print ) throw 1; print );", stmt.ThrowStmt{}, []error{
+			&loxerror.Error{Line: 1, Where: " at ')'", Message: loxerror.PARSE_ERROR_MISSING_EXPRESSION, Code: "LOX0204", Span: span(7, 1)},
+			&loxerror.Error{Line: 1, Where: " at ')'", Message: loxerror.PARSE_ERROR_MISSING_EXPRESSION, Code: "LOX0204", Span: span(24, 1)},
+		}},
+		{"print ) try {} finally {} print );", stmt.TryStmt{}, []error{
+			&loxerror.Error{Line: 1, Where: " at ')'", Message: loxerror.PARSE_ERROR_MISSING_EXPRESSION, Code: "LOX0204", Span: span(7, 1)},
+			&loxerror.Error{Line: 1, Where: " at ')'", Message: loxerror.PARSE_ERROR_MISSING_EXPRESSION, Code: "LOX0204", Span: span(33, 1)},
+		}},
+	}
+
+	for _, test := range tests {
+		scanner := scanner.NewScanner(test.input)
+		tokens, errors := scanner.Scan()
+		require.Empty(t, errors)
+		statements, errors := parser.NewParser(tokens).Parse()
+		require.Equal(t, test.errors, errors, test.input)
+		require.Len(t, statements, 1, test.input)
+		require.IsType(t, test.expected, statements[0], test.input)
+	}
+
+}
+
+func TestParser_LoopStatements(t *testing.T) {
+
+	outer := tkn.NewToken(tkn.IDENTIFIER, "outer", nil, 1)
+	tests := []struct {
+		input   	string
+		expected	stmt.Stmt
+	}{
+		{"while (true) { break; continue; }", stmt.WhileStmt{
+			Condition: expr.LiteralExpr{Value: &loxvalue.Boolean{Value: true}},
+			Body: stmt.BlockStmt{Statements: []stmt.Stmt{
+				stmt.BreakStmt{Keyword: tkn.NewToken(tkn.BREAK, "break", nil, 1)},
+				stmt.ContinueStmt{Keyword: tkn.NewToken(tkn.CONTINUE, "continue", nil, 1)},
+			}},
+		}},
+		{"outer: while (true) break outer;", stmt.WhileStmt{
+			Condition: expr.LiteralExpr{Value: &loxvalue.Boolean{Value: true}},
+			Body: stmt.BreakStmt{Keyword: tkn.NewToken(tkn.BREAK, "break", nil, 1), Label: &outer},
+			Label: &outer,
+		}},
+		{"outer: for (;;) continue outer;", stmt.ForStmt{
+			Body: stmt.ContinueStmt{Keyword: tkn.NewToken(tkn.CONTINUE, "continue", nil, 1), Label: &outer},
+			Label: &outer,
+		}},
+	}
+
+	for _, test := range tests {
+		testExpression(t, test.input, test.expected)
+	}
+
+}
+
+func TestParser_LoopErrors(t *testing.T) {
+
+	tests := []struct {
+		input   	string
+		expected	*loxerror.Error
+	}{
+		{"outer: print 1;", &loxerror.Error{Line: 1, Where: " at 'print'", Message: loxerror.PARSE_ERROR_MISSING_LABELED_LOOP, Code: "LOX0242", Span: span(8, 5)}},
+		{"while (true) break", &loxerror.Error{Line: 1, Where: " at end", Message: loxerror.PARSE_ERROR_MISSING_BREAK_SEMICOLON, Code: "LOX0243", Span: span(19, 0)}},
+		{"while (true) continue outer", &loxerror.Error{Line: 1, Where: " at end", Message: loxerror.PARSE_ERROR_MISSING_CONTINUE_SEMICOLON, Code: "LOX0244", Span: span(28, 0)}},
+	}
+
+	for _, test := range tests {
+		testExpressionError(t, test.input, test.expected)
+	}
+
+}
+
 func TestParser_ExpressionError(t *testing.T) {
 
 	tests := []struct {
 		input   	string
 		expected	*loxerror.Error
 	}{
-		{");", &loxerror.Error{Line: 1, Where: " at ')'", Message: loxerror.PARSE_ERROR_MISSING_EXPRESSION}},
+		{");", &loxerror.Error{Line: 1, Where: " at ')'", Message: loxerror.PARSE_ERROR_MISSING_EXPRESSION, Code: "LOX0204", Span: span(1, 1)}},
+		{"a ? b;", &loxerror.Error{Line: 1, Where: " at ';'", Message: loxerror.PARSE_ERROR_MISSING_CONDITIONAL_COLON, Code: "LOX0240", Span: span(6, 1)}},
+		{"a?.1;", &loxerror.Error{Line: 1, Where: " at '1'", Message: loxerror.PARSE_ERROR_MISSING_OPTIONAL_PROPERTY_NAME, Code: "LOX0241", Span: span(4, 1)}},
+		{"a?.b = 1;", &loxerror.Error{Line: 1, Where: " at '='", Message: loxerror.PARSE_ERROR_INVALID_ASSIGNMENT_TARGET, Code: "LOX0225", Span: span(6, 1)}},
+		{"a + b *= 2;", &loxerror.Error{Line: 1, Where: " at '*='", Message: loxerror.PARSE_ERROR_INVALID_ASSIGNMENT_TARGET, Code: "LOX0225", Span: span(7, 2)}},
+		{"++f();", &loxerror.Error{Line: 1, Where: " at '++'", Message: loxerror.PARSE_ERROR_INVALID_ASSIGNMENT_TARGET, Code: "LOX0225", Span: span(1, 2)}},
+		{"a?.b--;", &loxerror.Error{Line: 1, Where: " at '--'", Message: loxerror.PARSE_ERROR_INVALID_ASSIGNMENT_TARGET, Code: "LOX0225", Span: span(5, 2)}},
 	}
 
 	for _, test := range tests {
@@ -180,7 +636,8 @@ func TestParser_GroupingExpressionError(t *testing.T) {
 		input   	string
 		expected	*loxerror.Error
 	}{
-		{"(3;", &loxerror.Error{Line: 1, Where: " at ';'", Message: loxerror.PARSE_ERROR_MISSING_RIGHT_PAREN}},
+		{"(3;", &loxerror.Error{Line: 1, Where: " at ';'", Message: loxerror.PARSE_ERROR_MISSING_RIGHT_PAREN, Code: "LOX0201", Span: span(3, 1),
+			Notes: []loxerror.Note{{Message: "To match this '('.", Span: span(1, 1)}}}},
 	}
 
 	for _, test := range tests {
@@ -195,8 +652,8 @@ func TestParser_VariableExpressionError(t *testing.T) {
 		input   	string
 		expected	*loxerror.Error
 	}{
-		{"var;", &loxerror.Error{Line: 1, Where: " at ';'", Message: loxerror.PARSE_ERROR_VARIABLE_EXPR_MISSING_NAME}},
-		{"var a", &loxerror.Error{Line: 1, Where: " at end", Message: loxerror.PARSE_ERROR_VARIABLE_EXPR_MISSING_SEMICOLON}},
+		{"var;", &loxerror.Error{Line: 1, Where: " at ';'", Message: loxerror.PARSE_ERROR_VARIABLE_EXPR_MISSING_NAME, Code: "LOX0202", Span: span(4, 1)}},
+		{"var a", &loxerror.Error{Line: 1, Where: " at end", Message: loxerror.PARSE_ERROR_VARIABLE_EXPR_MISSING_SEMICOLON, Code: "LOX0203", Span: span(6, 0)}},
 	}
 
 	for _, test := range tests {
@@ -205,11 +662,58 @@ func TestParser_VariableExpressionError(t *testing.T) {
 
 }
 
+func TestParser_Spans(t *testing.T) {
+
+	scanner := scanner.NewScanner("var a = b.c(1) + -2;\nif (a) {\n  print (a);\n}")
+	tokens, errors := scanner.Scan()
+	require.Empty(t, errors)
+	statements, errors := parser.NewParser(tokens).Parse()
+	require.Empty(t, errors)
+
+	varStmt := statements[0].(stmt.VarStmt)
+	require.Equal(t, span(1, 20), varStmt.Span)
+	binary := varStmt.Initializer.(expr.BinaryExpr)
+	require.Equal(t, span(9, 11), binary.Span)
+	call := binary.Left.(expr.CallExpr)
+	require.Equal(t, span(9, 6), call.Span)
+	require.Equal(t, span(9, 3), call.Callee.(expr.GetExpr).Span)
+	require.Equal(t, span(18, 2), binary.Right.(expr.UnaryExpr).Span)
+
+	ifStmt := statements[1].(stmt.IfStmt)
+	require.Equal(t, tkn.Span{
+		Start: tkn.Position{Line: 2, Column: 1, Offset: 21},
+		End:   tkn.Position{Line: 4, Column: 2, Offset: 44},
+	}, ifStmt.Span)
+	printStmt := ifStmt.ThenBrnach.(stmt.BlockStmt).Statements[0].(stmt.PrintStmt)
+	require.Equal(t, tkn.Span{
+		Start: tkn.Position{Line: 3, Column: 3, Offset: 32},
+		End:   tkn.Position{Line: 3, Column: 13, Offset: 42},
+	}, printStmt.Span)
+	require.Equal(t, tkn.Span{
+		Start: tkn.Position{Line: 3, Column: 9, Offset: 38},
+		End:   tkn.Position{Line: 3, Column: 12, Offset: 41},
+	}, printStmt.E.(expr.GroupingExpr).Span)
+
+}
+
+// span returns the span of length bytes starting at column on line 1.
+func span(column, length int) tkn.Span {
+	return tkn.Span{
+		Start: tkn.Position{Line: 1, Column: column, Offset: column - 1},
+		End:   tkn.Position{Line: 1, Column: column + length, Offset: column - 1 + length},
+	}
+}
+
+// testExpression compares the tree of input with expected, ignoring source
+// spans, which TestParser_Spans covers.
 func testExpression(t *testing.T, input string, expected stmt.Stmt) {
 
 	scanner := scanner.NewScanner(input)
 	tokens, errors := scanner.Scan()
 	require.Empty(t, errors)
+	for i := range tokens {
+		tokens[i].Span = tkn.Span{}
+	}
 	parser := parser.NewParser(tokens)
 	statements, errors := parser.Parse()
 	require.Empty(t, errors)
@@ -0,0 +1,254 @@
+package printer
+
+import (
+	"golox/expr"
+	"golox/stmt"
+	tkn "golox/token"
+	loxvalue "golox/value"
+	"strings"
+)
+
+// AstPrinter dumps a syntax tree in the parenthesized prefix form used by
+// the book, such as (+ 1 (* 2 3)), one top-level statement per line.
+// Missing optional parts are written as ().
+type AstPrinter struct{}
+
+func NewAstPrinter() *AstPrinter {
+	return &AstPrinter{}
+}
+
+func (p *AstPrinter) Print(statements []stmt.Stmt) string {
+	var out strings.Builder
+	for _, statement := range statements {
+		out.WriteString(p.statement(statement))
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+func (p *AstPrinter) statement(statement stmt.Stmt) string {
+	if statement == nil {
+		return "()"
+	}
+	text, _ := statement.Accept(p)
+	return text.(string)
+}
+
+func (p *AstPrinter) expression(expression expr.Expr) string {
+	if expression == nil {
+		return "()"
+	}
+	text, _ := expression.Evaluate(p)
+	return text.(string)
+}
+
+func (p *AstPrinter) parenthesize(name string, parts ...string) (interface{}, error) {
+	return "(" + strings.Join(append([]string{name}, parts...), " ") + ")", nil
+}
+
+func (p *AstPrinter) statements(statements []stmt.Stmt) []string {
+	parts := []string{}
+	for _, statement := range statements {
+		parts = append(parts, p.statement(statement))
+	}
+	return parts
+}
+
+func (p *AstPrinter) function(function stmt.FunStmt) []string {
+	return append([]string{function.Name.Lexeme}, p.parameters(function.Params, function.Body)...)
+}
+
+func (p *AstPrinter) parameters(params []tkn.Token, body []stmt.Stmt) []string {
+	names := []string{}
+	for _, param := range params {
+		names = append(names, param.Lexeme)
+	}
+	return append([]string{"(" + strings.Join(names, " ") + ")"}, p.statements(body)...)
+}
+
+func (p *AstPrinter) VisitExpressionStatement(exprStmt stmt.ExprStmt) (interface{}, error) {
+	return p.parenthesize(";", p.expression(exprStmt.E))
+}
+
+func (p *AstPrinter) VisitPrintStatement(printStmt stmt.PrintStmt) (interface{}, error) {
+	return p.parenthesize("print", p.expression(printStmt.E))
+}
+
+func (p *AstPrinter) VisitVariableStatement(varStmt stmt.VarStmt) (interface{}, error) {
+	if varStmt.Initializer == nil {
+		return p.parenthesize("var", varStmt.Name.Lexeme)
+	}
+	return p.parenthesize("var", varStmt.Name.Lexeme, p.expression(varStmt.Initializer))
+}
+
+func (p *AstPrinter) VisitBlockStatement(blockStmt stmt.BlockStmt) (interface{}, error) {
+	return p.parenthesize("block", p.statements(blockStmt.Statements)...)
+}
+
+func (p *AstPrinter) VisitIfStatement(ifStmt stmt.IfStmt) (interface{}, error) {
+	if ifStmt.ElseBranch == nil {
+		return p.parenthesize("if", p.expression(ifStmt.Condition), p.statement(ifStmt.ThenBrnach))
+	}
+	return p.parenthesize("if", p.expression(ifStmt.Condition), p.statement(ifStmt.ThenBrnach), p.statement(ifStmt.ElseBranch))
+}
+
+func (p *AstPrinter) VisitWhileStatement(whileStmt stmt.WhileStmt) (interface{}, error) {
+	return p.labeled(whileStmt.Label, "while", p.expression(whileStmt.Condition), p.statement(whileStmt.Body))
+}
+
+func (p *AstPrinter) VisitForStatement(forStmt stmt.ForStmt) (interface{}, error) {
+	return p.labeled(forStmt.Label, "for", p.statement(forStmt.Initializer), p.expression(forStmt.Condition),
+		p.expression(forStmt.Increment), p.statement(forStmt.Body))
+}
+
+// labeled parenthesizes a statement, preceded by its label if it has one:
+// (while outer: ...).
+func (p *AstPrinter) labeled(label *tkn.Token, name string, parts ...string) (interface{}, error) {
+	if label != nil {
+		parts = append([]string{label.Lexeme + ":"}, parts...)
+	}
+	return p.parenthesize(name, parts...)
+}
+
+func (p *AstPrinter) VisitBreakStatement(breakStmt stmt.BreakStmt) (interface{}, error) {
+	return p.labeled(breakStmt.Label, "break")
+}
+
+func (p *AstPrinter) VisitContinueStatement(continueStmt stmt.ContinueStmt) (interface{}, error) {
+	return p.labeled(continueStmt.Label, "continue")
+}
+
+func (p *AstPrinter) VisitFunctionStatement(funStmt stmt.FunStmt) (interface{}, error) {
+	return p.parenthesize("fun", p.function(funStmt)...)
+}
+
+func (p *AstPrinter) VisitReturnStatement(returnStmt stmt.ReturnStmt) (interface{}, error) {
+	if returnStmt.Value == nil {
+		return p.parenthesize("return")
+	}
+	return p.parenthesize("return", p.expression(returnStmt.Value))
+}
+
+func (p *AstPrinter) VisitClassStatement(classStmt stmt.ClassStmt) (interface{}, error) {
+	parts := []string{classStmt.Name.Lexeme}
+	if classStmt.Superclass != nil {
+		parts = append(parts, "<", classStmt.Superclass.Name.Lexeme)
+	}
+	for _, method := range classStmt.Methods {
+		method, _ := p.parenthesize("method", p.function(method)...)
+		parts = append(parts, method.(string))
+	}
+	return p.parenthesize("class", parts...)
+}
+
+func (p *AstPrinter) VisitThrowStatement(throwStmt stmt.ThrowStmt) (interface{}, error) {
+	return p.parenthesize("throw", p.expression(throwStmt.Value))
+}
+
+func (p *AstPrinter) VisitTryStatement(tryStmt stmt.TryStmt) (interface{}, error) {
+	parts := []string{p.statement(tryStmt.Body)}
+	if tryStmt.Catch != nil {
+		catch, _ := p.parenthesize("catch", tryStmt.CatchName.Lexeme, p.statement(tryStmt.Catch))
+		parts = append(parts, catch.(string))
+	}
+	if tryStmt.Finally != nil {
+		finally, _ := p.parenthesize("finally", p.statement(tryStmt.Finally))
+		parts = append(parts, finally.(string))
+	}
+	return p.parenthesize("try", parts...)
+}
+
+func (p *AstPrinter) VisitLiteral(literal expr.LiteralExpr) (interface{}, error) {
+	if literal.Value.Type() == loxvalue.STRING {
+		return quote(literal.Value.ToString()), nil
+	}
+	return literal.Value.ToString(), nil
+}
+
+func (p *AstPrinter) VisitUnary(unary expr.UnaryExpr) (interface{}, error) {
+	return p.parenthesize(unary.Operator.Lexeme, p.expression(unary.Right))
+}
+
+func (p *AstPrinter) VisitBinary(binary expr.BinaryExpr) (interface{}, error) {
+	return p.parenthesize(binary.Operator.Lexeme, p.expression(binary.Left), p.expression(binary.Right))
+}
+
+func (p *AstPrinter) VisitGrouping(grouping expr.GroupingExpr) (interface{}, error) {
+	return p.parenthesize("group", p.expression(grouping.Expr))
+}
+
+func (p *AstPrinter) VisitVariable(variable *expr.VariableExpr) (interface{}, error) {
+	return variable.Name.Lexeme, nil
+}
+
+func (p *AstPrinter) VisitAssing(assign *expr.AssignExpr) (interface{}, error) {
+	return p.parenthesize("=", assign.Name.Lexeme, p.expression(assign.Right))
+}
+
+func (p *AstPrinter) VisitLogical(logical expr.LogicalExpr) (interface{}, error) {
+	return p.parenthesize(logical.Operator.Lexeme, p.expression(logical.Left), p.expression(logical.Right))
+}
+
+func (p *AstPrinter) VisitCall(call expr.CallExpr) (interface{}, error) {
+	parts := []string{p.expression(call.Callee)}
+	for _, argument := range call.Arguments {
+		parts = append(parts, p.expression(argument))
+	}
+	if call.Optional {
+		return p.parenthesize("call?", parts...)
+	}
+	return p.parenthesize("call", parts...)
+}
+
+func (p *AstPrinter) VisitGet(get expr.GetExpr) (interface{}, error) {
+	if get.Optional {
+		return p.parenthesize("?.", p.expression(get.Object), get.Name.Lexeme)
+	}
+	return p.parenthesize(".", p.expression(get.Object), get.Name.Lexeme)
+}
+
+func (p *AstPrinter) VisitUpdate(update expr.UpdateExpr) (interface{}, error) {
+	if update.Value != nil {
+		return p.parenthesize(update.Operator.Lexeme, p.expression(update.Target), p.expression(update.Value))
+	}
+	if update.Postfix {
+		return p.parenthesize("post"+update.Operator.Lexeme, p.expression(update.Target))
+	}
+	return p.parenthesize(update.Operator.Lexeme, p.expression(update.Target))
+}
+
+// VisitFunction prints an anonymous function as (fun (a) ...), or an arrow
+// function as (=> (a) (return ...)).
+func (p *AstPrinter) VisitFunction(function expr.FunctionExpr) (interface{}, error) {
+	return p.parenthesize(function.Keyword.Lexeme, p.parameters(function.Params, function.Body.([]stmt.Stmt))...)
+}
+
+func (p *AstPrinter) VisitConditional(conditional expr.ConditionalExpr) (interface{}, error) {
+	return p.parenthesize("?:", p.expression(conditional.Condition), p.expression(conditional.Then), p.expression(conditional.Else))
+}
+
+func (p *AstPrinter) VisitSet(set expr.SetExpr) (interface{}, error) {
+	target, _ := p.parenthesize(".", p.expression(set.Object), set.Name.Lexeme)
+	return p.parenthesize("=", target.(string), p.expression(set.Value))
+}
+
+func (p *AstPrinter) VisitThis(this *expr.ThisExpr) (interface{}, error) {
+	return "this", nil
+}
+
+func (p *AstPrinter) VisitSuper(super *expr.SuperExpr) (interface{}, error) {
+	return p.parenthesize("super", super.Method.Lexeme)
+}
+
+func (p *AstPrinter) VisitInterpolation(interpolation expr.InterpolationExpr) (interface{}, error) {
+	parts := []string{}
+	for i, text := range interpolation.Strings {
+		if text != "" {
+			parts = append(parts, quote(text))
+		}
+		if i < len(interpolation.Exprs) {
+			parts = append(parts, p.expression(interpolation.Exprs[i]))
+		}
+	}
+	return p.parenthesize("interpolate", parts...)
+}
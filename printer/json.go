@@ -0,0 +1,373 @@
+package printer
+
+import (
+	"encoding/json"
+	"golox/expr"
+	"golox/stmt"
+	tkn "golox/token"
+	loxvalue "golox/value"
+)
+
+// node is the JSON form of a syntax tree node. Its "node" field names the
+// Go type, such as "BinaryExpr"; the other fields follow the node's fields in
+// lower camel case. Nodes and tokens carry their source span; tokens also
+// have their type name, lexeme and line. Absent optional children are null.
+// encoding/json writes object keys in sorted order, so the output is stable.
+type node map[string]interface{}
+
+type jsonEncoder struct{}
+
+// ToJSON encodes statements as an indented JSON array of nodes.
+func ToJSON(statements []stmt.Stmt) ([]byte, error) {
+	encoder := jsonEncoder{}
+	return json.MarshalIndent(encoder.statements(statements), "", "  ")
+}
+
+func (e jsonEncoder) statement(statement stmt.Stmt) interface{} {
+	if statement == nil {
+		return nil
+	}
+	value, _ := statement.Accept(e)
+	return value
+}
+
+func (e jsonEncoder) expression(expression expr.Expr) interface{} {
+	if expression == nil {
+		return nil
+	}
+	value, _ := expression.Evaluate(e)
+	return value
+}
+
+func (e jsonEncoder) statements(statements []stmt.Stmt) []interface{} {
+	nodes := []interface{}{}
+	for _, statement := range statements {
+		nodes = append(nodes, e.statement(statement))
+	}
+	return nodes
+}
+
+func (e jsonEncoder) token(token tkn.Token) node {
+	return node{
+		"type":   token.Type.String(),
+		"lexeme": token.Lexeme,
+		"line":   token.Line,
+		"span":   token.Span,
+	}
+}
+
+func (e jsonEncoder) function(function stmt.FunStmt) node {
+	params := []interface{}{}
+	for _, param := range function.Params {
+		params = append(params, e.token(param))
+	}
+	return node{
+		"node":   "FunStmt",
+		"span":   function.Span,
+		"name":   e.token(function.Name),
+		"params": params,
+		"body":   e.statements(function.Body),
+		"doc":    e.doc(function.Doc),
+	}
+}
+
+// doc returns the doc comment text of a declaration, or nil without one.
+func (e jsonEncoder) doc(text string) interface{} {
+	if text == "" {
+		return nil
+	}
+	return text
+}
+
+func (e jsonEncoder) VisitExpressionStatement(exprStmt stmt.ExprStmt) (interface{}, error) {
+	return node{"node": "ExprStmt", "span": exprStmt.Span, "expression": e.expression(exprStmt.E)}, nil
+}
+
+func (e jsonEncoder) VisitPrintStatement(printStmt stmt.PrintStmt) (interface{}, error) {
+	return node{"node": "PrintStmt", "span": printStmt.Span, "expression": e.expression(printStmt.E)}, nil
+}
+
+func (e jsonEncoder) VisitVariableStatement(varStmt stmt.VarStmt) (interface{}, error) {
+	return node{
+		"node":        "VarStmt",
+		"span":        varStmt.Span,
+		"name":        e.token(varStmt.Name),
+		"initializer": e.expression(varStmt.Initializer),
+		"doc":         e.doc(varStmt.Doc),
+	}, nil
+}
+
+func (e jsonEncoder) VisitBlockStatement(blockStmt stmt.BlockStmt) (interface{}, error) {
+	return node{"node": "BlockStmt", "span": blockStmt.Span, "statements": e.statements(blockStmt.Statements)}, nil
+}
+
+func (e jsonEncoder) VisitIfStatement(ifStmt stmt.IfStmt) (interface{}, error) {
+	return node{
+		"node":       "IfStmt",
+		"span":       ifStmt.Span,
+		"condition":  e.expression(ifStmt.Condition),
+		"thenBranch": e.statement(ifStmt.ThenBrnach),
+		"elseBranch": e.statement(ifStmt.ElseBranch),
+	}, nil
+}
+
+func (e jsonEncoder) VisitWhileStatement(whileStmt stmt.WhileStmt) (interface{}, error) {
+	return node{
+		"node":      "WhileStmt",
+		"span":      whileStmt.Span,
+		"condition": e.expression(whileStmt.Condition),
+		"body":      e.statement(whileStmt.Body),
+		"label":     e.label(whileStmt.Label),
+	}, nil
+}
+
+// label returns the JSON form of an optional label token.
+func (e jsonEncoder) label(label *tkn.Token) interface{} {
+	if label == nil {
+		return nil
+	}
+	return e.token(*label)
+}
+
+func (e jsonEncoder) VisitBreakStatement(breakStmt stmt.BreakStmt) (interface{}, error) {
+	return node{
+		"node":    "BreakStmt",
+		"span":    breakStmt.Span,
+		"keyword": e.token(breakStmt.Keyword),
+		"label":   e.label(breakStmt.Label),
+	}, nil
+}
+
+func (e jsonEncoder) VisitContinueStatement(continueStmt stmt.ContinueStmt) (interface{}, error) {
+	return node{
+		"node":    "ContinueStmt",
+		"span":    continueStmt.Span,
+		"keyword": e.token(continueStmt.Keyword),
+		"label":   e.label(continueStmt.Label),
+	}, nil
+}
+
+func (e jsonEncoder) VisitForStatement(forStmt stmt.ForStmt) (interface{}, error) {
+	return node{
+		"node":        "ForStmt",
+		"span":        forStmt.Span,
+		"initializer": e.statement(forStmt.Initializer),
+		"condition":   e.expression(forStmt.Condition),
+		"increment":   e.expression(forStmt.Increment),
+		"body":        e.statement(forStmt.Body),
+		"label":       e.label(forStmt.Label),
+	}, nil
+}
+
+func (e jsonEncoder) VisitFunctionStatement(funStmt stmt.FunStmt) (interface{}, error) {
+	return e.function(funStmt), nil
+}
+
+func (e jsonEncoder) VisitReturnStatement(returnStmt stmt.ReturnStmt) (interface{}, error) {
+	return node{
+		"node":    "ReturnStmt",
+		"span":    returnStmt.Span,
+		"keyword": e.token(returnStmt.Keyword),
+		"value":   e.expression(returnStmt.Value),
+	}, nil
+}
+
+func (e jsonEncoder) VisitClassStatement(classStmt stmt.ClassStmt) (interface{}, error) {
+	methods := []interface{}{}
+	for _, method := range classStmt.Methods {
+		methods = append(methods, e.function(method))
+	}
+	var superclass interface{}
+	if classStmt.Superclass != nil {
+		superclass = e.expression(classStmt.Superclass)
+	}
+	return node{
+		"node":       "ClassStmt",
+		"span":       classStmt.Span,
+		"name":       e.token(classStmt.Name),
+		"superclass": superclass,
+		"methods":    methods,
+		"doc":        e.doc(classStmt.Doc),
+	}, nil
+}
+
+func (e jsonEncoder) VisitThrowStatement(throwStmt stmt.ThrowStmt) (interface{}, error) {
+	return node{
+		"node":    "ThrowStmt",
+		"span":    throwStmt.Span,
+		"keyword": e.token(throwStmt.Keyword),
+		"value":   e.expression(throwStmt.Value),
+	}, nil
+}
+
+func (e jsonEncoder) VisitTryStatement(tryStmt stmt.TryStmt) (interface{}, error) {
+	var catchName interface{}
+	if tryStmt.Catch != nil {
+		catchName = e.token(tryStmt.CatchName)
+	}
+	return node{
+		"node":      "TryStmt",
+		"span":      tryStmt.Span,
+		"keyword":   e.token(tryStmt.Keyword),
+		"body":      e.statement(tryStmt.Body),
+		"catchName": catchName,
+		"catch":     e.statement(tryStmt.Catch),
+		"finally":   e.statement(tryStmt.Finally),
+	}, nil
+}
+
+func (e jsonEncoder) VisitLiteral(literal expr.LiteralExpr) (interface{}, error) {
+	var value interface{}
+	valueType := "nil"
+	switch literal := literal.Value.(type) {
+	case *loxvalue.Number:
+		value, valueType = literal.Value, "number"
+	case *loxvalue.String:
+		value, valueType = literal.Value, "string"
+	case *loxvalue.Boolean:
+		value, valueType = literal.Value, "boolean"
+	}
+	return node{"node": "LiteralExpr", "span": literal.Span, "value": value, "valueType": valueType}, nil
+}
+
+func (e jsonEncoder) VisitUnary(unary expr.UnaryExpr) (interface{}, error) {
+	return node{
+		"node":     "UnaryExpr",
+		"span":     unary.Span,
+		"operator": e.token(unary.Operator),
+		"right":    e.expression(unary.Right),
+	}, nil
+}
+
+func (e jsonEncoder) VisitBinary(binary expr.BinaryExpr) (interface{}, error) {
+	return node{
+		"node":     "BinaryExpr",
+		"span":     binary.Span,
+		"operator": e.token(binary.Operator),
+		"left":     e.expression(binary.Left),
+		"right":    e.expression(binary.Right),
+	}, nil
+}
+
+func (e jsonEncoder) VisitGrouping(grouping expr.GroupingExpr) (interface{}, error) {
+	return node{"node": "GroupingExpr", "span": grouping.Span, "expression": e.expression(grouping.Expr)}, nil
+}
+
+func (e jsonEncoder) VisitVariable(variable *expr.VariableExpr) (interface{}, error) {
+	return node{"node": "VariableExpr", "span": variable.Span, "name": e.token(variable.Name)}, nil
+}
+
+func (e jsonEncoder) VisitAssing(assign *expr.AssignExpr) (interface{}, error) {
+	return node{
+		"node":  "AssignExpr",
+		"span":  assign.Span,
+		"name":  e.token(assign.Name),
+		"value": e.expression(assign.Right),
+	}, nil
+}
+
+func (e jsonEncoder) VisitLogical(logical expr.LogicalExpr) (interface{}, error) {
+	return node{
+		"node":     "LogicalExpr",
+		"span":     logical.Span,
+		"operator": e.token(logical.Operator),
+		"left":     e.expression(logical.Left),
+		"right":    e.expression(logical.Right),
+	}, nil
+}
+
+func (e jsonEncoder) VisitCall(call expr.CallExpr) (interface{}, error) {
+	arguments := []interface{}{}
+	for _, argument := range call.Arguments {
+		arguments = append(arguments, e.expression(argument))
+	}
+	return node{
+		"node":      "CallExpr",
+		"span":      call.Span,
+		"callee":    e.expression(call.Callee),
+		"paren":     e.token(call.Paren),
+		"arguments": arguments,
+		"optional":  call.Optional,
+	}, nil
+}
+
+func (e jsonEncoder) VisitGet(get expr.GetExpr) (interface{}, error) {
+	return node{
+		"node":     "GetExpr",
+		"span":     get.Span,
+		"object":   e.expression(get.Object),
+		"name":     e.token(get.Name),
+		"optional": get.Optional,
+	}, nil
+}
+
+func (e jsonEncoder) VisitUpdate(update expr.UpdateExpr) (interface{}, error) {
+	return node{
+		"node":     "UpdateExpr",
+		"span":     update.Span,
+		"target":   e.expression(update.Target),
+		"operator": e.token(update.Operator),
+		"value":    e.expression(update.Value),
+		"postfix":  update.Postfix,
+	}, nil
+}
+
+func (e jsonEncoder) VisitFunction(function expr.FunctionExpr) (interface{}, error) {
+	params := []interface{}{}
+	for _, param := range function.Params {
+		params = append(params, e.token(param))
+	}
+	return node{
+		"node":    "FunctionExpr",
+		"span":    function.Span,
+		"keyword": e.token(function.Keyword),
+		"params":  params,
+		"body":    e.statements(function.Body.([]stmt.Stmt)),
+	}, nil
+}
+
+func (e jsonEncoder) VisitConditional(conditional expr.ConditionalExpr) (interface{}, error) {
+	return node{
+		"node":      "ConditionalExpr",
+		"span":      conditional.Span,
+		"condition": e.expression(conditional.Condition),
+		"then":      e.expression(conditional.Then),
+		"else":      e.expression(conditional.Else),
+	}, nil
+}
+
+func (e jsonEncoder) VisitSet(set expr.SetExpr) (interface{}, error) {
+	return node{
+		"node":   "SetExpr",
+		"span":   set.Span,
+		"object": e.expression(set.Object),
+		"name":   e.token(set.Name),
+		"value":  e.expression(set.Value),
+	}, nil
+}
+
+func (e jsonEncoder) VisitThis(this *expr.ThisExpr) (interface{}, error) {
+	return node{"node": "ThisExpr", "span": this.Span, "keyword": e.token(this.Keyword)}, nil
+}
+
+func (e jsonEncoder) VisitInterpolation(interpolation expr.InterpolationExpr) (interface{}, error) {
+	expressions := []interface{}{}
+	for _, part := range interpolation.Exprs {
+		expressions = append(expressions, e.expression(part))
+	}
+	return node{
+		"node":        "InterpolationExpr",
+		"span":        interpolation.Span,
+		"strings":     interpolation.Strings,
+		"expressions": expressions,
+	}, nil
+}
+
+func (e jsonEncoder) VisitSuper(super *expr.SuperExpr) (interface{}, error) {
+	return node{
+		"node":    "SuperExpr",
+		"span":    super.Span,
+		"keyword": e.token(super.Keyword),
+		"method":  e.token(super.Method),
+	}, nil
+}
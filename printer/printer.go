@@ -0,0 +1,643 @@
+package printer
+
+import (
+	"golox/expr"
+	"golox/parser"
+	"golox/scanner"
+	"golox/stmt"
+	tkn "golox/token"
+	loxvalue "golox/value"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const INDENT = "  "
+
+// Printer writes a syntax tree back out as canonical Lox source: two space
+// indentation, one statement per line and single spaces around binary
+// operators. The printer emits exactly the tokens the parser consumed, in
+// the same order, so when it is given the scanned tokens and comments it
+// puts every comment back in front of the token that followed it.
+type Printer struct {
+	out         strings.Builder
+	depth       int
+	atLineStart bool
+	tokens      []tkn.Token
+	comments    []tkn.Comment
+	next        int
+	nextComment int
+	lastLine    int
+	lastText    string
+	spaced      bool
+}
+
+// NewPrinter returns a printer that keeps the given comments. Both slices
+// may be nil to print a tree without comments.
+func NewPrinter(tokens []tkn.Token, comments []tkn.Comment) *Printer {
+	return &Printer{
+		atLineStart: true,
+		tokens:      tokens,
+		comments:    comments,
+	}
+}
+
+// Format parses source and returns it in canonical form, comments included.
+func Format(source string) (string, []error) {
+	scanner := scanner.NewScanner(source)
+	tokens, errors := scanner.Scan()
+	if len(errors) != 0 {
+		return "", errors
+	}
+	statements, errors := parser.NewParser(tokens).Parse()
+	if len(errors) != 0 {
+		return "", errors
+	}
+	return NewPrinter(tokens, scanner.Comments()).Print(statements), nil
+}
+
+func (p *Printer) Print(statements []stmt.Stmt) string {
+	p.statements(statements)
+	p.flush(len(p.tokens), false)
+	return p.out.String()
+}
+
+func (p *Printer) statements(statements []stmt.Stmt) {
+	for index, statement := range statements {
+		if index > 0 && p.nextLine() > p.lastLine+1 {
+			p.blankLine()
+		}
+		p.statement(statement)
+		p.flush(p.next, true)
+		p.newline()
+	}
+}
+
+func (p *Printer) statement(statement stmt.Stmt) {
+	statement.Accept(p)
+}
+
+func (p *Printer) expression(expression expr.Expr) {
+	expression.Evaluate(p)
+}
+
+// token writes a token of the source, preceded by the comments before it.
+func (p *Printer) token(text string) {
+	if p.tokens == nil {
+		p.write(text)
+		return
+	}
+	if p.flush(p.next, false) && text != "}" && p.tokens[p.next].Line > p.lastLine+1 {
+		p.blankLine()
+	}
+	p.write(text)
+	p.lastLine = p.tokens[p.next].Line
+	p.next++
+}
+
+func (p *Printer) write(text string) {
+	if p.atLineStart {
+		p.out.WriteString(strings.Repeat(INDENT, p.depth))
+		p.atLineStart = false
+	} else if p.spaced {
+		p.out.WriteString(" ")
+	}
+	p.spaced = false
+	p.out.WriteString(text)
+	p.lastText = text
+}
+
+// space separates the next token from the previous one on the same line.
+func (p *Printer) space() {
+	p.spaced = true
+}
+
+func (p *Printer) newline() {
+	if !p.atLineStart {
+		p.out.WriteString("\n")
+		p.atLineStart = true
+	}
+	p.spaced = false
+}
+
+func (p *Printer) blankLine() {
+	p.newline()
+	text := p.out.String()
+	if text != "" && !strings.HasSuffix(text, "\n\n") && p.lastText != "{" {
+		p.out.WriteString("\n")
+	}
+}
+
+// flush writes the comments that come before the token at index limit.
+// A comment on the line of the last token stays at the end of that line.
+// A block comment followed by a token on the line where it ends stays in
+// front of that token. It reports whether it wrote a comment on a line of
+// its own.
+func (p *Printer) flush(limit int, trailingOnly bool) bool {
+	ownLine := false
+	for p.nextComment < len(p.comments) && p.comments[p.nextComment].Next <= limit {
+		comment := p.comments[p.nextComment]
+		trailing := !p.atLineStart && comment.Line == p.lastLine
+		if trailingOnly && !trailing {
+			break
+		}
+		if trailing {
+			p.out.WriteString(" " + comment.Text)
+		} else {
+			if p.lastLine > 0 && comment.Line > p.lastLine+1 {
+				p.blankLine()
+			}
+			p.newline()
+			p.write(comment.Text)
+			ownLine = true
+		}
+		p.lastLine = comment.Span.End.Line
+		p.nextComment++
+		next := p.following(comment)
+		if comment.Kind == tkn.BLOCK_COMMENT && next.Line == p.lastLine {
+			if next.Offset > comment.Span.End.Offset {
+				p.space()
+			}
+		} else {
+			p.newline()
+		}
+	}
+	return ownLine
+}
+
+// following returns where the comment or token after comment starts.
+func (p *Printer) following(comment tkn.Comment) tkn.Position {
+	if p.nextComment < len(p.comments) && p.comments[p.nextComment].Next == comment.Next {
+		return p.comments[p.nextComment].Span.Start
+	}
+	if comment.Next < len(p.tokens)-1 {
+		return p.tokens[comment.Next].Span.Start
+	}
+	return tkn.Position{}
+}
+
+// nextLine returns the source line of the next comment or token.
+func (p *Printer) nextLine() int {
+	if p.nextComment < len(p.comments) && p.comments[p.nextComment].Next <= p.next {
+		return p.comments[p.nextComment].Line
+	}
+	if p.next < len(p.tokens) {
+		return p.tokens[p.next].Line
+	}
+	return 0
+}
+
+func (p *Printer) block(statements []stmt.Stmt) {
+	p.braces(len(statements) == 0, func() {
+		p.statements(statements)
+	})
+}
+
+// braces writes "{", the indented contents and "}", or "{}" when there are
+// neither contents nor comments inside.
+func (p *Printer) braces(empty bool, contents func()) {
+	p.token("{")
+	if empty && (p.nextComment == len(p.comments) || p.comments[p.nextComment].Next > p.next) {
+		p.token("}")
+		return
+	}
+	p.flush(p.next, true)
+	p.newline()
+	p.depth++
+	contents()
+	p.flush(p.next, false)
+	p.depth--
+	p.newline()
+	p.token("}")
+}
+
+// body writes the statement controlled by an if, while or for.
+func (p *Printer) body(statement stmt.Stmt) {
+	p.space()
+	p.statement(statement)
+}
+
+func (p *Printer) function(function stmt.FunStmt) {
+	p.token(function.Name.Lexeme)
+	p.parameters(function.Params)
+	p.space()
+	p.block(function.Body)
+}
+
+func (p *Printer) parameters(params []tkn.Token) {
+	p.token("(")
+	for index, param := range params {
+		if index > 0 {
+			p.token(",")
+			p.space()
+		}
+		p.token(param.Lexeme)
+	}
+	p.token(")")
+}
+
+func (p *Printer) VisitExpressionStatement(exprStmt stmt.ExprStmt) (interface{}, error) {
+	p.expression(exprStmt.E)
+	p.token(";")
+	return nil, nil
+}
+
+func (p *Printer) VisitPrintStatement(printStmt stmt.PrintStmt) (interface{}, error) {
+	p.token("print")
+	p.space()
+	p.expression(printStmt.E)
+	p.token(";")
+	return nil, nil
+}
+
+func (p *Printer) VisitVariableStatement(varStmt stmt.VarStmt) (interface{}, error) {
+	p.token("var")
+	p.space()
+	p.token(varStmt.Name.Lexeme)
+	if varStmt.Initializer != nil {
+		p.space()
+		p.token("=")
+		p.space()
+		p.expression(varStmt.Initializer)
+	}
+	p.token(";")
+	return nil, nil
+}
+
+func (p *Printer) VisitBlockStatement(blockStmt stmt.BlockStmt) (interface{}, error) {
+	p.block(blockStmt.Statements)
+	return nil, nil
+}
+
+func (p *Printer) VisitIfStatement(ifStmt stmt.IfStmt) (interface{}, error) {
+	p.token("if")
+	p.space()
+	p.token("(")
+	p.expression(ifStmt.Condition)
+	p.token(")")
+	p.body(ifStmt.ThenBrnach)
+	if ifStmt.ElseBranch != nil {
+		p.space()
+		p.token("else")
+		p.body(ifStmt.ElseBranch)
+	}
+	return nil, nil
+}
+
+// label writes the label of a loop, if it has one.
+func (p *Printer) label(label *tkn.Token) {
+	if label != nil {
+		p.token(label.Lexeme)
+		p.token(":")
+		p.space()
+	}
+}
+
+func (p *Printer) VisitWhileStatement(whileStmt stmt.WhileStmt) (interface{}, error) {
+	p.label(whileStmt.Label)
+	p.token("while")
+	p.space()
+	p.token("(")
+	p.expression(whileStmt.Condition)
+	p.token(")")
+	p.body(whileStmt.Body)
+	return nil, nil
+}
+
+func (p *Printer) VisitForStatement(forStmt stmt.ForStmt) (interface{}, error) {
+	p.label(forStmt.Label)
+	p.token("for")
+	p.space()
+	p.token("(")
+	if forStmt.Initializer != nil {
+		p.statement(forStmt.Initializer)
+	} else {
+		p.token(";")
+	}
+	if forStmt.Condition != nil {
+		p.space()
+		p.expression(forStmt.Condition)
+	}
+	p.token(";")
+	if forStmt.Increment != nil {
+		p.space()
+		p.expression(forStmt.Increment)
+	}
+	p.token(")")
+	p.body(forStmt.Body)
+	return nil, nil
+}
+
+func (p *Printer) VisitFunctionStatement(funStmt stmt.FunStmt) (interface{}, error) {
+	p.token("fun")
+	p.space()
+	p.function(funStmt)
+	return nil, nil
+}
+
+func (p *Printer) VisitReturnStatement(returnStmt stmt.ReturnStmt) (interface{}, error) {
+	p.token("return")
+	if returnStmt.Value != nil {
+		p.space()
+		p.expression(returnStmt.Value)
+	}
+	p.token(";")
+	return nil, nil
+}
+
+func (p *Printer) VisitBreakStatement(breakStmt stmt.BreakStmt) (interface{}, error) {
+	p.jump("break", breakStmt.Label)
+	return nil, nil
+}
+
+func (p *Printer) VisitContinueStatement(continueStmt stmt.ContinueStmt) (interface{}, error) {
+	p.jump("continue", continueStmt.Label)
+	return nil, nil
+}
+
+func (p *Printer) jump(keyword string, label *tkn.Token) {
+	p.token(keyword)
+	if label != nil {
+		p.space()
+		p.token(label.Lexeme)
+	}
+	p.token(";")
+}
+
+func (p *Printer) VisitThrowStatement(throwStmt stmt.ThrowStmt) (interface{}, error) {
+	p.token("throw")
+	p.space()
+	p.expression(throwStmt.Value)
+	p.token(";")
+	return nil, nil
+}
+
+func (p *Printer) VisitTryStatement(tryStmt stmt.TryStmt) (interface{}, error) {
+	p.token("try")
+	p.body(tryStmt.Body)
+	if tryStmt.Catch != nil {
+		p.space()
+		p.token("catch")
+		p.space()
+		p.token("(")
+		p.token(tryStmt.CatchName.Lexeme)
+		p.token(")")
+		p.body(tryStmt.Catch)
+	}
+	if tryStmt.Finally != nil {
+		p.space()
+		p.token("finally")
+		p.body(tryStmt.Finally)
+	}
+	return nil, nil
+}
+
+func (p *Printer) VisitClassStatement(classStmt stmt.ClassStmt) (interface{}, error) {
+	p.token("class")
+	p.space()
+	p.token(classStmt.Name.Lexeme)
+	if classStmt.Superclass != nil {
+		p.space()
+		p.token("<")
+		p.space()
+		p.token(classStmt.Superclass.Name.Lexeme)
+	}
+	p.space()
+	p.braces(len(classStmt.Methods) == 0, func() {
+		for index, method := range classStmt.Methods {
+			if index > 0 && p.nextLine() > p.lastLine+1 {
+				p.blankLine()
+			}
+			p.function(method)
+			p.flush(p.next, true)
+			p.newline()
+		}
+	})
+	return nil, nil
+}
+
+// VisitLiteral prints plain decimal numbers in canonical form. Numbers
+// written with a base prefix, an exponent or separators keep their spelling.
+func (p *Printer) VisitLiteral(literal expr.LiteralExpr) (interface{}, error) {
+	switch {
+	case literal.Value.Type() == loxvalue.STRING:
+		p.token(quote(literal.Value.ToString()))
+	case literal.Value.Type() == loxvalue.NUMBER && p.tokens != nil && strings.ContainsAny(p.tokens[p.next].Lexeme, "xXbBoOeE_"):
+		p.token(p.tokens[p.next].Lexeme)
+	default:
+		p.token(literal.Value.ToString())
+	}
+	return nil, nil
+}
+
+func (p *Printer) VisitUnary(unary expr.UnaryExpr) (interface{}, error) {
+	p.token(unary.Operator.Lexeme)
+	if unary.Operator.Type == tkn.MINUS && startsWithMinus(unary.Right) {
+		p.space()
+	}
+	p.expression(unary.Right)
+	return nil, nil
+}
+
+// startsWithMinus reports whether e is printed with a leading '-', which
+// a '-' before it must be kept apart from to not read as '--'.
+func startsWithMinus(e expr.Expr) bool {
+	switch e := e.(type) {
+	case expr.UnaryExpr:
+		return e.Operator.Type == tkn.MINUS
+	case expr.UpdateExpr:
+		return !e.Postfix && e.Operator.Type == tkn.MINUS_MINUS
+	}
+	return false
+}
+
+func (p *Printer) VisitUpdate(update expr.UpdateExpr) (interface{}, error) {
+	if update.Value != nil {
+		p.expression(update.Target)
+		p.space()
+		p.token(update.Operator.Lexeme)
+		p.space()
+		p.expression(update.Value)
+		return nil, nil
+	}
+	if !update.Postfix {
+		p.token(update.Operator.Lexeme)
+	}
+	p.expression(update.Target)
+	if update.Postfix {
+		p.token(update.Operator.Lexeme)
+	}
+	return nil, nil
+}
+
+func (p *Printer) VisitBinary(binary expr.BinaryExpr) (interface{}, error) {
+	p.expression(binary.Left)
+	p.space()
+	p.token(binary.Operator.Lexeme)
+	p.space()
+	p.expression(binary.Right)
+	return nil, nil
+}
+
+func (p *Printer) VisitGrouping(grouping expr.GroupingExpr) (interface{}, error) {
+	p.token("(")
+	p.expression(grouping.Expr)
+	p.token(")")
+	return nil, nil
+}
+
+func (p *Printer) VisitInterpolation(interpolation expr.InterpolationExpr) (interface{}, error) {
+	opening := "\""
+	for i, e := range interpolation.Exprs {
+		p.token(opening + escape(interpolation.Strings[i]) + "${")
+		p.expression(e)
+		opening = "}"
+	}
+	p.token(opening + escape(interpolation.Strings[len(interpolation.Exprs)]) + "\"")
+	return nil, nil
+}
+
+func (p *Printer) VisitVariable(variable *expr.VariableExpr) (interface{}, error) {
+	p.token(variable.Name.Lexeme)
+	return nil, nil
+}
+
+func (p *Printer) VisitAssing(assign *expr.AssignExpr) (interface{}, error) {
+	p.token(assign.Name.Lexeme)
+	p.space()
+	p.token("=")
+	p.space()
+	p.expression(assign.Right)
+	return nil, nil
+}
+
+func (p *Printer) VisitLogical(logical expr.LogicalExpr) (interface{}, error) {
+	p.expression(logical.Left)
+	p.space()
+	p.token(logical.Operator.Lexeme)
+	p.space()
+	p.expression(logical.Right)
+	return nil, nil
+}
+
+func (p *Printer) VisitConditional(conditional expr.ConditionalExpr) (interface{}, error) {
+	p.expression(conditional.Condition)
+	p.space()
+	p.token("?")
+	p.space()
+	p.expression(conditional.Then)
+	p.space()
+	p.token(":")
+	p.space()
+	p.expression(conditional.Else)
+	return nil, nil
+}
+
+// VisitFunction writes an anonymous function in the form it was written in;
+// an arrow function's body is the value of its only return statement.
+func (p *Printer) VisitFunction(function expr.FunctionExpr) (interface{}, error) {
+	body := function.Body.([]stmt.Stmt)
+	if function.Keyword.Type == tkn.ARROW {
+		p.parameters(function.Params)
+		p.space()
+		p.token("=>")
+		p.space()
+		p.expression(body[0].(stmt.ReturnStmt).Value)
+		return nil, nil
+	}
+	p.token("fun")
+	p.space()
+	p.parameters(function.Params)
+	p.space()
+	p.block(body)
+	return nil, nil
+}
+
+func (p *Printer) VisitCall(call expr.CallExpr) (interface{}, error) {
+	p.expression(call.Callee)
+	if call.Optional {
+		p.token("?.")
+	}
+	p.token("(")
+	for index, argument := range call.Arguments {
+		if index > 0 {
+			p.token(",")
+			p.space()
+		}
+		p.expression(argument)
+	}
+	p.token(")")
+	return nil, nil
+}
+
+func (p *Printer) VisitGet(get expr.GetExpr) (interface{}, error) {
+	p.expression(get.Object)
+	if get.Optional {
+		p.token("?.")
+	} else {
+		p.token(".")
+	}
+	p.token(get.Name.Lexeme)
+	return nil, nil
+}
+
+func (p *Printer) VisitSet(set expr.SetExpr) (interface{}, error) {
+	p.expression(set.Object)
+	p.token(".")
+	p.token(set.Name.Lexeme)
+	p.space()
+	p.token("=")
+	p.space()
+	p.expression(set.Value)
+	return nil, nil
+}
+
+func (p *Printer) VisitThis(this *expr.ThisExpr) (interface{}, error) {
+	p.token("this")
+	return nil, nil
+}
+
+func (p *Printer) VisitSuper(super *expr.SuperExpr) (interface{}, error) {
+	p.token("super")
+	p.token(".")
+	p.token(super.Method.Lexeme)
+	return nil, nil
+}
+
+// quote writes text as a Lox string literal.
+func quote(text string) string {
+	return "\"" + escape(text) + "\""
+}
+
+// escape escapes the quotes, backslashes, "${" and unprintable characters in
+// text so that it reads back as the same string.
+func escape(text string) string {
+	var literal strings.Builder
+	for i, char := range text {
+		switch char {
+		case '$':
+			if strings.HasPrefix(text[i+1:], "{") {
+				literal.WriteString(`\$`)
+			} else {
+				literal.WriteRune(char)
+			}
+		case '"':
+			literal.WriteString(`\"`)
+		case '\\':
+			literal.WriteString(`\\`)
+		case '\n':
+			literal.WriteString(`\n`)
+		case '\t':
+			literal.WriteString(`\t`)
+		case '\r':
+			literal.WriteString(`\r`)
+		default:
+			if unicode.IsPrint(char) {
+				literal.WriteRune(char)
+			} else {
+				literal.WriteString(`\u{` + strconv.FormatInt(int64(char), 16) + `}`)
+			}
+		}
+	}
+	return literal.String()
+}
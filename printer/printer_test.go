@@ -0,0 +1,197 @@
+package printer_test
+
+import (
+	"golox/parser"
+	"golox/printer"
+	"golox/scanner"
+	"golox/stmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrinter_Format(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"print 1+2*(3-4);", "print 1 + 2 * (3 - 4);\n"},
+		{"var a;var b=\"x\"; a=b=!true;", "var a;\nvar b = \"x\";\na = b = !true;\n"},
+		{"print 1.50; print -2;", "print 1.5;\nprint -2;\n"},
+		{"print 7~/2%3**2|~1&2^3<<1>>1;", "print 7 ~/ 2 % 3 ** 2 | ~1 & 2 ^ 3 << 1 >> 1;\n"},
+		{"print a?b:c?d:e; print x??y; print a?.b?.(1).c;", "print a ? b : c ? d : e;\nprint x ?? y;\nprint a?.b?.(1).c;\n"},
+		{"i+=1;a.b*=c=2;i++;--a.b;print - -i; print -(--i); print -i--;", "i += 1;\na.b *= c = 2;\ni++;\n--a.b;\nprint - -i;\nprint -(--i);\nprint -i--;\n"},
+		{"print 0xFF+0b1_0+1_000.50+1e-9;", "print 0xFF + 0b1_0 + 1_000.50 + 1e-9;\n"},
+		{"if(a)print 1;else if (b) {print 2;} else {}", "if (a) print 1; else if (b) {\n  print 2;\n} else {}\n"},
+		{"for(var i=0;i<3;i=i+1)print i; for(;;){}", "for (var i = 0; i < 3; i = i + 1) print i;\nfor (;;) {}\n"},
+		{"while (a and b or c) a = nil;", "while (a and b or c) a = nil;\n"},
+		{"outer:while(true){for(;;){break outer;continue;}} l : for(;;)break;", "outer: while (true) {\n  for (;;) {\n    break outer;\n    continue;\n  }\n}\nl: for (;;) break;\n"},
+		{"fun f(a,b){return a(b).c;} fun g(){return;}", "fun f(a, b) {\n  return a(b).c;\n}\nfun g() {\n  return;\n}\n"},
+		{"class A<B{init(x){this.x=x;} m(){return super.m();}}", "class A < B {\n  init(x) {\n    this.x = x;\n  }\n  m() {\n    return super.m();\n  }\n}\n"},
+		{"class A {}", "class A {}\n"},
+		{"var f=fun(a,b){return a;}; g(( x )=>x+1, ()=>nil); fun(){}();", "var f = fun (a, b) {\n  return a;\n};\ng((x) => x + 1, () => nil);\nfun () {}();\n"},
+		{"try{throw \"x\";}catch(e){print e;}finally{}", "try {\n  throw \"x\";\n} catch (e) {\n  print e;\n} finally {}\n"},
+		{`print "a\tb\"\\\u{1F600}\u{7}";`, "print \"a\\tb\\\"\\\\\U0001F600\\u{7}\";\n"},
+		{"print \"two\nlines\";", "print \"two\\nlines\";\n"},
+		{"print \"a ${ b+1 } \\${c} ${\"${d}\"}\";", "print \"a ${b + 1} \\${c} ${\"${d}\"}\";\n"},
+	}
+
+	for _, test := range tests {
+		output, errors := printer.Format(test.input)
+		require.Empty(t, errors)
+		require.Equal(t, test.expected, output, test.input)
+	}
+
+}
+
+func TestPrinter_Comments(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"// header\n\nvar a; // trailing\n// before b\nvar b;\n// end\n", "// header\n\nvar a; // trailing\n// before b\nvar b;\n// end\n"},
+		{"print 1;\n\n\n\nprint 2;", "print 1;\n\nprint 2;\n"},
+		{"{ // open\nprint 1;\n// last\n}", "{ // open\n  print 1;\n  // last\n}\n"},
+		{"fun f() {\n  // only a comment\n}", "fun f() {\n  // only a comment\n}\n"},
+		{"class A {\n  a() {}\n\n  // b\n  b() {}\n}", "class A {\n  a() {}\n\n  // b\n  b() {}\n}\n"},
+		{"print f(1, // one\n2);", "print f(1, // one\n2);\n"},
+		{"print f(1 /* one */, /* two */ 2);", "print f(1 /* one */, /* two */ 2);\n"},
+		{"/* a\n   /* b */\n*/\nprint 1;", "/* a\n   /* b */\n*/\nprint 1;\n"},
+		{"/// Adds.\nfun add(a, b) { return a + b; }", "/// Adds.\nfun add(a, b) {\n  return a + b;\n}\n"},
+	}
+
+	for _, test := range tests {
+		output, errors := printer.Format(test.input)
+		require.Empty(t, errors)
+		require.Equal(t, test.expected, output, test.input)
+	}
+
+}
+
+func TestPrinter_RoundTrip(t *testing.T) {
+
+	input := `// Counter example.
+fun makeCounter() {
+  var i = 0; // shared by every call
+  fun count() { i = i + 1; return i; }
+  return count;
+}
+
+class A < B {
+  init(n) { this.n = n * 1.5; }
+
+  m() { return super.m() + "x"; } // appends
+}
+for (var i = 0; i < 3; i = i + 1) { if (i == 1) print "one"; else print i; }
+`
+
+	formatted, errors := printer.Format(input)
+	require.Empty(t, errors)
+	require.Equal(t, canonical(t, input), canonical(t, formatted))
+
+	again, errors := printer.Format(formatted)
+	require.Empty(t, errors)
+	require.Equal(t, formatted, again)
+
+}
+
+func TestAstPrinter(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"print 1 + 2 * 3;", "(print (+ 1 (* 2 3)))\n"},
+		{"-(1) == \"a\" or !b;", "(; (or (== (- (group 1)) \"a\") (! b)))\n"},
+		{"var a; var b = a = 1;", "(var a)\n(var b (= a 1))\n"},
+		{"{ if (a) print 1; else {} }", "(block (if a (print 1) (block)))\n"},
+		{"for (;;) while (true) {}", "(for () () () (while true (block)))\n"},
+		{"for (var i = 0; i < 1; i = i + 1) {}", "(for (var i 0) (< i 1) (= i (+ i 1)) (block))\n"},
+		{"fun f(a, b) { return; return a(b).c = this; }", "(fun f (a b) (return) (return (= (. (call a b) c) this)))\n"},
+		{"class A < B { m() { super.m(); } }", "(class A < B (method m () (; (call (super m)))))\n"},
+		{"try { throw 1; } catch (e) {} finally {}", "(try (block (throw 1)) (catch e (block)) (finally (block)))\n"},
+		{"try {} finally {}", "(try (block) (finally (block)))\n"},
+		{"var f = fun (a) { return a; }; (a, b) => a + b;", "(var f (fun (a) (return a)))\n(; (=> (a b) (return (+ a b))))\n"},
+		{"outer: while (true) for (;;) { break outer; continue; }", "(while outer: true (for () () () (block (break outer:) (continue))))\n"},
+		{"a ? b : c ?? d;", "(; (?: a b (?? c d)))\n"},
+		{"a?.b.c?.(1);", "(; (call? (. (?. a b) c) 1))\n"},
+		{"a += b -= 1; -i++; ++a.b ** 2;", "(; (+= a (-= b 1)))\n(; (- (post++ i)))\n(; (** (++ (. a b)) 2))\n"},
+		{"-2 ** 3 ** 2;", "(; (- (** 2 (** 3 2))))\n"},
+		{"1 | 2 ^ 3 & 4 << 5 + 6 ~/ 7 % 8 == ~9;", "(; (== (| 1 (^ 2 (& 3 (<< 4 (+ 5 (% (~/ 6 7) 8)))))) (~ 9)))\n"},
+		{"print \"a ${b} ${\"${c}\"}\";", "(print (interpolate \"a \" b \" \" (interpolate c)))\n"},
+		{`print "say \"hi\"\n";`, "(print \"say \\\"hi\\\"\\n\")\n"},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, printer.NewAstPrinter().Print(parse(t, test.input)), test.input)
+	}
+
+}
+
+func TestToJSON(t *testing.T) {
+
+	expected := `[
+  {
+    "keyword": {
+      "lexeme": "return",
+      "line": 1,
+      "span": {
+        "start": {
+          "line": 1,
+          "column": 1,
+          "offset": 0
+        },
+        "end": {
+          "line": 1,
+          "column": 7,
+          "offset": 6
+        }
+      },
+      "type": "RETURN"
+    },
+    "node": "ReturnStmt",
+    "span": {
+      "start": {
+        "line": 1,
+        "column": 1,
+        "offset": 0
+      },
+      "end": {
+        "line": 1,
+        "column": 8,
+        "offset": 7
+      }
+    },
+    "value": null
+  }
+]`
+	data, err := printer.ToJSON(parse(t, "return;"))
+	require.NoError(t, err)
+	require.Equal(t, expected, string(data))
+
+	data, err = printer.ToJSON(parse(t, "-1.5;"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"value": 1.5,
+        "valueType": "number"`)
+
+}
+
+func parse(t *testing.T, input string) []stmt.Stmt {
+
+	tokens, errors := scanner.NewScanner(input).Scan()
+	require.Empty(t, errors)
+	statements, errors := parser.NewParser(tokens).Parse()
+	require.Empty(t, errors)
+	return statements
+
+}
+
+// canonical prints the tree of input without comments, which makes two
+// sources comparable regardless of layout and line numbers.
+func canonical(t *testing.T, input string) string {
+
+	return printer.NewPrinter(nil, nil).Print(parse(t, input))
+
+}
@@ -0,0 +1,423 @@
+package resolver
+
+import (
+	loxerror "golox/error"
+	"golox/expr"
+	"golox/stmt"
+	tkn "golox/token"
+)
+
+type functionType int
+
+const (
+	FUNCTION_NONE functionType = iota
+	FUNCTION_FUNCTION
+	FUNCTION_INITIALIZER
+	FUNCTION_METHOD
+)
+
+type classType int
+
+const (
+	CLASS_NONE classType = iota
+	CLASS_CLASS
+	CLASS_SUBCLASS
+)
+
+// Resolver is a static pass over the syntax tree that binds every local
+// variable reference to the scope that declares it and reports misuse of
+// names, return, this and super before the program runs.
+type Resolver struct {
+	scopes          []map[string]bool
+	declarations    []map[string]tkn.Token
+	locals          map[expr.Expr]int
+	errors          []error
+	currentFunction functionType
+	currentClass    classType
+	loops           []tkn.Token
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		scopes:          []map[string]bool{},
+		locals:          make(map[expr.Expr]int),
+		errors:          []error{},
+		currentFunction: FUNCTION_NONE,
+		currentClass:    CLASS_NONE,
+	}
+}
+
+// Resolve walks statements and returns, for every expression that refers to a
+// local variable, the number of scopes between the reference and the
+// declaration. References missing from the map are globals.
+func (r *Resolver) Resolve(statements []stmt.Stmt) (map[expr.Expr]int, []error) {
+	r.resolveStatements(statements)
+	return r.locals, r.errors
+}
+
+func (r *Resolver) resolveStatements(statements []stmt.Stmt) {
+	for _, statement := range statements {
+		r.resolveStatement(statement)
+	}
+}
+
+func (r *Resolver) resolveStatement(statement stmt.Stmt) {
+	statement.Accept(r)
+}
+
+func (r *Resolver) resolveExpression(e expr.Expr) {
+	e.Evaluate(r)
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes = append(r.scopes, make(map[string]bool))
+	r.declarations = append(r.declarations, make(map[string]tkn.Token))
+}
+
+func (r *Resolver) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+	r.declarations = r.declarations[:len(r.declarations)-1]
+}
+
+func (r *Resolver) declare(name tkn.Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+	scope := r.scopes[len(r.scopes)-1]
+	declarations := r.declarations[len(r.declarations)-1]
+	if _, ok := scope[name.Lexeme]; ok {
+		err := loxerror.NewErrorFromToken(name, loxerror.RESOLVER_ERROR_ALREADY_DECLARED)
+		previous := declarations[name.Lexeme]
+		r.errors = append(r.errors, err.WithNote(previous.Span, "'"+name.Lexeme+"' is first declared here."))
+	} else {
+		declarations[name.Lexeme] = name
+	}
+	scope[name.Lexeme] = false
+}
+
+func (r *Resolver) define(name tkn.Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+	r.scopes[len(r.scopes)-1][name.Lexeme] = true
+}
+
+func (r *Resolver) resolveLocal(e expr.Expr, name tkn.Token) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if _, ok := r.scopes[i][name.Lexeme]; ok {
+			r.locals[e] = len(r.scopes) - 1 - i
+			return
+		}
+	}
+}
+
+func (r *Resolver) resolveFunction(function stmt.FunStmt, kind functionType) {
+	enclosingFunction := r.currentFunction
+	enclosingLoops := r.loops
+	r.currentFunction = kind
+	r.loops = nil
+	defer func() {
+		r.currentFunction = enclosingFunction
+		r.loops = enclosingLoops
+	}()
+
+	r.beginScope()
+	for _, param := range function.Params {
+		r.declare(param)
+		r.define(param)
+	}
+	r.resolveStatements(function.Body)
+	r.endScope()
+}
+
+func (r *Resolver) error(token tkn.Token, message string, args ...interface{}) {
+	r.errors = append(r.errors, loxerror.NewErrorFromToken(token, message, args...))
+}
+
+func (r *Resolver) VisitExpressionStatement(exprStmt stmt.ExprStmt) (interface{}, error) {
+	r.resolveExpression(exprStmt.E)
+	return nil, nil
+}
+
+func (r *Resolver) VisitPrintStatement(printStmt stmt.PrintStmt) (interface{}, error) {
+	r.resolveExpression(printStmt.E)
+	return nil, nil
+}
+
+func (r *Resolver) VisitVariableStatement(variableStmt stmt.VarStmt) (interface{}, error) {
+	r.declare(variableStmt.Name)
+	if variableStmt.Initializer != nil {
+		r.resolveExpression(variableStmt.Initializer)
+	}
+	r.define(variableStmt.Name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitBlockStatement(blockStmt stmt.BlockStmt) (interface{}, error) {
+	r.beginScope()
+	r.resolveStatements(blockStmt.Statements)
+	r.endScope()
+	return nil, nil
+}
+
+func (r *Resolver) VisitIfStatement(ifStmt stmt.IfStmt) (interface{}, error) {
+	r.resolveExpression(ifStmt.Condition)
+	r.resolveStatement(ifStmt.ThenBrnach)
+	if ifStmt.ElseBranch != nil {
+		r.resolveStatement(ifStmt.ElseBranch)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitWhileStatement(whileStmt stmt.WhileStmt) (interface{}, error) {
+	r.resolveExpression(whileStmt.Condition)
+	r.resolveLoopBody(whileStmt.Label, whileStmt.Body)
+	return nil, nil
+}
+
+// resolveLoopBody resolves the body of a loop with label, or of an unlabeled
+// one when label is nil, where break and continue may refer to the loop.
+func (r *Resolver) resolveLoopBody(label *tkn.Token, body stmt.Stmt) {
+	var name tkn.Token
+	if label != nil {
+		name = *label
+		for _, enclosing := range r.loops {
+			if enclosing.Lexeme == name.Lexeme {
+				err := loxerror.NewErrorFromToken(name, loxerror.RESOLVER_ERROR_DUPLICATE_LABEL, name.Lexeme)
+				r.errors = append(r.errors, err.WithNote(enclosing.Span, "The enclosing loop is labeled here."))
+			}
+		}
+	}
+	r.loops = append(r.loops, name)
+	r.resolveStatement(body)
+	r.loops = r.loops[:len(r.loops)-1]
+}
+
+// resolveJump checks that a break or continue is inside a loop, and inside
+// the loop it names if it has a label.
+func (r *Resolver) resolveJump(keyword tkn.Token, label *tkn.Token, outsideLoop string) {
+	if len(r.loops) == 0 {
+		r.error(keyword, outsideLoop)
+		return
+	}
+	if label == nil {
+		return
+	}
+	for _, enclosing := range r.loops {
+		if enclosing.Lexeme == label.Lexeme {
+			return
+		}
+	}
+	r.error(*label, loxerror.RESOLVER_ERROR_UNDEFINED_LABEL, label.Lexeme)
+}
+
+func (r *Resolver) VisitBreakStatement(breakStmt stmt.BreakStmt) (interface{}, error) {
+	r.resolveJump(breakStmt.Keyword, breakStmt.Label, loxerror.RESOLVER_ERROR_BREAK_OUTSIDE_LOOP)
+	return nil, nil
+}
+
+func (r *Resolver) VisitContinueStatement(continueStmt stmt.ContinueStmt) (interface{}, error) {
+	r.resolveJump(continueStmt.Keyword, continueStmt.Label, loxerror.RESOLVER_ERROR_CONTINUE_OUTSIDE_LOOP)
+	return nil, nil
+}
+
+func (r *Resolver) VisitForStatement(forStmt stmt.ForStmt) (interface{}, error) {
+	r.beginScope()
+	if forStmt.Initializer != nil {
+		r.resolveStatement(forStmt.Initializer)
+	}
+	if forStmt.Condition != nil {
+		r.resolveExpression(forStmt.Condition)
+	}
+	if forStmt.Increment != nil {
+		r.resolveExpression(forStmt.Increment)
+	}
+	r.resolveLoopBody(forStmt.Label, forStmt.Body)
+	r.endScope()
+	return nil, nil
+}
+
+func (r *Resolver) VisitFunctionStatement(funStmt stmt.FunStmt) (interface{}, error) {
+	r.declare(funStmt.Name)
+	r.define(funStmt.Name)
+	r.resolveFunction(funStmt, FUNCTION_FUNCTION)
+	return nil, nil
+}
+
+func (r *Resolver) VisitReturnStatement(returnStmt stmt.ReturnStmt) (interface{}, error) {
+	if r.currentFunction == FUNCTION_NONE {
+		r.error(returnStmt.Keyword, loxerror.RESOLVER_ERROR_TOP_LEVEL_RETURN)
+	}
+	if returnStmt.Value != nil {
+		if r.currentFunction == FUNCTION_INITIALIZER {
+			r.error(returnStmt.Keyword, loxerror.RESOLVER_ERROR_INITIALIZER_RETURN)
+		}
+		r.resolveExpression(returnStmt.Value)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitClassStatement(classStmt stmt.ClassStmt) (interface{}, error) {
+	enclosingClass := r.currentClass
+	r.currentClass = CLASS_CLASS
+	defer func() {
+		r.currentClass = enclosingClass
+	}()
+
+	r.declare(classStmt.Name)
+	r.define(classStmt.Name)
+
+	if classStmt.Superclass != nil {
+		if classStmt.Superclass.Name.Lexeme == classStmt.Name.Lexeme {
+			r.error(classStmt.Superclass.Name, loxerror.RESOLVER_ERROR_INHERIT_ITSELF)
+		}
+		r.currentClass = CLASS_SUBCLASS
+		r.resolveExpression(classStmt.Superclass)
+		r.beginScope()
+		r.scopes[len(r.scopes)-1]["super"] = true
+	}
+
+	r.beginScope()
+	r.scopes[len(r.scopes)-1]["this"] = true
+	for _, method := range classStmt.Methods {
+		kind := FUNCTION_METHOD
+		if method.Name.Lexeme == "init" {
+			kind = FUNCTION_INITIALIZER
+		}
+		r.resolveFunction(method, kind)
+	}
+	r.endScope()
+
+	if classStmt.Superclass != nil {
+		r.endScope()
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitThrowStatement(throwStmt stmt.ThrowStmt) (interface{}, error) {
+	r.resolveExpression(throwStmt.Value)
+	return nil, nil
+}
+
+func (r *Resolver) VisitTryStatement(tryStmt stmt.TryStmt) (interface{}, error) {
+	r.resolveStatement(tryStmt.Body)
+	if tryStmt.Catch != nil {
+		r.beginScope()
+		r.declare(tryStmt.CatchName)
+		r.define(tryStmt.CatchName)
+		r.resolveStatement(tryStmt.Catch)
+		r.endScope()
+	}
+	if tryStmt.Finally != nil {
+		r.resolveStatement(tryStmt.Finally)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitLiteral(literalExpr expr.LiteralExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitUnary(unaryExpr expr.UnaryExpr) (interface{}, error) {
+	r.resolveExpression(unaryExpr.Right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitBinary(binaryExpr expr.BinaryExpr) (interface{}, error) {
+	r.resolveExpression(binaryExpr.Left)
+	r.resolveExpression(binaryExpr.Right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitGrouping(groupingExpr expr.GroupingExpr) (interface{}, error) {
+	r.resolveExpression(groupingExpr.Expr)
+	return nil, nil
+}
+
+func (r *Resolver) VisitVariable(variableExpr *expr.VariableExpr) (interface{}, error) {
+	if len(r.scopes) > 0 {
+		defined, ok := r.scopes[len(r.scopes)-1][variableExpr.Name.Lexeme]
+		if ok && !defined {
+			r.error(variableExpr.Name, loxerror.RESOLVER_ERROR_OWN_INITIALIZER)
+		}
+	}
+	r.resolveLocal(variableExpr, variableExpr.Name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitAssing(assignExpr *expr.AssignExpr) (interface{}, error) {
+	r.resolveExpression(assignExpr.Right)
+	r.resolveLocal(assignExpr, assignExpr.Name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitLogical(logicalExpr expr.LogicalExpr) (interface{}, error) {
+	r.resolveExpression(logicalExpr.Left)
+	r.resolveExpression(logicalExpr.Right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitCall(callExpr expr.CallExpr) (interface{}, error) {
+	r.resolveExpression(callExpr.Callee)
+	for _, argument := range callExpr.Arguments {
+		r.resolveExpression(argument)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitGet(getExpr expr.GetExpr) (interface{}, error) {
+	r.resolveExpression(getExpr.Object)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSet(setExpr expr.SetExpr) (interface{}, error) {
+	r.resolveExpression(setExpr.Value)
+	r.resolveExpression(setExpr.Object)
+	return nil, nil
+}
+
+func (r *Resolver) VisitThis(thisExpr *expr.ThisExpr) (interface{}, error) {
+	if r.currentClass == CLASS_NONE {
+		r.error(thisExpr.Keyword, loxerror.RESOLVER_ERROR_THIS_OUTSIDE_CLASS)
+		return nil, nil
+	}
+	r.resolveLocal(thisExpr, thisExpr.Keyword)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSuper(superExpr *expr.SuperExpr) (interface{}, error) {
+	if r.currentClass == CLASS_NONE {
+		r.error(superExpr.Keyword, loxerror.RESOLVER_ERROR_SUPER_OUTSIDE_CLASS)
+	} else if r.currentClass != CLASS_SUBCLASS {
+		r.error(superExpr.Keyword, loxerror.RESOLVER_ERROR_SUPER_WITHOUT_SUPERCLASS)
+	}
+	r.resolveLocal(superExpr, superExpr.Keyword)
+	return nil, nil
+}
+
+func (r *Resolver) VisitConditional(conditionalExpr expr.ConditionalExpr) (interface{}, error) {
+	r.resolveExpression(conditionalExpr.Condition)
+	r.resolveExpression(conditionalExpr.Then)
+	r.resolveExpression(conditionalExpr.Else)
+	return nil, nil
+}
+
+func (r *Resolver) VisitFunction(functionExpr expr.FunctionExpr) (interface{}, error) {
+	r.resolveFunction(stmt.Declaration(functionExpr), FUNCTION_FUNCTION)
+	return nil, nil
+}
+
+func (r *Resolver) VisitUpdate(updateExpr expr.UpdateExpr) (interface{}, error) {
+	r.resolveExpression(updateExpr.Target)
+	if updateExpr.Value != nil {
+		r.resolveExpression(updateExpr.Value)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitInterpolation(interpolationExpr expr.InterpolationExpr) (interface{}, error) {
+	for _, e := range interpolationExpr.Exprs {
+		r.resolveExpression(e)
+	}
+	return nil, nil
+}
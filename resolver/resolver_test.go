@@ -0,0 +1,108 @@
+package resolver_test
+
+import (
+	"fmt"
+	loxerror "golox/error"
+	"golox/expr"
+	"golox/parser"
+	"golox/resolver"
+	"golox/scanner"
+	"golox/stmt"
+	tkn "golox/token"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_LocalDepths(t *testing.T) {
+
+	statements := parse(t, "var a; { var b; { a; b; } }")
+	locals, errors := resolver.NewResolver().Resolve(statements)
+	require.Empty(t, errors)
+
+	inner := statements[1].(stmt.BlockStmt).Statements[1].(stmt.BlockStmt).Statements
+	global := inner[0].(stmt.ExprStmt).E
+	local := inner[1].(stmt.ExprStmt).E
+
+	_, ok := locals[global]
+	require.False(t, ok)
+	require.Equal(t, 1, locals[local])
+
+}
+
+func TestResolver_SameNameOnOneLine(t *testing.T) {
+
+	statements := parse(t, "{ var a; { var a; a; } a; }")
+	locals, errors := resolver.NewResolver().Resolve(statements)
+	require.Empty(t, errors)
+
+	outer := statements[0].(stmt.BlockStmt).Statements
+	inner := outer[1].(stmt.BlockStmt).Statements[1].(stmt.ExprStmt).E
+	require.Equal(t, 0, locals[inner])
+	require.Equal(t, 0, locals[outer[2].(stmt.ExprStmt).E.(*expr.VariableExpr)])
+
+}
+
+func TestResolver_Errors(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected *loxerror.Error
+	}{
+		{"{ var a = a; }", &loxerror.Error{Line: 1, Where: " at 'a'", Message: loxerror.RESOLVER_ERROR_OWN_INITIALIZER, Code: "LOX0301", Span: span(11, 1)}},
+		{"{ var a; var a; }", &loxerror.Error{Line: 1, Where: " at 'a'", Message: loxerror.RESOLVER_ERROR_ALREADY_DECLARED, Code: "LOX0302", Span: span(14, 1),
+			Notes: []loxerror.Note{{Message: "'a' is first declared here.", Span: span(7, 1)}}}},
+		{"fun f(a, a) {}", &loxerror.Error{Line: 1, Where: " at 'a'", Message: loxerror.RESOLVER_ERROR_ALREADY_DECLARED, Code: "LOX0302", Span: span(10, 1),
+			Notes: []loxerror.Note{{Message: "'a' is first declared here.", Span: span(7, 1)}}}},
+		{"return 1;", &loxerror.Error{Line: 1, Where: " at 'return'", Message: loxerror.RESOLVER_ERROR_TOP_LEVEL_RETURN, Code: "LOX0303", Span: span(1, 6)}},
+		{"class A { init() { return 1; } }", &loxerror.Error{Line: 1, Where: " at 'return'", Message: loxerror.RESOLVER_ERROR_INITIALIZER_RETURN, Code: "LOX0304", Span: span(20, 6)}},
+		{"print this;", &loxerror.Error{Line: 1, Where: " at 'this'", Message: loxerror.RESOLVER_ERROR_THIS_OUTSIDE_CLASS, Code: "LOX0305", Span: span(7, 4)}},
+		{"fun f() { this; }", &loxerror.Error{Line: 1, Where: " at 'this'", Message: loxerror.RESOLVER_ERROR_THIS_OUTSIDE_CLASS, Code: "LOX0305", Span: span(11, 4)}},
+		{"super.foo();", &loxerror.Error{Line: 1, Where: " at 'super'", Message: loxerror.RESOLVER_ERROR_SUPER_OUTSIDE_CLASS, Code: "LOX0306", Span: span(1, 5)}},
+		{"class A { m() { super.m(); } }", &loxerror.Error{Line: 1, Where: " at 'super'", Message: loxerror.RESOLVER_ERROR_SUPER_WITHOUT_SUPERCLASS, Code: "LOX0307", Span: span(17, 5)}},
+		{"class A < A {}", &loxerror.Error{Line: 1, Where: " at 'A'", Message: loxerror.RESOLVER_ERROR_INHERIT_ITSELF, Code: "LOX0308", Span: span(11, 1)}},
+		{"break;", &loxerror.Error{Line: 1, Where: " at 'break'", Message: loxerror.RESOLVER_ERROR_BREAK_OUTSIDE_LOOP, Code: "LOX0309", Span: span(1, 5)}},
+		{"while (true) { fun f() { continue; } }", &loxerror.Error{Line: 1, Where: " at 'continue'", Message: loxerror.RESOLVER_ERROR_CONTINUE_OUTSIDE_LOOP, Code: "LOX0310", Span: span(26, 8)}},
+		{"a: while (true) break b;", &loxerror.Error{Line: 1, Where: " at 'b'", Message: fmt.Sprintf(loxerror.RESOLVER_ERROR_UNDEFINED_LABEL, "b"), Code: "LOX0311", Span: span(23, 1)}},
+		{"a: while (true) a: for (;;) {}", &loxerror.Error{Line: 1, Where: " at 'a'", Message: fmt.Sprintf(loxerror.RESOLVER_ERROR_DUPLICATE_LABEL, "a"), Code: "LOX0312", Span: span(17, 1),
+			Notes: []loxerror.Note{{Message: "The enclosing loop is labeled here.", Span: span(1, 1)}}}},
+		{"while (true) { var f = fun () { break; }; }", &loxerror.Error{Line: 1, Where: " at 'break'", Message: loxerror.RESOLVER_ERROR_BREAK_OUTSIDE_LOOP, Code: "LOX0309", Span: span(33, 5)}},
+		{"var f = (a, a) => a;", &loxerror.Error{Line: 1, Where: " at 'a'", Message: loxerror.RESOLVER_ERROR_ALREADY_DECLARED, Code: "LOX0302", Span: span(13, 1),
+			Notes: []loxerror.Note{{Message: "'a' is first declared here.", Span: span(10, 1)}}}},
+		{"try {} catch (e) { var a = a; }", &loxerror.Error{Line: 1, Where: " at 'a'", Message: loxerror.RESOLVER_ERROR_OWN_INITIALIZER, Code: "LOX0301", Span: span(28, 1)}},
+	}
+
+	for _, test := range tests {
+		_, errors := resolver.NewResolver().Resolve(parse(t, test.input))
+		require.NotEmpty(t, errors, test.input)
+		require.Equal(t, test.expected, errors[0], test.input)
+	}
+
+}
+
+func TestResolver_GlobalRedeclarationAllowed(t *testing.T) {
+
+	_, errors := resolver.NewResolver().Resolve(parse(t, "var a; var a; class A { init() { return; } }"))
+	require.Empty(t, errors)
+
+}
+
+func parse(t *testing.T, input string) []stmt.Stmt {
+
+	scanner := scanner.NewScanner(input)
+	tokens, errors := scanner.Scan()
+	require.Empty(t, errors)
+	parser := parser.NewParser(tokens)
+	statements, errors := parser.Parse()
+	require.Empty(t, errors)
+	return statements
+
+}
+
+// span returns the span of length bytes starting at column on line 1.
+func span(column, length int) tkn.Span {
+	return tkn.Span{
+		Start: tkn.Position{Line: 1, Column: column, Offset: column - 1},
+		End:   tkn.Position{Line: 1, Column: column + length, Offset: column - 1 + length},
+	}
+}
@@ -2,6 +2,10 @@ package scanner
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 	loxerror "golox/error"
 	tkn "golox/token"
 	loxvalue "golox/value"
@@ -13,22 +17,73 @@ type Scanner struct {
 	current int
 	line    int
 	tokens  []tkn.Token
+	comments []tkn.Comment
+	doc     []string
+	interpolations []int
+	lineStart int
+	startPosition tkn.Position
 }
 
 func NewScanner(source string) *Scanner {
-	return &Scanner{source, 0, 0, 1, []tkn.Token{}}
+	return &Scanner{
+		source:   source,
+		line:     1,
+		tokens:   []tkn.Token{},
+		comments: []tkn.Comment{},
+	}
+}
+
+// position returns the position of the next character to be scanned.
+// Columns count characters, offsets count bytes.
+func (s *Scanner) position() tkn.Position {
+	column := utf8.RuneCountInString(s.source[s.lineStart:s.current]) + 1
+	return tkn.Position{Line: s.line, Column: column, Offset: s.current}
+}
+
+// span covers the lexeme scanned so far.
+func (s *Scanner) span() tkn.Span {
+	return tkn.Span{Start: s.startPosition, End: s.position()}
+}
+
+func (s *Scanner) newline() {
+	s.line++
+	s.lineStart = s.current
+}
+
+func (s *Scanner) error(message string, args ...interface{}) *loxerror.Error {
+	err := loxerror.NewError(s.line, "", message, args...)
+	err.Span = s.span()
+	return err
+}
+
+// Comments returns the comments skipped by Scan in source order.
+func (s *Scanner) Comments() []tkn.Comment {
+	return s.comments
 }
 
 
 func (s *Scanner) addToken(tokenType tkn.TokenType, literal loxvalue.LoxValue) {
 	lexeme := s.source[s.start:s.current]
 	token := tkn.NewToken(tokenType, lexeme, literal, s.line)
+	token.Span = s.span()
+	token.Doc = strings.Join(s.doc, "\n")
+	s.doc = nil
 	s.tokens = append(s.tokens, token)
 }
 
-func (s *Scanner) advance() byte {
-	char := s.source[s.current]
-	s.current++
+func (s *Scanner) addComment(kind tkn.CommentKind, text string) {
+	s.comments = append(s.comments, tkn.Comment{
+		Text: text,
+		Line: s.startPosition.Line,
+		Next: len(s.tokens),
+		Kind: kind,
+		Span: s.span(),
+	})
+}
+
+func (s *Scanner) advance() rune {
+	char, size := utf8.DecodeRuneInString(s.source[s.current:])
+	s.current += size
 	return char
 }
 
@@ -41,48 +96,135 @@ func (s *Scanner) Scan() ([]tkn.Token, []error) {
 	for !s.isAtEnd() {
 		err := s.scanToken()
 		if err != nil {
-			fmt.Println(err.Error())
 			errors = append(errors, err)
 		}
 	}
-	s.tokens = append(s.tokens, tkn.NewToken(tkn.EOF, "", nil, s.line))
+	s.start = s.current
+	s.startPosition = s.position()
+	eof := tkn.NewToken(tkn.EOF, "", nil, s.line)
+	eof.Span = s.span()
+	s.tokens = append(s.tokens, eof)
 	return s.tokens, errors
 }
 
-func (s *Scanner) match(expected byte) bool {
+func (s *Scanner) match(expected rune) bool {
 	if s.isAtEnd() {
 		return false
 	}
-	if s.source[s.current] != expected {
+	if s.peek() != expected {
 		return false
 	}
 
-	s.current++
+	s.advance()
 	return true
 }
 
-func (s *Scanner) scanString() *loxerror.Error {
+// scanString scans the text of a string literal up to its closing quote.
+// Text followed by "${" becomes an INTERPOLATION token instead, and the
+// string resumes after the "}" that closes the embedded expression.
+func (s *Scanner) scanString() error {
 
+	var text strings.Builder
+	var invalid *loxerror.Error
 	for s.peek() != '"' && !s.isAtEnd() {
-		if s.peek() == '\n' {
-			s.line++
+		if s.peek() == '$' && s.peekNext() == '{' {
+			s.advance()
+			s.advance()
+			s.addToken(tkn.INTERPOLATION, loxvalue.NewString(text.String()))
+			s.interpolations = append(s.interpolations, 0)
+			return errorOrNil(invalid)
+		}
+		from := s.position()
+		c := s.advance()
+		switch c {
+		case '\\':
+			char, err := s.scanEscape(from)
+			if err != nil && invalid == nil {
+				invalid = err
+			}
+			text.WriteString(char)
+		case '\n':
+			s.newline()
+			text.WriteRune(c)
+		default:
+			text.WriteString(s.source[from.Offset:s.current])
 		}
-		s.advance()
 	}
 
 	if s.isAtEnd() {
-		return loxerror.NewError(s.line, "", loxerror.SCANNER_ERROR_UNTERMINATED_STRING)
+		return s.error(loxerror.SCANNER_ERROR_UNTERMINATED_STRING)
 	}
 
 	s.advance()
-	text := s.source[s.start+1 : s.current-1]
-	s.addToken(tkn.STRING, loxvalue.NewString(text))
-	return nil
+	s.addToken(tkn.STRING, loxvalue.NewString(text.String()))
+	return errorOrNil(invalid)
+}
+
+// errorOrNil keeps a nil *loxerror.Error from becoming a non-nil error.
+func errorOrNil(err *loxerror.Error) error {
+	if err == nil {
+		return nil
+	}
+	return err
+}
+
+// scanEscape decodes the escape sequence whose backslash starts at from.
+func (s *Scanner) scanEscape(from tkn.Position) (string, *loxerror.Error) {
+	if s.isAtEnd() || s.peek() == '\n' {
+		return "", s.escapeError(from, loxerror.SCANNER_ERROR_INVALID_ESCAPE)
+	}
+	switch s.advance() {
+	case 'n':
+		return "\n", nil
+	case 't':
+		return "\t", nil
+	case 'r':
+		return "\r", nil
+	case '\\':
+		return "\\", nil
+	case '"':
+		return "\"", nil
+	case '$':
+		return "$", nil
+	case 'u':
+		return s.scanUnicodeEscape(from)
+	}
+	return "", s.escapeError(from, loxerror.SCANNER_ERROR_INVALID_ESCAPE)
+}
+
+// scanUnicodeEscape decodes the code point of a \u{...} escape, written as
+// one to six hexadecimal digits.
+func (s *Scanner) scanUnicodeEscape(from tkn.Position) (string, *loxerror.Error) {
+	if !s.match('{') {
+		return "", s.escapeError(from, loxerror.SCANNER_ERROR_INVALID_UNICODE_ESCAPE)
+	}
+	digits := s.current
+	for isHexDigit(s.peek()) {
+		s.advance()
+	}
+	hex := s.source[digits:s.current]
+	if !s.match('}') || len(hex) == 0 || len(hex) > 6 {
+		return "", s.escapeError(from, loxerror.SCANNER_ERROR_INVALID_UNICODE_ESCAPE)
+	}
+	code, _ := strconv.ParseUint(hex, 16, 32)
+	char := rune(code)
+	if !utf8.ValidRune(char) {
+		return "", s.escapeError(from, loxerror.SCANNER_ERROR_INVALID_UNICODE_ESCAPE)
+	}
+	return string(char), nil
+}
+
+func (s *Scanner) escapeError(from tkn.Position, message string) *loxerror.Error {
+	sequence := s.source[from.Offset:s.current]
+	err := loxerror.NewError(from.Line, "", message, sequence)
+	err.Span = tkn.Span{Start: from, End: s.position()}
+	return err.WithHint(`Use \n, \t, \r, \\, \", \$ or \u{...} with one to six hexadecimal digits.`)
 }
 
 func (s *Scanner) scanToken() error {
 
 	s.start = s.current
+	s.startPosition = s.position()
 	c := s.advance()
 
 	switch c {
@@ -91,27 +233,83 @@ func (s *Scanner) scanToken() error {
 	case '\t':
 		break
 	case '\n':
-		s.line++
+		s.newline()
 	case '(':
 		s.addToken(tkn.LEFT_PAREN, nil)
 	case ')':
 		s.addToken(tkn.RIGHT_PAREN, nil)
 	case '{':
+		if n := len(s.interpolations); n > 0 {
+			s.interpolations[n-1]++
+		}
 		s.addToken(tkn.LEFT_BRACE, nil)
 	case '}':
+		if n := len(s.interpolations); n > 0 {
+			if s.interpolations[n-1] == 0 {
+				s.interpolations = s.interpolations[:n-1]
+				return s.scanString()
+			}
+			s.interpolations[n-1]--
+		}
 		s.addToken(tkn.RIGHT_BRACE, nil)
 	case ',':
 		s.addToken(tkn.COMMA, nil)
 	case '.':
 		s.addToken(tkn.DOT, nil)
 	case '-':
-		s.addToken(tkn.MINUS, nil)
+		if s.match('-') {
+			s.addToken(tkn.MINUS_MINUS, nil)
+		} else if s.match('=') {
+			s.addToken(tkn.MINUS_EQUAL, nil)
+		} else {
+			s.addToken(tkn.MINUS, nil)
+		}
 	case '+':
-		s.addToken(tkn.PLUS, nil)
+		if s.match('+') {
+			s.addToken(tkn.PLUS_PLUS, nil)
+		} else if s.match('=') {
+			s.addToken(tkn.PLUS_EQUAL, nil)
+		} else {
+			s.addToken(tkn.PLUS, nil)
+		}
 	case ';':
 		s.addToken(tkn.SEMICOLON, nil)
 	case '*':
-		s.addToken(tkn.STAR, nil)
+		if s.match('*') {
+			s.addToken(tkn.STAR_STAR, nil)
+		} else if s.match('=') {
+			s.addToken(tkn.STAR_EQUAL, nil)
+		} else {
+			s.addToken(tkn.STAR, nil)
+		}
+	case '%':
+		if s.match('=') {
+			s.addToken(tkn.PERCENT_EQUAL, nil)
+		} else {
+			s.addToken(tkn.PERCENT, nil)
+		}
+	case '&':
+		s.addToken(tkn.AMPERSAND, nil)
+	case '|':
+		s.addToken(tkn.PIPE, nil)
+	case '^':
+		s.addToken(tkn.CARET, nil)
+	case '~':
+		if s.match('/') {
+			s.addToken(tkn.TILDE_SLASH, nil)
+		} else {
+			s.addToken(tkn.TILDE, nil)
+		}
+	case ':':
+		s.addToken(tkn.COLON, nil)
+	case '?':
+		if s.match('?') {
+			s.addToken(tkn.QUESTION_QUESTION, nil)
+		} else if s.match('.') {
+			s.addToken(tkn.QUESTION_DOT, nil)
+		} else {
+			s.addToken(tkn.QUESTION, nil)
+		}
 	case '!':
 		if s.match('=') {
 			s.addToken(tkn.BANG_EQUAL, nil)
@@ -121,63 +319,192 @@ func (s *Scanner) scanToken() error {
 	case '=':
 		if s.match('=') {
 			s.addToken(tkn.EQUAL_EQUAL, nil)
+		} else if s.match('>') {
+			s.addToken(tkn.ARROW, nil)
 		} else {
 			s.addToken(tkn.EQUAL, nil)
 		}
 	case '<':
 		if s.match('=') {
 			s.addToken(tkn.LESS_EQUAL, nil)
+		} else if s.match('<') {
+			s.addToken(tkn.LESS_LESS, nil)
 		} else {
 			s.addToken(tkn.LESS, nil)
 		}
 	case '>':
 		if s.match('=') {
 			s.addToken(tkn.GREATER_EQUAL, nil)
+		} else if s.match('>') {
+			s.addToken(tkn.GREATER_GREATER, nil)
 		} else {
 			s.addToken(tkn.GREATER, nil)
 		}
 	case '/':
-		if s.match('/') { //comment
-			for s.peek() != '\n' && !s.isAtEnd() {
-				s.advance()
-			}
+		if s.match('/') {
+			s.scanLineComment()
+		} else if s.match('*') {
+			return s.scanBlockComment()
+		} else if s.match('=') {
+			s.addToken(tkn.SLASH_EQUAL, nil)
 		} else {
 			s.addToken(tkn.SLASH, nil)
 		}
 	case '"':
-		err := s.scanString()
-		if err != nil {
-			return err
-		}
+		return s.scanString()
 	default:
 		if isDigit(c) {
-			s.scanNumber()
+			return s.scanNumber()
 		} else if isAlpha(c) {
 			s.scanIdentifier()
 		} else {
-			return loxerror.NewError(s.line, "", loxerror.SCANNER_ERROR_UNEXPECTED_CHARACTER)
+			return s.error(loxerror.SCANNER_ERROR_UNEXPECTED_CHARACTER)
 		}
 	}
 	return nil
 }
 
-func (s *Scanner) scanNumber() error {
-	for !s.isAtEnd() && isDigit(s.peek()) {
+// scanLineComment scans a // comment. A comment starting with exactly three
+// slashes is a doc comment for the token that follows it.
+func (s *Scanner) scanLineComment() {
+	for s.peek() != '\n' && !s.isAtEnd() {
 		s.advance()
 	}
+	text := strings.TrimRight(s.source[s.start:s.current], " \t\r")
+	if strings.HasPrefix(text, "///") && !strings.HasPrefix(text, "////") {
+		s.addComment(tkn.DOC_COMMENT, text)
+		s.doc = append(s.doc, strings.TrimPrefix(text[3:], " "))
+		return
+	}
+	s.addComment(tkn.LINE_COMMENT, text)
+}
+
+// scanBlockComment scans a /* */ comment, which may contain nested ones.
+// An unterminated comment is reported at its opening "/*".
+func (s *Scanner) scanBlockComment() error {
+	depth := 1
+	for depth > 0 {
+		if s.isAtEnd() {
+			err := loxerror.NewError(s.startPosition.Line, "", loxerror.SCANNER_ERROR_UNTERMINATED_COMMENT)
+			end := s.startPosition
+			end.Column += 2
+			end.Offset += 2
+			err.Span = tkn.Span{Start: s.startPosition, End: end}
+			return err
+		}
+		c := s.advance()
+		switch {
+		case c == '\n':
+			s.newline()
+		case c == '/' && s.match('*'):
+			depth++
+		case c == '*' && s.match('/'):
+			depth--
+		}
+	}
+	s.addComment(tkn.BLOCK_COMMENT, s.source[s.start:s.current])
+	return nil
+}
+
+// scanNumber scans a decimal number with an optional fraction and exponent,
+// or a hexadecimal, binary or octal integer after a 0x, 0b or 0o prefix.
+// Underscores may separate digits. Letters or digits running on from a number
+// are scanned with it, so "0b12" or "3px" is reported as one malformed number.
+// A malformed number still becomes a token to keep the parser in step.
+func (s *Scanner) scanNumber() error {
+	base := 10
+	if s.source[s.start] == '0' {
+		switch s.peek() {
+		case 'x', 'X':
+			base = 16
+		case 'b', 'B':
+			base = 2
+		case 'o', 'O':
+			base = 8
+		}
+	}
 
-	if !s.isAtEnd() && s.peek() == '.' && isDigit(s.peekNext()) {
+	hint := ""
+	if base != 10 {
 		s.advance()
-		for !s.isAtEnd() && isDigit(s.peek()) {
+		s.skipDigits(base)
+		if s.current == s.start+2 && !isAlphaNumeric(s.peek()) {
+			hint = fmt.Sprintf("Write at least one digit after '%s'.", s.source[s.start:s.current])
+		}
+	} else {
+		s.skipDigits(10)
+		if s.peek() == '.' && isDigit(s.peekNext()) {
+			s.advance()
+			s.skipDigits(10)
+		}
+		if s.peek() == 'e' || s.peek() == 'E' {
+			s.advance()
+			if s.peek() == '+' || s.peek() == '-' {
+				s.advance()
+			}
+			if !isDigit(s.peek()) {
+				hint = "Write the digits of the exponent after '" + s.source[s.start:s.current] + "'."
+			}
+			s.skipDigits(10)
+		}
+	}
+
+	if c := s.peek(); isAlphaNumeric(c) {
+		if hint == "" {
+			hint = fmt.Sprintf("'%c' is not %s digit.", c, baseNames[base])
+			if base == 10 {
+				hint = fmt.Sprintf("'%c' cannot follow a number; separate them with a space.", c)
+			}
+		}
+		for isAlphaNumeric(s.peek()) {
 			s.advance()
 		}
 	}
 
-	loxNumber, _ := loxvalue.NewNumberFromText(s.source[s.start:s.current])
-	s.addToken(tkn.NUMBER, loxNumber)
+	text := s.source[s.start:s.current]
+	if hint == "" && !separatesDigits(text, base) {
+		hint = "'_' may only appear between two digits."
+	}
+	if hint != "" {
+		s.addToken(tkn.NUMBER, &loxvalue.Number{})
+		return s.error(loxerror.SCANNER_ERROR_MALFORMED_NUMBER, text).WithHint(hint)
+	}
+
+	number, err := loxvalue.NewNumberFromText(text)
+	s.addToken(tkn.NUMBER, number)
+	if err != nil {
+		hint = "Numbers are 64-bit floating point values, up to about 1.8e308."
+		if base != 10 {
+			hint = "Hexadecimal, binary and octal numbers must fit in 64 bits."
+		}
+		return s.error(loxerror.SCANNER_ERROR_NUMBER_OUT_OF_RANGE, text).WithHint(hint)
+	}
 	return nil
 }
 
+var baseNames = map[int]string{2: "a binary", 8: "an octal", 16: "a hexadecimal"}
+
+// skipDigits skips digits of base and underscores.
+func (s *Scanner) skipDigits(base int) {
+	for isDigitOf(s.peek(), base) || s.peek() == '_' {
+		s.advance()
+	}
+}
+
+// separatesDigits reports whether every underscore in a number has a digit
+// of base on both sides.
+func separatesDigits(text string, base int) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] != '_' {
+			continue
+		}
+		if i == 0 || i == len(text)-1 || !isDigitOf(rune(text[i-1]), base) || !isDigitOf(rune(text[i+1]), base) {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *Scanner) scanIdentifier() error {
 	for !s.isAtEnd() && isAlphaNumeric(s.peek()) {
 		s.advance()
@@ -199,30 +526,52 @@ func (s *Scanner) scanIdentifier() error {
 	return nil
 }
 
-func (s *Scanner) peekNext() byte {
-	if s.current >= len(s.source)-1 {
+func (s *Scanner) peekNext() rune {
+	if s.isAtEnd() {
+		return 0
+	}
+	_, size := utf8.DecodeRuneInString(s.source[s.current:])
+	if s.current+size >= len(s.source) {
 		return 0
 	}
-	return s.source[s.current+1]
+	char, _ := utf8.DecodeRuneInString(s.source[s.current+size:])
+	return char
 }
 
-func (s *Scanner) peek() byte {
+func (s *Scanner) peek() rune {
 	if s.isAtEnd() {
 		return 0
 	}
-	return s.source[s.current]
+	char, _ := utf8.DecodeRuneInString(s.source[s.current:])
+	return char
 }
 
-func isDigit(c byte) bool {
+func isDigit(c rune) bool {
 	return c >= '0' && c <= '9'
 }
 
-func isAlpha(c byte) bool {
-	return (c >= 'a' && c <= 'z') ||
-		(c >= 'A' && c <= 'Z') ||
-		c == '_'
+func isHexDigit(c rune) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isDigitOf(c rune, base int) bool {
+	switch base {
+	case 2:
+		return c == '0' || c == '1'
+	case 8:
+		return c >= '0' && c <= '7'
+	case 16:
+		return isHexDigit(c)
+	}
+	return isDigit(c)
+}
+
+// isAlpha reports whether c may start an identifier: any Unicode letter or
+// an underscore. Numbers stay ASCII.
+func isAlpha(c rune) bool {
+	return unicode.IsLetter(c) || c == '_'
 }
 
-func isAlphaNumeric(c byte) bool {
-	return isAlpha(c) || isDigit(c)
+func isAlphaNumeric(c rune) bool {
+	return isAlpha(c) || unicode.IsDigit(c)
 }
@@ -1,6 +1,7 @@
 package scanner_test
 
 import (
+	"fmt"
 	loxerror "golox/error"
 	"golox/scanner"
 	tkn "golox/token"
@@ -27,6 +28,27 @@ func TestScanner_Tokens(t *testing.T) {
 		{";", tkn.NewToken(tkn.SEMICOLON, ";", nil, 1)},
 		{"/", tkn.NewToken(tkn.SLASH, "/", nil, 1)},
 		{"*", tkn.NewToken(tkn.STAR, "*", nil, 1)},
+		{"%", tkn.NewToken(tkn.PERCENT, "%", nil, 1)},
+		{"&", tkn.NewToken(tkn.AMPERSAND, "&", nil, 1)},
+		{"|", tkn.NewToken(tkn.PIPE, "|", nil, 1)},
+		{"^", tkn.NewToken(tkn.CARET, "^", nil, 1)},
+		{"~", tkn.NewToken(tkn.TILDE, "~", nil, 1)},
+		{"**", tkn.NewToken(tkn.STAR_STAR, "**", nil, 1)},
+		{"~/", tkn.NewToken(tkn.TILDE_SLASH, "~/", nil, 1)},
+		{"<<", tkn.NewToken(tkn.LESS_LESS, "<<", nil, 1)},
+		{">>", tkn.NewToken(tkn.GREATER_GREATER, ">>", nil, 1)},
+		{":", tkn.NewToken(tkn.COLON, ":", nil, 1)},
+		{"=>", tkn.NewToken(tkn.ARROW, "=>", nil, 1)},
+		{"?", tkn.NewToken(tkn.QUESTION, "?", nil, 1)},
+		{"??", tkn.NewToken(tkn.QUESTION_QUESTION, "??", nil, 1)},
+		{"?.", tkn.NewToken(tkn.QUESTION_DOT, "?.", nil, 1)},
+		{"+=", tkn.NewToken(tkn.PLUS_EQUAL, "+=", nil, 1)},
+		{"-=", tkn.NewToken(tkn.MINUS_EQUAL, "-=", nil, 1)},
+		{"*=", tkn.NewToken(tkn.STAR_EQUAL, "*=", nil, 1)},
+		{"/=", tkn.NewToken(tkn.SLASH_EQUAL, "/=", nil, 1)},
+		{"%=", tkn.NewToken(tkn.PERCENT_EQUAL, "%=", nil, 1)},
+		{"++", tkn.NewToken(tkn.PLUS_PLUS, "++", nil, 1)},
+		{"--", tkn.NewToken(tkn.MINUS_MINUS, "--", nil, 1)},
 		{"!", tkn.NewToken(tkn.BANG, "!", nil, 1)},
 		{"!=", tkn.NewToken(tkn.BANG_EQUAL, "!=", nil, 1)},
 		{"=", tkn.NewToken(tkn.EQUAL, "=", nil, 1)},
@@ -51,9 +73,20 @@ func TestScanner_Tokens(t *testing.T) {
 		{"true", tkn.NewToken(tkn.TRUE, "true", &loxvalue.Boolean{Value: true}, 1)},
 		{"var", tkn.NewToken(tkn.VAR, "var", nil, 1)},
 		{"while", tkn.NewToken(tkn.WHILE, "while", nil, 1)},
+		{"break", tkn.NewToken(tkn.BREAK, "break", nil, 1)},
+		{"continue", tkn.NewToken(tkn.CONTINUE, "continue", nil, 1)},
 		{"identifier", tkn.NewToken(tkn.IDENTIFIER, "identifier", nil, 1)},
 		{"123", tkn.NewToken(tkn.NUMBER, "123", &loxvalue.Number{Value: 123}, 1)},
 		{"\"hello\"", tkn.NewToken(tkn.STRING, "\"hello\"", &loxvalue.String{Value: "hello"}, 1)},
+		{`"a\nb\tc\r\\\""`, tkn.NewToken(tkn.STRING, `"a\nb\tc\r\\\""`, &loxvalue.String{Value: "a\nb\tc\r\\\""}, 1)},
+		{`"\u{41}\u{1F600}"`, tkn.NewToken(tkn.STRING, `"\u{41}\u{1F600}"`, &loxvalue.String{Value: "A\U0001F600"}, 1)},
+		{"0xFF", tkn.NewToken(tkn.NUMBER, "0xFF", &loxvalue.Number{Value: 255}, 1)},
+		{"0b1010", tkn.NewToken(tkn.NUMBER, "0b1010", &loxvalue.Number{Value: 10}, 1)},
+		{"0o17", tkn.NewToken(tkn.NUMBER, "0o17", &loxvalue.Number{Value: 15}, 1)},
+		{"1e-9", tkn.NewToken(tkn.NUMBER, "1e-9", &loxvalue.Number{Value: 1e-9}, 1)},
+		{"2.5E+3", tkn.NewToken(tkn.NUMBER, "2.5E+3", &loxvalue.Number{Value: 2500}, 1)},
+		{"1_000_000", tkn.NewToken(tkn.NUMBER, "1_000_000", &loxvalue.Number{Value: 1000000}, 1)},
+		{"_id3", tkn.NewToken(tkn.IDENTIFIER, "_id3", nil, 1)},
 	}
 
 	for _, test := range tests {
@@ -68,8 +101,24 @@ func TestScanner_TokensError(t *testing.T) {
 		input   string
 		expected 	*loxerror.Error
 	}{
-		{"&", loxerror.NewError(1, "", loxerror.SCANNER_ERROR_UNEXPECTED_CHARACTER)},
-		{"\"foo", loxerror.NewError(1, "", loxerror.SCANNER_ERROR_UNTERMINATED_STRING)},
+		{"@", &loxerror.Error{Line: 1, Message: loxerror.SCANNER_ERROR_UNEXPECTED_CHARACTER, Code: "LOX0101", Span: span(1, 1, 0, 1, 2, 1)}},
+		{"\"foo", &loxerror.Error{Line: 1, Message: loxerror.SCANNER_ERROR_UNTERMINATED_STRING, Code: "LOX0102", Span: span(1, 1, 0, 1, 5, 4)}},
+		{"a\n\"f\no", &loxerror.Error{Line: 3, Message: loxerror.SCANNER_ERROR_UNTERMINATED_STRING, Code: "LOX0102", Span: span(2, 1, 2, 3, 2, 6)}},
+		{`"a\qb"`, &loxerror.Error{Line: 1, Message: `Invalid escape sequence '\q'.`, Code: "LOX0104", Span: span(1, 3, 2, 1, 5, 4), Hint: `Use \n, \t, \r, \\, \", \$ or \u{...} with one to six hexadecimal digits.`}},
+		{`"é\u{D800}"`, &loxerror.Error{Line: 1, Message: `Invalid Unicode escape '\u{D800}'.`, Code: "LOX0105", Span: span(1, 3, 3, 1, 11, 11), Hint: `Use \n, \t, \r, \\, \", \$ or \u{...} with one to six hexadecimal digits.`}},
+		{`"\u{1234567}"`, &loxerror.Error{Line: 1, Message: `Invalid Unicode escape '\u{1234567}'.`, Code: "LOX0105", Span: span(1, 2, 1, 1, 13, 12), Hint: `Use \n, \t, \r, \\, \", \$ or \u{...} with one to six hexadecimal digits.`}},
+		{`"\u41"`, &loxerror.Error{Line: 1, Message: `Invalid Unicode escape '\u'.`, Code: "LOX0105", Span: span(1, 2, 1, 1, 4, 3), Hint: `Use \n, \t, \r, \\, \", \$ or \u{...} with one to six hexadecimal digits.`}},
+		{"0x", numberError(loxerror.SCANNER_ERROR_MALFORMED_NUMBER, "0x", "Write at least one digit after '0x'.")},
+		{"0b102", numberError(loxerror.SCANNER_ERROR_MALFORMED_NUMBER, "0b102", "'2' is not a binary digit.")},
+		{"0o8", numberError(loxerror.SCANNER_ERROR_MALFORMED_NUMBER, "0o8", "'8' is not an octal digit.")},
+		{"1e+", numberError(loxerror.SCANNER_ERROR_MALFORMED_NUMBER, "1e+", "Write the digits of the exponent after '1e+'.")},
+		{"3px", numberError(loxerror.SCANNER_ERROR_MALFORMED_NUMBER, "3px", "'p' cannot follow a number; separate them with a space.")},
+		{"1__0", numberError(loxerror.SCANNER_ERROR_MALFORMED_NUMBER, "1__0", "'_' may only appear between two digits.")},
+		{"1_.5", numberError(loxerror.SCANNER_ERROR_MALFORMED_NUMBER, "1_.5", "'_' may only appear between two digits.")},
+		{"1e400", numberError(loxerror.SCANNER_ERROR_NUMBER_OUT_OF_RANGE, "1e400", "Numbers are 64-bit floating point values, up to about 1.8e308.")},
+		{"0x1_0000_0000_0000_0000", numberError(loxerror.SCANNER_ERROR_NUMBER_OUT_OF_RANGE, "0x1_0000_0000_0000_0000", "Hexadecimal, binary and octal numbers must fit in 64 bits.")},
+		{"é §", &loxerror.Error{Line: 1, Message: loxerror.SCANNER_ERROR_UNEXPECTED_CHARACTER, Code: "LOX0101", Span: span(1, 3, 3, 1, 4, 5)}},
+		{"a\n/* /* */\n", &loxerror.Error{Line: 2, Message: loxerror.SCANNER_ERROR_UNTERMINATED_COMMENT, Code: "LOX0103", Span: span(2, 1, 2, 2, 3, 4)}},
 	}
 
 	for _, test := range tests {
@@ -78,8 +127,122 @@ func TestScanner_TokensError(t *testing.T) {
 
 }
 
+func TestScanner_Spans(t *testing.T) {
+
+	tokens, errors := scanner.NewScanner("var a = 1;\n  print \"x\ny\" // c\n").Scan()
+	require.Empty(t, errors)
+
+	expected := []tkn.Span{
+		span(1, 1, 0, 1, 4, 3),
+		span(1, 5, 4, 1, 6, 5),
+		span(1, 7, 6, 1, 8, 7),
+		span(1, 9, 8, 1, 10, 9),
+		span(1, 10, 9, 1, 11, 10),
+		span(2, 3, 13, 2, 8, 18),
+		span(2, 9, 19, 3, 3, 24),
+		span(4, 1, 30, 4, 1, 30),
+	}
+	require.Len(t, tokens, len(expected))
+	for i, token := range tokens {
+		require.Equal(t, expected[i], token.Span, token.Lexeme)
+	}
+
+}
+
+func TestScanner_Interpolation(t *testing.T) {
+
+	tokens, errors := scanner.NewScanner(`"a ${ {b} } \${c} ${"${d}"}!"`).Scan()
+	require.Empty(t, errors)
+
+	expected := []struct {
+		tokenType tkn.TokenType
+		lexeme    string
+		literal   loxvalue.LoxValue
+	}{
+		{tkn.INTERPOLATION, `"a ${`, loxvalue.NewString("a ")},
+		{tkn.LEFT_BRACE, "{", nil},
+		{tkn.IDENTIFIER, "b", nil},
+		{tkn.RIGHT_BRACE, "}", nil},
+		{tkn.INTERPOLATION, `} \${c} ${`, loxvalue.NewString(" ${c} ")},
+		{tkn.INTERPOLATION, `"${`, loxvalue.NewString("")},
+		{tkn.IDENTIFIER, "d", nil},
+		{tkn.STRING, `}"`, loxvalue.NewString("")},
+		{tkn.STRING, `}!"`, loxvalue.NewString("!")},
+		{tkn.EOF, "", nil},
+	}
+	require.Len(t, tokens, len(expected))
+	for i, token := range tokens {
+		require.Equal(t, expected[i].tokenType, token.Type, token.Lexeme)
+		require.Equal(t, expected[i].lexeme, token.Lexeme)
+		require.Equal(t, expected[i].literal, token.Literal, token.Lexeme)
+	}
+
+}
+
+func TestScanner_UnicodeSpans(t *testing.T) {
+
+	tokens, errors := scanner.NewScanner("var naïve = \"😀\";").Scan()
+	require.Empty(t, errors)
+
+	expected := []tkn.Span{
+		span(1, 1, 0, 1, 4, 3),
+		span(1, 5, 4, 1, 10, 10),
+		span(1, 11, 11, 1, 12, 12),
+		span(1, 13, 13, 1, 16, 19),
+		span(1, 16, 19, 1, 17, 20),
+		span(1, 17, 20, 1, 17, 20),
+	}
+	require.Len(t, tokens, len(expected))
+	for i, token := range tokens {
+		require.Equal(t, expected[i], token.Span, token.Lexeme)
+	}
+	require.Equal(t, tkn.IDENTIFIER, tokens[1].Type)
+
+}
+
+func TestScanner_Comments(t *testing.T) {
+
+	s := scanner.NewScanner("/* a /* nested\n */ b */ var x;\n//// line\n/// Doc\n///  more\nfun")
+	tokens, errors := s.Scan()
+	require.Empty(t, errors)
+
+	expected := []struct {
+		kind tkn.CommentKind
+		text string
+		line int
+		next int
+	}{
+		{tkn.BLOCK_COMMENT, "/* a /* nested\n */ b */", 1, 0},
+		{tkn.LINE_COMMENT, "//// line", 3, 3},
+		{tkn.DOC_COMMENT, "/// Doc", 4, 3},
+		{tkn.DOC_COMMENT, "///  more", 5, 3},
+	}
+	comments := s.Comments()
+	require.Len(t, comments, len(expected))
+	for i, comment := range comments {
+		require.Equal(t, expected[i].kind, comment.Kind, comment.Text)
+		require.Equal(t, expected[i].text, comment.Text)
+		require.Equal(t, expected[i].line, comment.Line, comment.Text)
+		require.Equal(t, expected[i].next, comment.Next, comment.Text)
+	}
+
+	require.Equal(t, 2, tokens[0].Line)
+	require.Empty(t, tokens[0].Doc)
+	require.Equal(t, tkn.FUN, tokens[3].Type)
+	require.Equal(t, "Doc\n more", tokens[3].Doc)
+
+}
+
+func span(startLine, startColumn, startOffset, endLine, endColumn, endOffset int) tkn.Span {
+	return tkn.Span{
+		Start: tkn.Position{Line: startLine, Column: startColumn, Offset: startOffset},
+		End:   tkn.Position{Line: endLine, Column: endColumn, Offset: endOffset},
+	}
+}
+
 func testToken(t *testing.T, input string, expected tkn.Token) {
 	
+	expected.Span = span(1, 1, 0, 1, len(input)+1, len(input))
 	s := scanner.NewScanner(input)
 	tokens, errors := s.Scan()
 	require.Empty(t, errors)
@@ -89,6 +252,20 @@ func testToken(t *testing.T, input string, expected tkn.Token) {
 
 }
 
+func numberError(message, text, hint string) *loxerror.Error {
+	codes := map[string]string{
+		loxerror.SCANNER_ERROR_MALFORMED_NUMBER:    "LOX0106",
+		loxerror.SCANNER_ERROR_NUMBER_OUT_OF_RANGE: "LOX0107",
+	}
+	return &loxerror.Error{
+		Line:    1,
+		Message: fmt.Sprintf(message, text),
+		Code:    codes[message],
+		Span:    span(1, 1, 0, 1, len(text)+1, len(text)),
+		Hint:    hint,
+	}
+}
+
 func testTokenError(t *testing.T, input string, expected *loxerror.Error) {
 
 	scanner := scanner.NewScanner(input)
@@ -12,6 +12,14 @@ type StmtVisitor interface {
 	VisitBlockStatement(BlockStmt BlockStmt) (interface{}, error)
 	VisitIfStatement(IfStmt IfStmt) (interface{}, error)
 	VisitWhileStatement(WhileStmt WhileStmt) (interface{}, error)
+	VisitForStatement(ForStmt ForStmt) (interface{}, error)
+	VisitFunctionStatement(FunStmt FunStmt) (interface{}, error)
+	VisitReturnStatement(ReturnStmt ReturnStmt) (interface{}, error)
+	VisitClassStatement(ClassStmt ClassStmt) (interface{}, error)
+	VisitThrowStatement(ThrowStmt ThrowStmt) (interface{}, error)
+	VisitTryStatement(TryStmt TryStmt) (interface{}, error)
+	VisitBreakStatement(BreakStmt BreakStmt) (interface{}, error)
+	VisitContinueStatement(ContinueStmt ContinueStmt) (interface{}, error)
 }
 
 type Stmt interface {
@@ -19,7 +27,8 @@ type Stmt interface {
 }
 
 type ExprStmt struct {
-	E expr.Expr
+	E    expr.Expr
+	Span token.Span
 }
 
 func (es ExprStmt) Accept(visitor StmtVisitor) (interface{}, error) {
@@ -27,50 +36,169 @@ func (es ExprStmt) Accept(visitor StmtVisitor) (interface{}, error) {
 }
 
 type PrintStmt struct {
-	E expr.Expr
+	E    expr.Expr
+	Span token.Span
 }
 
 func (ps PrintStmt) Accept(visitor StmtVisitor) (interface{}, error) {
-	return nil, nil
-	// return visitor.VisitPrintStatement(ps)
+	return visitor.VisitPrintStatement(ps)
 }
 
+// VarStmt, FunStmt and ClassStmt keep the text of the doc comments written
+// before them in Doc, for documentation tools.
 type VarStmt struct {
-	Name token.Token
+	Name        token.Token
 	Initializer expr.Expr
+	Span        token.Span
+	Doc         string
 }
 
 func (s VarStmt) Accept(visitor StmtVisitor) (interface{}, error) {
-	return nil, nil
-	// return visitor.VisitVariableStatement(s)
+	return visitor.VisitVariableStatement(s)
 }
 
 type BlockStmt struct {
 	Statements []Stmt
+	Span       token.Span
 }
 
 func (s BlockStmt) Accept(visitor StmtVisitor) (interface{}, error) {
-	return nil, nil
-	// return visitor.VisitBlockStatement(s)
+	return visitor.VisitBlockStatement(s)
 }
 
 type IfStmt struct {
-	Condition expr.Expr
+	Condition  expr.Expr
 	ThenBrnach Stmt
 	ElseBranch Stmt
+	Span       token.Span
 }
 
 func (s IfStmt) Accept(visitor StmtVisitor) (interface{}, error) {
-	return nil, nil
-	// return visitor.VisitIfStatement(s)
+	return visitor.VisitIfStatement(s)
 }
 
-type WhileStmt struct { 
+// WhileStmt and ForStmt have a Label when written as "name: while ...", for
+// break and continue statements in nested loops to refer to.
+type WhileStmt struct {
 	Condition expr.Expr
-	Body Stmt
+	Body      Stmt
+	Label     *token.Token
+	Span      token.Span
 }
 
 func (s WhileStmt) Accept(visitor StmtVisitor) (interface{}, error) {
-	return nil, nil
-	// return visitor.VisitWhileStatement(s)
-}
\ No newline at end of file
+	return visitor.VisitWhileStatement(s)
+}
+
+type FunStmt struct {
+	Name   token.Token
+	Params []token.Token
+	Body   []Stmt
+	Span   token.Span
+	Doc    string
+}
+
+func (s FunStmt) Accept(visitor StmtVisitor) (interface{}, error) {
+	return visitor.VisitFunctionStatement(s)
+}
+
+// ANONYMOUS is the name anonymous functions go by when printed and in stack
+// traces.
+const ANONYMOUS = "anonymous"
+
+// Declaration returns the anonymous function as a declaration named
+// ANONYMOUS, located at its keyword.
+func Declaration(function expr.FunctionExpr) FunStmt {
+	name := function.Keyword
+	name.Type = token.IDENTIFIER
+	name.Lexeme = ANONYMOUS
+	return FunStmt{
+		Name:   name,
+		Params: function.Params,
+		Body:   function.Body.([]Stmt),
+		Span:   function.Span,
+	}
+}
+
+type ReturnStmt struct {
+	Keyword token.Token
+	Value   expr.Expr
+	Span    token.Span
+}
+
+func (s ReturnStmt) Accept(visitor StmtVisitor) (interface{}, error) {
+	return visitor.VisitReturnStatement(s)
+}
+
+type ClassStmt struct {
+	Name       token.Token
+	Superclass *expr.VariableExpr
+	Methods    []FunStmt
+	Span       token.Span
+	Doc        string
+}
+
+func (s ClassStmt) Accept(visitor StmtVisitor) (interface{}, error) {
+	return visitor.VisitClassStatement(s)
+}
+
+type ForStmt struct {
+	Initializer Stmt
+	Condition   expr.Expr
+	Increment   expr.Expr
+	Body        Stmt
+	Label       *token.Token
+	Span        token.Span
+}
+
+func (s ForStmt) Accept(visitor StmtVisitor) (interface{}, error) {
+	return visitor.VisitForStatement(s)
+}
+
+type ThrowStmt struct {
+	Keyword token.Token
+	Value   expr.Expr
+	Span    token.Span
+}
+
+func (s ThrowStmt) Accept(visitor StmtVisitor) (interface{}, error) {
+	return visitor.VisitThrowStatement(s)
+}
+
+// TryStmt runs Body and, if it raises an error, Catch with the error bound to
+// CatchName. Catch and Finally are block statements, nil when the clause is
+// missing; the parser requires at least one of them.
+type TryStmt struct {
+	Keyword   token.Token
+	Body      BlockStmt
+	CatchName token.Token
+	Catch     Stmt
+	Finally   Stmt
+	Span      token.Span
+}
+
+func (s TryStmt) Accept(visitor StmtVisitor) (interface{}, error) {
+	return visitor.VisitTryStatement(s)
+}
+
+// BreakStmt and ContinueStmt leave or restart the innermost loop, or the
+// loop named by Label when it is set.
+type BreakStmt struct {
+	Keyword token.Token
+	Label   *token.Token
+	Span    token.Span
+}
+
+func (s BreakStmt) Accept(visitor StmtVisitor) (interface{}, error) {
+	return visitor.VisitBreakStatement(s)
+}
+
+type ContinueStmt struct {
+	Keyword token.Token
+	Label   *token.Token
+	Span    token.Span
+}
+
+func (s ContinueStmt) Accept(visitor StmtVisitor) (interface{}, error) {
+	return visitor.VisitContinueStatement(s)
+}
@@ -1,6 +1,9 @@
 package token
 
-import loxvalue "golox/value"
+import (
+	"fmt"
+	loxvalue "golox/value"
+)
 
 const (
 	LEFT_PAREN TokenType = iota
@@ -14,6 +17,12 @@ const (
 	SEMICOLON
 	SLASH
 	STAR
+	PERCENT
+	AMPERSAND
+	PIPE
+	CARET
+	TILDE
+	COLON
 
 	// One or two character tokens.
 	BANG
@@ -24,11 +33,27 @@ const (
 	GREATER_EQUAL
 	LESS
 	LESS_EQUAL
+	STAR_STAR
+	TILDE_SLASH
+	LESS_LESS
+	GREATER_GREATER
+	QUESTION
+	QUESTION_QUESTION
+	QUESTION_DOT
+	PLUS_EQUAL
+	MINUS_EQUAL
+	STAR_EQUAL
+	SLASH_EQUAL
+	PERCENT_EQUAL
+	PLUS_PLUS
+	MINUS_MINUS
+	ARROW
 
 	// Literals.
 	IDENTIFIER
 	STRING
 	NUMBER
+	INTERPOLATION
 
 	// Keywords.
 	AND
@@ -47,17 +72,124 @@ const (
 	TRUE
 	VAR
 	WHILE
+	THROW
+	TRY
+	CATCH
+	FINALLY
+	BREAK
+	CONTINUE
 
 	EOF
 )
 
 type TokenType int
 
+var tokenNames = map[TokenType]string{
+	LEFT_PAREN:    "LEFT_PAREN",
+	RIGHT_PAREN:   "RIGHT_PAREN",
+	LEFT_BRACE:    "LEFT_BRACE",
+	RIGHT_BRACE:   "RIGHT_BRACE",
+	COMMA:         "COMMA",
+	DOT:           "DOT",
+	MINUS:         "MINUS",
+	PLUS:          "PLUS",
+	SEMICOLON:     "SEMICOLON",
+	SLASH:         "SLASH",
+	STAR:          "STAR",
+	PERCENT:       "PERCENT",
+	AMPERSAND:     "AMPERSAND",
+	PIPE:          "PIPE",
+	CARET:         "CARET",
+	TILDE:         "TILDE",
+	COLON:         "COLON",
+	BANG:          "BANG",
+	BANG_EQUAL:    "BANG_EQUAL",
+	EQUAL:         "EQUAL",
+	EQUAL_EQUAL:   "EQUAL_EQUAL",
+	GREATER:       "GREATER",
+	GREATER_EQUAL: "GREATER_EQUAL",
+	LESS:          "LESS",
+	LESS_EQUAL:    "LESS_EQUAL",
+	STAR_STAR:     "STAR_STAR",
+	TILDE_SLASH:   "TILDE_SLASH",
+	LESS_LESS:     "LESS_LESS",
+	GREATER_GREATER: "GREATER_GREATER",
+	QUESTION:      "QUESTION",
+	QUESTION_QUESTION: "QUESTION_QUESTION",
+	QUESTION_DOT:  "QUESTION_DOT",
+	PLUS_EQUAL:    "PLUS_EQUAL",
+	MINUS_EQUAL:   "MINUS_EQUAL",
+	STAR_EQUAL:    "STAR_EQUAL",
+	SLASH_EQUAL:   "SLASH_EQUAL",
+	PERCENT_EQUAL: "PERCENT_EQUAL",
+	PLUS_PLUS:     "PLUS_PLUS",
+	MINUS_MINUS:   "MINUS_MINUS",
+	ARROW:         "ARROW",
+	IDENTIFIER:    "IDENTIFIER",
+	STRING:        "STRING",
+	NUMBER:        "NUMBER",
+	INTERPOLATION: "INTERPOLATION",
+	AND:           "AND",
+	CLASS:         "CLASS",
+	ELSE:          "ELSE",
+	FALSE:         "FALSE",
+	FUN:           "FUN",
+	FOR:           "FOR",
+	IF:            "IF",
+	NIL:           "NIL",
+	OR:            "OR",
+	PRINT:         "PRINT",
+	RETURN:        "RETURN",
+	SUPER:         "SUPER",
+	THIS:          "THIS",
+	TRUE:          "TRUE",
+	VAR:           "VAR",
+	WHILE:         "WHILE",
+	THROW:         "THROW",
+	TRY:           "TRY",
+	CATCH:         "CATCH",
+	FINALLY:       "FINALLY",
+	BREAK:         "BREAK",
+	CONTINUE:      "CONTINUE",
+	EOF:           "EOF",
+}
+
+func (t TokenType) String() string {
+	if name, ok := tokenNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("TokenType(%d)", int(t))
+}
+
+// Token is a lexeme of the source. Doc holds the text of the /// doc
+// comments right before the token, one line per comment, with the slashes
+// and the space after them removed.
 type Token struct {
 	Literal loxvalue.LoxValue
 	Type    TokenType
 	Lexeme  string
 	Line    int
+	Span    Span
+	Doc     string
+}
+
+// Position is a point in the source. Line and Column start at 1 and Column
+// counts characters; Offset is the byte offset from the start of the source.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// Span is the source range from Start up to, but not including, End.
+type Span struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// IsZero reports whether the span was never set, as for synthesized tokens.
+func (s Span) IsZero() bool {
+	return s.Start.Line == 0
 }
 
 func NewToken(tokenType TokenType, lexeme string, literal loxvalue.LoxValue, line int) Token {
@@ -66,6 +198,8 @@ func NewToken(tokenType TokenType, lexeme string, literal loxvalue.LoxValue, lin
 		tokenType,
 		lexeme,
 		line,
+		Span{},
+		"",
 	}
 }
 
@@ -103,7 +237,57 @@ func Identifier(text string) TokenType {
 		return VAR
 	case "while":
 		return WHILE
+	case "throw":
+		return THROW
+	case "try":
+		return TRY
+	case "catch":
+		return CATCH
+	case "finally":
+		return FINALLY
+	case "break":
+		return BREAK
+	case "continue":
+		return CONTINUE
 	default:
 		return IDENTIFIER
 	}
-}
\ No newline at end of file
+}
+
+// BinaryOperator returns the operator that a compound assignment or an
+// increment applies, such as PLUS for PLUS_EQUAL and PLUS_PLUS.
+func BinaryOperator(t TokenType) TokenType {
+	switch t {
+	case PLUS_EQUAL, PLUS_PLUS:
+		return PLUS
+	case MINUS_EQUAL, MINUS_MINUS:
+		return MINUS
+	case STAR_EQUAL:
+		return STAR
+	case SLASH_EQUAL:
+		return SLASH
+	case PERCENT_EQUAL:
+		return PERCENT
+	}
+	return t
+}
+
+type CommentKind int
+
+const (
+	LINE_COMMENT CommentKind = iota
+	BLOCK_COMMENT
+	DOC_COMMENT
+)
+
+// Comment is source text the scanner skips. Next is the index of the token
+// that follows it, so tools such as the formatter can put comments back
+// where they were. Line is the first line of the comment; block comments
+// may span several.
+type Comment struct {
+	Text string
+	Line int
+	Next int
+	Kind CommentKind
+	Span Span
+}
@@ -0,0 +1,40 @@
+package loxvalue
+
+// LoxCallable is implemented by every value that can be invoked with call
+// syntax. Go code can implement it to expose native functions to scripts.
+type LoxCallable interface {
+	LoxValue
+	Arity() int
+	Call(arguments []LoxValue) (LoxValue, error)
+}
+
+// NativeFunction wraps a Go function so it can be called from Lox.
+type NativeFunction struct {
+	Name   string
+	Params int
+	Fn     func(arguments []LoxValue) (LoxValue, error)
+}
+
+func NewNativeFunction(name string, arity int, fn func(arguments []LoxValue) (LoxValue, error)) *NativeFunction {
+	return &NativeFunction{
+		Name:   name,
+		Params: arity,
+		Fn:     fn,
+	}
+}
+
+func (f *NativeFunction) Type() int {
+	return FUNCTION
+}
+
+func (f *NativeFunction) ToString() string {
+	return "<native fn>"
+}
+
+func (f *NativeFunction) Arity() int {
+	return f.Params
+}
+
+func (f *NativeFunction) Call(arguments []LoxValue) (LoxValue, error) {
+	return f.Fn(arguments)
+}
@@ -0,0 +1,41 @@
+package loxvalue
+
+import "strings"
+
+// Error is the value a catch clause receives for an error raised by golox
+// itself, such as a type error or an undefined variable. Lox code reads its
+// message and stack properties. Err is the original Go error, so that
+// throwing the value again reports the error where it first happened.
+type Error struct {
+	Message string
+	Stack   []string
+	Err     error
+}
+
+func NewError(message string, stack []string, err error) *Error {
+	return &Error{
+		Message: message,
+		Stack:   stack,
+		Err:     err,
+	}
+}
+
+func (e *Error) Type() int {
+	return ERROR
+}
+
+func (e *Error) ToString() string {
+	return e.Message
+}
+
+// Get returns the property called name: the message, or the stack trace
+// with one frame per line.
+func (e *Error) Get(name string) (LoxValue, bool) {
+	switch name {
+	case "message":
+		return NewString(e.Message), true
+	case "stack":
+		return NewString(strings.Join(e.Stack, "\n")), true
+	}
+	return nil, false
+}
@@ -0,0 +1,13 @@
+package loxvalue
+
+import "time"
+
+// Natives returns the built-in functions every backend defines as globals.
+func Natives() []*NativeFunction {
+	return []*NativeFunction{
+		NewNativeFunction("clock", 0, func(arguments []LoxValue) (LoxValue, error) {
+			seconds := float64(time.Now().UnixNano()) / float64(time.Second)
+			return &Number{Value: seconds}, nil
+		}),
+	}
+}
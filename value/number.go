@@ -1,6 +1,7 @@
 package loxvalue
 
 import (
+	"math"
 	"strconv"
 	"strings"
 )
@@ -9,7 +10,27 @@ type Number struct {
 	Value float64
 }
 
+// NewNumberFromText converts a number literal: a decimal with an optional
+// fraction and exponent, or an integer with a 0x, 0b or 0o prefix. Underscores
+// between digits are ignored. Hexadecimal, binary and octal literals must fit
+// in 64 bits.
 func NewNumberFromText(number string) (*Number, error) {
+	number = strings.ReplaceAll(number, "_", "")
+	if len(number) > 2 && number[0] == '0' {
+		base := 0
+		switch number[1] {
+		case 'x', 'X':
+			base = 16
+		case 'b', 'B':
+			base = 2
+		case 'o', 'O':
+			base = 8
+		}
+		if base != 0 {
+			value, err := strconv.ParseUint(number[2:], base, 64)
+			return &Number{Value: float64(value)}, err
+		}
+	}
 	value, err := strconv.ParseFloat(number, 64)
 	return &Number{Value: value}, err
 }
@@ -43,6 +64,56 @@ func (n Number) Multiply(v *Number) *Number {
 	return &Number{Value: n.Value * v.Value,}
 }
 
+// Modulo returns the remainder of the floored division n // v, which has
+// the sign of v.
+func (n Number) Modulo(v *Number) *Number {
+	remainder := math.Mod(n.Value, v.Value)
+	if remainder != 0 && (remainder < 0) != (v.Value < 0) {
+		remainder += v.Value
+	}
+	return &Number{Value: remainder}
+}
+
+func (n Number) FloorDivide(v *Number) *Number {
+	return &Number{Value: math.Floor(n.Value / v.Value)}
+}
+
+func (n Number) Power(v *Number) *Number {
+	return &Number{Value: math.Pow(n.Value, v.Value)}
+}
+
+// IsInteger reports whether n is a whole number that fits in 64 bits, the
+// only numbers the bitwise operators accept.
+func (n Number) IsInteger() bool {
+	return n.Value == math.Trunc(n.Value) && n.Value >= -(1<<63) && n.Value < 1<<63
+}
+
+func (n Number) BitAnd(v *Number) *Number {
+	return &Number{Value: float64(int64(n.Value) & int64(v.Value))}
+}
+
+func (n Number) BitOr(v *Number) *Number {
+	return &Number{Value: float64(int64(n.Value) | int64(v.Value))}
+}
+
+func (n Number) BitXor(v *Number) *Number {
+	return &Number{Value: float64(int64(n.Value) ^ int64(v.Value))}
+}
+
+func (n Number) BitNot() *Number {
+	return &Number{Value: float64(^int64(n.Value))}
+}
+
+// ShiftLeft and ShiftRight expect a count that is not negative. ShiftRight
+// keeps the sign of n.
+func (n Number) ShiftLeft(v *Number) *Number {
+	return &Number{Value: float64(int64(n.Value) << uint64(v.Value))}
+}
+
+func (n Number) ShiftRight(v *Number) *Number {
+	return &Number{Value: float64(int64(n.Value) >> uint64(v.Value))}
+}
+
 func (n Number) Greater(v *Number) *Boolean {
 	return &Boolean{Value: n.Value > v.Value,}
 }
@@ -7,6 +7,10 @@ const (
 	BOOLEAN
 	NUMBER
 	STRING
+	FUNCTION
+	CLASS
+	INSTANCE
+	ERROR
 )
 
 type LoxValue interface {
@@ -14,8 +18,14 @@ type LoxValue interface {
 	ToString() string
 }
 
+// IsEqual compares primitive values by content and every other value by
+// identity.
 func IsEqual(a LoxValue, b LoxValue) bool {
-	return reflect.DeepEqual(a, b)
+	switch a.Type() {
+	case NIL, BOOLEAN, NUMBER, STRING:
+		return reflect.DeepEqual(a, b)
+	}
+	return a == b
 }
 
 func IsTruthy(value LoxValue) bool {
@@ -26,4 +26,32 @@ func TestValue_IsTruthyFalse(t *testing.T) {
 	t.Skip()
 	require.False(t, loxvalue.IsTruthy(loxvalue.Boolean{Value: false}))
 	require.False(t, loxvalue.IsTruthy(loxvalue.Nil{}))
-}
\ No newline at end of file
+}
+
+func TestValue_NewNumberFromText(t *testing.T) {
+
+	tests := []struct {
+		text     string
+		expected float64
+	}{
+		{"12.5", 12.5},
+		{"1_000_000", 1000000},
+		{"1e-3", 0.001},
+		{"0xFF", 255},
+		{"0b1010", 10},
+		{"0o17", 15},
+		{"0", 0},
+	}
+
+	for _, test := range tests {
+		number, err := loxvalue.NewNumberFromText(test.text)
+		require.NoError(t, err, test.text)
+		require.Equal(t, test.expected, number.Value, test.text)
+	}
+
+	_, err := loxvalue.NewNumberFromText("1e400")
+	require.Error(t, err)
+	_, err = loxvalue.NewNumberFromText("0x10000000000000000")
+	require.Error(t, err)
+
+}
@@ -0,0 +1,89 @@
+package vm
+
+import (
+	"golox/bytecode"
+	loxvalue "golox/value"
+)
+
+// Closure pairs a compiled function with the variables it captured.
+type Closure struct {
+	Function *bytecode.Function
+	Upvalues []*Upvalue
+}
+
+func NewClosure(function *bytecode.Function) *Closure {
+	return &Closure{
+		Function: function,
+		Upvalues: make([]*Upvalue, function.UpvalueCount),
+	}
+}
+
+func (c *Closure) Type() int {
+	return loxvalue.FUNCTION
+}
+
+func (c *Closure) ToString() string {
+	return c.Function.ToString()
+}
+
+// Upvalue refers to a captured variable. While the variable is still on the
+// stack location points into it; once closed, location points at closed.
+type Upvalue struct {
+	location *loxvalue.LoxValue
+	closed   loxvalue.LoxValue
+	slot     int
+	next     *Upvalue
+}
+
+type Class struct {
+	Name    string
+	Methods map[string]*Closure
+}
+
+func NewClass(name string) *Class {
+	return &Class{
+		Name:    name,
+		Methods: make(map[string]*Closure),
+	}
+}
+
+func (c *Class) Type() int {
+	return loxvalue.CLASS
+}
+
+func (c *Class) ToString() string {
+	return c.Name
+}
+
+type Instance struct {
+	Class  *Class
+	Fields map[string]loxvalue.LoxValue
+}
+
+func NewInstance(class *Class) *Instance {
+	return &Instance{
+		Class:  class,
+		Fields: make(map[string]loxvalue.LoxValue),
+	}
+}
+
+func (o *Instance) Type() int {
+	return loxvalue.INSTANCE
+}
+
+func (o *Instance) ToString() string {
+	return o.Class.Name + " instance"
+}
+
+type BoundMethod struct {
+	Receiver loxvalue.LoxValue
+	Method   *Closure
+}
+
+func (b *BoundMethod) Type() int {
+	return loxvalue.FUNCTION
+}
+
+func (b *BoundMethod) ToString() string {
+	return b.Method.ToString()
+}
@@ -0,0 +1,708 @@
+package vm
+
+import (
+	"fmt"
+	"golox/bytecode"
+	loxerror "golox/error"
+	"golox/stmt"
+	loxvalue "golox/value"
+	"io"
+	"os"
+	"strings"
+)
+
+const FRAMES_MAX = 256
+const STACK_MAX = FRAMES_MAX * 256
+
+var (
+	nilValue   = &loxvalue.Nil{}
+	trueValue  = loxvalue.NewBoolean(true)
+	falseValue = loxvalue.NewBoolean(false)
+)
+
+// handler is an active try block: the frame and stack height to unwind to,
+// and where its catch or finally code starts. A finally handler receives the
+// error itself, so that rethrowing it keeps the original location.
+type handler struct {
+	frameCount int
+	stackTop   int
+	ip         int
+	finally    bool
+}
+
+type callFrame struct {
+	closure *Closure
+	ip      int
+	slots   int
+}
+
+func (f *callFrame) readByte() byte {
+	b := f.closure.Function.Chunk.Code[f.ip]
+	f.ip++
+	return b
+}
+
+func (f *callFrame) readShort() int {
+	value := f.closure.Function.Chunk.ReadShort(f.ip)
+	f.ip += 2
+	return value
+}
+
+func (f *callFrame) readConstant() loxvalue.LoxValue {
+	return f.closure.Function.Chunk.Constants[f.readShort()]
+}
+
+func (f *callFrame) readString() string {
+	return f.readConstant().(*loxvalue.String).Value
+}
+
+// VM executes compiled functions on a value stack. Globals persist across
+// calls to Interpret, so a single VM can serve a whole REPL session.
+type VM struct {
+	frames       [FRAMES_MAX]callFrame
+	frameCount   int
+	stack        [STACK_MAX]loxvalue.LoxValue
+	stackTop     int
+	globals      map[string]loxvalue.LoxValue
+	openUpvalues *Upvalue
+	handlers     []handler
+	out          io.Writer
+	file         string
+}
+
+func NewVM() *VM {
+	vm := &VM{
+		globals: make(map[string]loxvalue.LoxValue),
+		out:     os.Stdout,
+	}
+	for _, native := range loxvalue.Natives() {
+		vm.Define(native.Name, native)
+	}
+	return vm
+}
+
+// Define binds a global name, typically to a loxvalue.LoxCallable
+// implemented in Go.
+func (vm *VM) Define(name string, value loxvalue.LoxValue) {
+	vm.globals[name] = value
+}
+
+// SetOutput redirects the output of print statements.
+func (vm *VM) SetOutput(out io.Writer) {
+	vm.out = out
+}
+
+// SetFile names the file the program came from in stack traces.
+func (vm *VM) SetFile(file string) {
+	vm.file = file
+}
+
+// Interpret runs a script produced by compiler.Compile.
+func (vm *VM) Interpret(function *bytecode.Function) error {
+	closure := NewClosure(function)
+	vm.push(closure)
+	err := vm.call(closure, 0)
+	if err == nil {
+		err = vm.run()
+	}
+	if err != nil {
+		vm.resetStack()
+	}
+	return err
+}
+
+func (vm *VM) push(value loxvalue.LoxValue) {
+	vm.stack[vm.stackTop] = value
+	vm.stackTop++
+}
+
+func (vm *VM) pop() loxvalue.LoxValue {
+	vm.stackTop--
+	return vm.stack[vm.stackTop]
+}
+
+func (vm *VM) peek(distance int) loxvalue.LoxValue {
+	return vm.stack[vm.stackTop-1-distance]
+}
+
+func (vm *VM) resetStack() {
+	vm.stackTop = 0
+	vm.frameCount = 0
+	vm.openUpvalues = nil
+	vm.handlers = nil
+}
+
+func (vm *VM) runtimeError(format string, args ...interface{}) *loxerror.Error {
+	frame := &vm.frames[vm.frameCount-1]
+	chunk := frame.closure.Function.Chunk
+	err := loxerror.NewError(chunk.Lines[frame.ip-1], "", format, args...)
+	err.Span = chunk.Spans[frame.ip-1]
+	if vm.frameCount > 1 {
+		err.Trace = vm.trace()
+	}
+	return err
+}
+
+func (vm *VM) undefinedVariable(name string) error {
+	err := vm.runtimeError(loxerror.RUNTIME_ERROR_UNDEFINED_VARIABLE, name)
+	return err.WithHint(fmt.Sprintf(loxerror.HINT_UNDEFINED_VARIABLE, name))
+}
+
+// throw returns the error raised by throwing value. Throwing an error value
+// that was caught earlier raises the original error again.
+func (vm *VM) throw(value loxvalue.LoxValue) error {
+	if caught, ok := value.(*loxvalue.Error); ok && caught.Err != nil {
+		return caught.Err
+	}
+	err := vm.runtimeError(loxerror.RUNTIME_ERROR_UNCAUGHT, value.ToString())
+	err.Thrown = value
+	return err
+}
+
+func (vm *VM) pushHandler(frame *callFrame, finally bool) {
+	offset := frame.readShort()
+	vm.handlers = append(vm.handlers, handler{
+		frameCount: vm.frameCount,
+		stackTop:   vm.stackTop,
+		ip:         frame.ip + offset,
+		finally:    finally,
+	})
+}
+
+// catch unwinds to the innermost try block and resumes at its handler with
+// the caught value on the stack. It reports false when no try block is
+// active.
+func (vm *VM) catch(err error) bool {
+	if len(vm.handlers) == 0 {
+		return false
+	}
+	h := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	vm.closeUpvalues(h.stackTop)
+	vm.frameCount = h.frameCount
+	vm.stackTop = h.stackTop
+	if h.finally {
+		vm.push(loxvalue.NewError(err.Error(), nil, err))
+	} else {
+		vm.push(loxerror.Caught(err))
+	}
+	vm.frames[vm.frameCount-1].ip = h.ip
+	return true
+}
+
+// trace returns the active frames, innermost first.
+func (vm *VM) trace() []loxerror.Frame {
+	trace := []loxerror.Frame{}
+	for i := vm.frameCount - 1; i >= 0; i-- {
+		frame := &vm.frames[i]
+		function := frame.closure.Function
+		name := function.Name
+		if name == "" {
+			name = loxerror.SCRIPT_FRAME
+		}
+		trace = append(trace, loxerror.Frame{Function: name, File: vm.file, Line: function.Chunk.Lines[frame.ip-1]})
+	}
+	return trace
+}
+
+// arityError reports a call of function with argCount arguments, with a note
+// pointing at the function's declaration.
+func (vm *VM) arityError(function *bytecode.Function, argCount int) error {
+	err := vm.runtimeError(loxerror.RUNTIME_ERROR_ARITY, function.Arity, argCount)
+	note := fmt.Sprintf(loxerror.NOTE_DECLARED_HERE, function.Name)
+	if function.Name == stmt.ANONYMOUS {
+		note = loxerror.NOTE_ANONYMOUS_DECLARED_HERE
+	}
+	return err.WithNote(function.Span, note)
+}
+
+func (vm *VM) call(closure *Closure, argCount int) error {
+	if argCount != closure.Function.Arity {
+		return vm.runtimeError(loxerror.RUNTIME_ERROR_ARITY, closure.Function.Arity, argCount)
+	}
+	if vm.frameCount == FRAMES_MAX {
+		return vm.runtimeError(loxerror.RUNTIME_ERROR_STACK_OVERFLOW)
+	}
+	frame := &vm.frames[vm.frameCount]
+	vm.frameCount++
+	frame.closure = closure
+	frame.ip = 0
+	frame.slots = vm.stackTop - argCount - 1
+	return nil
+}
+
+func (vm *VM) callValue(callee loxvalue.LoxValue, argCount int) error {
+	switch callee := callee.(type) {
+	case *BoundMethod:
+		if argCount != callee.Method.Function.Arity {
+			return vm.arityError(callee.Method.Function, argCount)
+		}
+		vm.stack[vm.stackTop-argCount-1] = callee.Receiver
+		return vm.call(callee.Method, argCount)
+	case *Class:
+		vm.stack[vm.stackTop-argCount-1] = NewInstance(callee)
+		if initializer, ok := callee.Methods["init"]; ok {
+			return vm.call(initializer, argCount)
+		}
+		if argCount != 0 {
+			return vm.runtimeError(loxerror.RUNTIME_ERROR_ARITY, 0, argCount)
+		}
+		return nil
+	case *Closure:
+		if argCount != callee.Function.Arity {
+			return vm.arityError(callee.Function, argCount)
+		}
+		return vm.call(callee, argCount)
+	case loxvalue.LoxCallable:
+		if argCount != callee.Arity() {
+			return vm.runtimeError(loxerror.RUNTIME_ERROR_ARITY, callee.Arity(), argCount)
+		}
+		arguments := make([]loxvalue.LoxValue, argCount)
+		copy(arguments, vm.stack[vm.stackTop-argCount:vm.stackTop])
+		result, err := callee.Call(arguments)
+		if err != nil {
+			return vm.runtimeError("%s", err.Error())
+		}
+		vm.stackTop -= argCount + 1
+		vm.push(result)
+		return nil
+	}
+	return vm.runtimeError(loxerror.RUNTIME_ERROR_NOT_CALLABLE)
+}
+
+func (vm *VM) invokeFromClass(class *Class, name string, argCount int) error {
+	method, ok := class.Methods[name]
+	if !ok {
+		return vm.runtimeError(loxerror.RUNTIME_ERROR_UNDEFINED_PROPERTY, name)
+	}
+	if argCount != method.Function.Arity {
+		return vm.arityError(method.Function, argCount)
+	}
+	return vm.call(method, argCount)
+}
+
+func (vm *VM) invoke(name string, argCount int) error {
+	instance, ok := vm.peek(argCount).(*Instance)
+	if !ok {
+		return vm.runtimeError(loxerror.RUNTIME_ERROR_INSTANCE_PROPERTIES)
+	}
+	if value, ok := instance.Fields[name]; ok {
+		vm.stack[vm.stackTop-argCount-1] = value
+		return vm.callValue(value, argCount)
+	}
+	return vm.invokeFromClass(instance.Class, name, argCount)
+}
+
+func (vm *VM) bindMethod(class *Class, name string) error {
+	method, ok := class.Methods[name]
+	if !ok {
+		return vm.runtimeError(loxerror.RUNTIME_ERROR_UNDEFINED_PROPERTY, name)
+	}
+	bound := &BoundMethod{Receiver: vm.peek(0), Method: method}
+	vm.pop()
+	vm.push(bound)
+	return nil
+}
+
+// captureUpvalue returns the open upvalue for slot, creating it if no
+// closure has captured that slot yet. The open list is sorted by slot,
+// highest first.
+func (vm *VM) captureUpvalue(slot int) *Upvalue {
+	var previous *Upvalue
+	upvalue := vm.openUpvalues
+	for upvalue != nil && upvalue.slot > slot {
+		previous = upvalue
+		upvalue = upvalue.next
+	}
+	if upvalue != nil && upvalue.slot == slot {
+		return upvalue
+	}
+
+	created := &Upvalue{location: &vm.stack[slot], slot: slot, next: upvalue}
+	if previous == nil {
+		vm.openUpvalues = created
+	} else {
+		previous.next = created
+	}
+	return created
+}
+
+// closeUpvalues moves every captured variable at or above slot off the stack.
+func (vm *VM) closeUpvalues(slot int) {
+	for vm.openUpvalues != nil && vm.openUpvalues.slot >= slot {
+		upvalue := vm.openUpvalues
+		upvalue.closed = *upvalue.location
+		upvalue.location = &upvalue.closed
+		vm.openUpvalues = upvalue.next
+	}
+}
+
+func (vm *VM) defineMethod(name string) {
+	method := vm.peek(0).(*Closure)
+	class := vm.peek(1).(*Class)
+	class.Methods[name] = method
+	vm.pop()
+}
+
+func (vm *VM) numberOperands() (*loxvalue.Number, *loxvalue.Number, error) {
+	right, rightOk := vm.peek(0).(*loxvalue.Number)
+	left, leftOk := vm.peek(1).(*loxvalue.Number)
+	if !leftOk || !rightOk {
+		return nil, nil, vm.runtimeError(loxerror.RUNTIME_ERROR_OPERANDS_NUMBERS)
+	}
+	vm.stackTop -= 2
+	return left, right, nil
+}
+
+// bitwise applies a binary bitwise or shift instruction to the two integers
+// on top of the stack.
+func (vm *VM) bitwise(op bytecode.OpCode) error {
+	right, rightOk := vm.peek(0).(*loxvalue.Number)
+	left, leftOk := vm.peek(1).(*loxvalue.Number)
+	if !leftOk || !rightOk || !left.IsInteger() || !right.IsInteger() {
+		return vm.runtimeError(loxerror.RUNTIME_ERROR_OPERANDS_INTEGERS)
+	}
+	if (op == bytecode.OP_SHIFT_LEFT || op == bytecode.OP_SHIFT_RIGHT) && right.Value < 0 {
+		return vm.runtimeError(loxerror.RUNTIME_ERROR_NEGATIVE_SHIFT)
+	}
+	vm.stackTop -= 2
+	switch op {
+	case bytecode.OP_BIT_AND:
+		vm.push(left.BitAnd(right))
+	case bytecode.OP_BIT_OR:
+		vm.push(left.BitOr(right))
+	case bytecode.OP_BIT_XOR:
+		vm.push(left.BitXor(right))
+	case bytecode.OP_SHIFT_LEFT:
+		vm.push(left.ShiftLeft(right))
+	case bytecode.OP_SHIFT_RIGHT:
+		vm.push(left.ShiftRight(right))
+	}
+	return nil
+}
+
+func boolValue(value bool) loxvalue.LoxValue {
+	if value {
+		return trueValue
+	}
+	return falseValue
+}
+
+// valuesEqual matches loxvalue.IsEqual without going through reflection.
+func valuesEqual(a loxvalue.LoxValue, b loxvalue.LoxValue) bool {
+	switch a := a.(type) {
+	case *loxvalue.Number:
+		b, ok := b.(*loxvalue.Number)
+		return ok && a.Value == b.Value
+	case *loxvalue.String:
+		b, ok := b.(*loxvalue.String)
+		return ok && a.Value == b.Value
+	case *loxvalue.Boolean:
+		b, ok := b.(*loxvalue.Boolean)
+		return ok && a.Value == b.Value
+	case *loxvalue.Nil:
+		_, ok := b.(*loxvalue.Nil)
+		return ok
+	}
+	return a == b
+}
+
+// run executes until the script returns or an error escapes every try
+// block.
+func (vm *VM) run() error {
+	for {
+		err := vm.execute()
+		if err == nil || !vm.catch(err) {
+			return err
+		}
+	}
+}
+
+func (vm *VM) execute() error {
+	frame := &vm.frames[vm.frameCount-1]
+
+	for {
+		op := bytecode.OpCode(frame.readByte())
+		switch op {
+		case bytecode.OP_CONSTANT:
+			vm.push(frame.readConstant())
+		case bytecode.OP_NIL:
+			vm.push(nilValue)
+		case bytecode.OP_TRUE:
+			vm.push(trueValue)
+		case bytecode.OP_FALSE:
+			vm.push(falseValue)
+		case bytecode.OP_POP:
+			vm.pop()
+		case bytecode.OP_DUP:
+			vm.push(vm.peek(0))
+		case bytecode.OP_SWAP:
+			top := vm.stackTop - 1
+			vm.stack[top], vm.stack[top-1] = vm.stack[top-1], vm.stack[top]
+		case bytecode.OP_GET_LOCAL:
+			slot := int(frame.readByte())
+			vm.push(vm.stack[frame.slots+slot])
+		case bytecode.OP_SET_LOCAL:
+			slot := int(frame.readByte())
+			vm.stack[frame.slots+slot] = vm.peek(0)
+		case bytecode.OP_GET_GLOBAL:
+			name := frame.readString()
+			value, ok := vm.globals[name]
+			if !ok {
+				return vm.undefinedVariable(name)
+			}
+			vm.push(value)
+		case bytecode.OP_DEFINE_GLOBAL:
+			name := frame.readString()
+			vm.globals[name] = vm.peek(0)
+			vm.pop()
+		case bytecode.OP_SET_GLOBAL:
+			name := frame.readString()
+			if _, ok := vm.globals[name]; !ok {
+				return vm.undefinedVariable(name)
+			}
+			vm.globals[name] = vm.peek(0)
+		case bytecode.OP_GET_UPVALUE:
+			slot := frame.readByte()
+			vm.push(*frame.closure.Upvalues[slot].location)
+		case bytecode.OP_SET_UPVALUE:
+			slot := frame.readByte()
+			*frame.closure.Upvalues[slot].location = vm.peek(0)
+		case bytecode.OP_GET_PROPERTY:
+			name := frame.readString()
+			if caught, ok := vm.peek(0).(*loxvalue.Error); ok {
+				value, ok := caught.Get(name)
+				if !ok {
+					return vm.runtimeError(loxerror.RUNTIME_ERROR_UNDEFINED_PROPERTY, name)
+				}
+				vm.pop()
+				vm.push(value)
+				break
+			}
+			instance, ok := vm.peek(0).(*Instance)
+			if !ok {
+				return vm.runtimeError(loxerror.RUNTIME_ERROR_INSTANCE_PROPERTIES)
+			}
+			if value, ok := instance.Fields[name]; ok {
+				vm.pop()
+				vm.push(value)
+				break
+			}
+			if err := vm.bindMethod(instance.Class, name); err != nil {
+				return err
+			}
+		case bytecode.OP_SET_PROPERTY:
+			name := frame.readString()
+			instance, ok := vm.peek(1).(*Instance)
+			if !ok {
+				return vm.runtimeError(loxerror.RUNTIME_ERROR_INSTANCE_FIELDS)
+			}
+			instance.Fields[name] = vm.peek(0)
+			value := vm.pop()
+			vm.pop()
+			vm.push(value)
+		case bytecode.OP_GET_SUPER:
+			name := frame.readString()
+			superclass := vm.pop().(*Class)
+			if err := vm.bindMethod(superclass, name); err != nil {
+				return err
+			}
+		case bytecode.OP_EQUAL:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(boolValue(valuesEqual(a, b)))
+		case bytecode.OP_GREATER:
+			left, right, err := vm.numberOperands()
+			if err != nil {
+				return err
+			}
+			vm.push(boolValue(left.Value > right.Value))
+		case bytecode.OP_LESS:
+			left, right, err := vm.numberOperands()
+			if err != nil {
+				return err
+			}
+			vm.push(boolValue(left.Value < right.Value))
+		case bytecode.OP_ADD:
+			rightStr, rightIsStr := vm.peek(0).(*loxvalue.String)
+			leftStr, leftIsStr := vm.peek(1).(*loxvalue.String)
+			if leftIsStr && rightIsStr {
+				vm.stackTop -= 2
+				vm.push(leftStr.Concat(rightStr))
+				break
+			}
+			rightNum, rightIsNum := vm.peek(0).(*loxvalue.Number)
+			leftNum, leftIsNum := vm.peek(1).(*loxvalue.Number)
+			if leftIsNum && rightIsNum {
+				vm.stackTop -= 2
+				vm.push(leftNum.Add(rightNum))
+				break
+			}
+			return vm.runtimeError(loxerror.RUNTIME_ERROR_OPERANDS_NUMBERS_OR_STRINGS)
+		case bytecode.OP_SUBTRACT:
+			left, right, err := vm.numberOperands()
+			if err != nil {
+				return err
+			}
+			vm.push(left.Subtract(right))
+		case bytecode.OP_MULTIPLY:
+			left, right, err := vm.numberOperands()
+			if err != nil {
+				return err
+			}
+			vm.push(left.Multiply(right))
+		case bytecode.OP_DIVIDE:
+			left, right, err := vm.numberOperands()
+			if err != nil {
+				return err
+			}
+			vm.push(left.Divide(right))
+		case bytecode.OP_FLOOR_DIVIDE, bytecode.OP_MODULO:
+			left, right, err := vm.numberOperands()
+			if err != nil {
+				return err
+			}
+			if right.Value == 0 {
+				return vm.runtimeError(loxerror.RUNTIME_ERROR_DIVISION_BY_ZERO)
+			}
+			if op == bytecode.OP_MODULO {
+				vm.push(left.Modulo(right))
+			} else {
+				vm.push(left.FloorDivide(right))
+			}
+		case bytecode.OP_POWER:
+			left, right, err := vm.numberOperands()
+			if err != nil {
+				return err
+			}
+			vm.push(left.Power(right))
+		case bytecode.OP_BIT_AND, bytecode.OP_BIT_OR, bytecode.OP_BIT_XOR, bytecode.OP_SHIFT_LEFT, bytecode.OP_SHIFT_RIGHT:
+			if err := vm.bitwise(op); err != nil {
+				return err
+			}
+		case bytecode.OP_BIT_NOT:
+			number, ok := vm.peek(0).(*loxvalue.Number)
+			if !ok || !number.IsInteger() {
+				return vm.runtimeError(loxerror.RUNTIME_ERROR_OPERAND_INTEGER)
+			}
+			vm.pop()
+			vm.push(number.BitNot())
+		case bytecode.OP_NOT:
+			vm.push(boolValue(!loxvalue.IsTruthy(vm.pop())))
+		case bytecode.OP_INCREMENT, bytecode.OP_DECREMENT:
+			number, ok := vm.peek(0).(*loxvalue.Number)
+			if !ok {
+				return vm.runtimeError(loxerror.RUNTIME_ERROR_OPERAND_NUMBER)
+			}
+			vm.pop()
+			if op == bytecode.OP_INCREMENT {
+				vm.push(number.Add(&loxvalue.Number{Value: 1}))
+			} else {
+				vm.push(number.Subtract(&loxvalue.Number{Value: 1}))
+			}
+		case bytecode.OP_NEGATE:
+			number, ok := vm.peek(0).(*loxvalue.Number)
+			if !ok {
+				return vm.runtimeError(loxerror.RUNTIME_ERROR_OPERAND_NUMBER)
+			}
+			vm.pop()
+			vm.push(number.Minus())
+		case bytecode.OP_PRINT:
+			fmt.Fprintln(vm.out, vm.pop().ToString())
+		case bytecode.OP_JUMP:
+			offset := frame.readShort()
+			frame.ip += offset
+		case bytecode.OP_JUMP_IF_FALSE:
+			offset := frame.readShort()
+			if !loxvalue.IsTruthy(vm.peek(0)) {
+				frame.ip += offset
+			}
+		case bytecode.OP_JUMP_IF_NIL:
+			offset := frame.readShort()
+			if vm.peek(0).Type() == loxvalue.NIL {
+				frame.ip += offset
+			}
+		case bytecode.OP_LOOP:
+			offset := frame.readShort()
+			frame.ip -= offset
+		case bytecode.OP_CALL:
+			argCount := int(frame.readByte())
+			if err := vm.callValue(vm.peek(argCount), argCount); err != nil {
+				return err
+			}
+			frame = &vm.frames[vm.frameCount-1]
+		case bytecode.OP_INVOKE:
+			name := frame.readString()
+			argCount := int(frame.readByte())
+			if err := vm.invoke(name, argCount); err != nil {
+				return err
+			}
+			frame = &vm.frames[vm.frameCount-1]
+		case bytecode.OP_SUPER_INVOKE:
+			name := frame.readString()
+			argCount := int(frame.readByte())
+			superclass := vm.pop().(*Class)
+			if err := vm.invokeFromClass(superclass, name, argCount); err != nil {
+				return err
+			}
+			frame = &vm.frames[vm.frameCount-1]
+		case bytecode.OP_CLOSURE:
+			function := frame.readConstant().(*bytecode.Function)
+			closure := NewClosure(function)
+			vm.push(closure)
+			for i := range closure.Upvalues {
+				isLocal := frame.readByte()
+				index := int(frame.readByte())
+				if isLocal == 1 {
+					closure.Upvalues[i] = vm.captureUpvalue(frame.slots + index)
+				} else {
+					closure.Upvalues[i] = frame.closure.Upvalues[index]
+				}
+			}
+		case bytecode.OP_CLOSE_UPVALUE:
+			vm.closeUpvalues(vm.stackTop - 1)
+			vm.pop()
+		case bytecode.OP_RETURN:
+			result := vm.pop()
+			vm.closeUpvalues(frame.slots)
+			vm.frameCount--
+			if vm.frameCount == 0 {
+				vm.pop()
+				return nil
+			}
+			vm.stackTop = frame.slots
+			vm.push(result)
+			frame = &vm.frames[vm.frameCount-1]
+		case bytecode.OP_CLASS:
+			vm.push(NewClass(frame.readString()))
+		case bytecode.OP_INHERIT:
+			superclass, ok := vm.peek(1).(*Class)
+			if !ok {
+				return vm.runtimeError(loxerror.RUNTIME_ERROR_SUPERCLASS)
+			}
+			subclass := vm.peek(0).(*Class)
+			for name, method := range superclass.Methods {
+				subclass.Methods[name] = method
+			}
+			vm.pop()
+		case bytecode.OP_METHOD:
+			vm.defineMethod(frame.readString())
+		case bytecode.OP_TRY:
+			vm.pushHandler(frame, false)
+		case bytecode.OP_TRY_FINALLY:
+			vm.pushHandler(frame, true)
+		case bytecode.OP_END_TRY:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		case bytecode.OP_THROW:
+			return vm.throw(vm.pop())
+		case bytecode.OP_INTERPOLATE:
+			count := int(frame.readByte())
+			var text strings.Builder
+			for _, value := range vm.stack[vm.stackTop-count : vm.stackTop] {
+				text.WriteString(value.ToString())
+			}
+			vm.stackTop -= count
+			vm.push(loxvalue.NewString(text.String()))
+		}
+	}
+}
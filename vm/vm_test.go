@@ -0,0 +1,353 @@
+package vm_test
+
+import (
+	"bytes"
+	"golox/compiler"
+	loxerror "golox/error"
+	"golox/parser"
+	"golox/resolver"
+	"golox/scanner"
+	tkn "golox/token"
+	loxvalue "golox/value"
+	"golox/vm"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVM_Expressions(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"print 1 + 2 * 3;", "7\n"},
+		{"print (1 + 2) * 3;", "9\n"},
+		{"print 10 / 4 - 1;", "1.5\n"},
+		{"print -(1 + 1);", "-2\n"},
+		{"print \"foo\" + \"bar\";", "foobar\n"},
+		{"print !nil;", "true\n"},
+		{"print 1 < 2; print 1 <= 1; print 1 > 2; print 1 >= 2;", "true\ntrue\nfalse\nfalse\n"},
+		{"print 1 == 1; print 1 != 1; print \"a\" == \"a\"; print nil == false;", "true\nfalse\ntrue\nfalse\n"},
+		{"print nil or \"default\"; print 1 and 2; print false and 1;", "default\n2\nfalse\n"},
+		{"print 7 ~/ 2; print -7 ~/ 2; print 7.5 ~/ 2;", "3\n-4\n3\n"},
+		{"print 7 % 3; print -7 % 3; print 7 % -3; print 5.5 % 2;", "1\n2\n-2\n1.5\n"},
+		{"print 2 ** 10; print 2 ** 3 ** 2; print -2 ** 2; print 2 ** -1;", "1024\n512\n-4\n0.5\n"},
+		{"print 6 & 3; print 6 | 3; print 6 ^ 3; print ~5;", "2\n7\n5\n-6\n"},
+		{"print 1 << 10; print -16 >> 2; print 1 + 2 << 1; print 5 & 1 == 1;", "1024\n-4\n6\ntrue\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestVM_Interpolation(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var name = \"Ann\"; var n = 2; print \"Hello ${name}, you have ${n * 2} items\";", "Hello Ann, you have 4 items\n"},
+		{"print \"${nil} ${true} ${1.5}\";", "nil true 1.5\n"},
+		{"print \"a${\"b${1 + 1}c\"}d\";", "ab2cd\n"},
+		{"fun f() {} class A {} print \"${f} ${A} ${A()}\";", "<fn f> A A instance\n"},
+		{"print \"" + strings.Repeat("${1}-", 300) + "\";", strings.Repeat("1-", 300) + "\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestVM_NilOperators(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"class A { init() { this.b = nil; } m(x) { return x * 2; } } var a = A(); var z; print z?.b; print z?.b.c(1).d; print a?.m(2); print a.b?.c;", "nil\nnil\n4\nnil\n"},
+		{"var f; fun g(x) { return x + 1; } print f?.(1); print g?.(1);", "nil\n2\n"},
+		{"var calls = 0; fun arg() { calls = calls + 1; } var z; z?.m(arg()); print calls;", "0\n"},
+		{"print nil ?? 1; print false ?? 1; print 0 ?? 1;", "1\nfalse\n0\n"},
+		{"print 1 < 2 ? \"yes\" : \"no\"; print nil ? 1 : false ? 2 : 3;", "yes\n3\n"},
+		{"var a; print a ?? false ? 1 : 2; a = true ? 3 : 4; print a;", "2\n3\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestVM_UpdateOperators(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var i = 0; print i++; print i; print ++i; print i--; print --i;", "0\n1\n2\n2\n0\n"},
+		{"var i = 1; i += 10; print i; i -= 3; print i; i *= 4; print i; i /= 8; print i; i %= 3; print i;", "11\n8\n32\n4\n1\n"},
+		{"var s = \"a\"; s += \"b\"; print s; print s += \"c\";", "ab\nabc\n"},
+		{"class C {} var c = C(); c.n = 1; var calls = 0; fun get() { calls += 1; return c; } print get().n++; print ++get().n; get().n *= 5; print c.n; print calls;", "1\n3\n15\n3\n"},
+		{"fun f() { var x = 1; fun g() { x += 1; return x++; } return g; } var g = f(); print g(); print g();", "2\n4\n"},
+		{"{ var l = 5; l++; ++l; print l; print - -l; print - --l; }", "7\n7\n-6\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestVM_Statements(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var a = 1; a = a + 1; print a;", "2\n"},
+		{"var a; print a;", "nil\n"},
+		{"var a = 1; { var a = 2; { var b = a + 1; print b; } print a; } print a;", "3\n2\n1\n"},
+		{"if (1 < 2) print \"then\"; else print \"else\";", "then\n"},
+		{"if (nil) print \"then\"; else print \"else\";", "else\n"},
+		{"var i = 0; while (i < 3) { print i; i = i + 1; }", "0\n1\n2\n"},
+		{"for (var i = 0; i < 2; i = i + 1) print i;", "0\n1\n"},
+		{"for (var i = 0; i < 5; i++) { if (i == 1) continue; if (i == 3) break; print i; }", "0\n2\n"},
+		{"var i = 0; while (true) { i++; if (i < 3) continue; print i; break; }", "3\n"},
+		{"outer: for (var i = 0; i < 3; i++) for (var j = 0; j < 3; j++) { if (j == 1) continue outer; if (i == 2) break outer; print \"${i}${j}\"; }", "00\n10\n"},
+		{"var fs = \"\"; for (var i = 0; i < 3; i++) { var x = i; fun f() { return x; } if (i == 1) continue; fs = fs + \"${f()}\"; } print fs;", "02\n"},
+		{"var g; for (var i = 0; i < 3; i++) { var x = i; fun f() { return x; } g = f; if (i == 1) break; } print g();", "1\n"},
+		{"while (true) { try { break; } finally { print \"finally\"; } } print \"after\";", "finally\nafter\n"},
+		{"for (var i = 0; i < 2; i++) { try { continue; } catch (e) { print \"caught\"; } finally { print i; } }", "0\n1\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestVM_Functions(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fun f() {} print f(); print f;", "nil\n<fn f>\n"},
+		{"fun add(a, b) { return a + b; } print add(1, 2);", "3\n"},
+		{"fun fib(n) { if (n < 2) return n; return fib(n - 1) + fib(n - 2); } print fib(10);", "55\n"},
+		{"{ fun local(n) { if (n == 0) return \"done\"; return local(n - 1); } print local(3); }", "done\n"},
+		{"fun makeCounter() { var i = 0; fun count() { i = i + 1; return i; } return count; } var c = makeCounter(); c(); print c();", "2\n"},
+		{"var a = \"global\"; { fun show() { print a; } show(); var a = \"block\"; show(); }", "global\nglobal\n"},
+		{`var fs = nil;
+		for (var i = 0; i < 2; i = i + 1) { var j = i; fun f() { return j; } if (fs == nil) fs = f; }
+		print fs();`, "0\n"},
+		{`fun outer() { var x = "outer"; fun middle() { fun inner() { return x; } return inner; } return middle()(); }
+		print outer();`, "outer\n"},
+		{"print clock;", "<native fn>\n"},
+		{"var add = fun (a, b) { return a + b; }; print add(1, 2);", "3\n"},
+		{"var mul = (a, b) => a * b; print mul(3, 4); print (() => 42)();", "12\n42\n"},
+		{"print fun () {}; print (x) => x;", "<fn anonymous>\n<fn anonymous>\n"},
+		{"fun (x) { print x; }(5);", "5\n"},
+		{"fun apply(f, v) { return f(v); } print apply((x) => x + 1, 10);", "11\n"},
+		{"fun counter() { var n = 0; return () => ++n; } var c = counter(); c(); print c();", "2\n"},
+		{"var curry = (a) => (b) => a + b; print curry(1)(2);", "3\n"},
+		{"class A { init() { this.v = 7; } get() { return () => this.v; } } print A().get()();", "7\n"},
+		{"var a = 1; print (a); print (a) + 1;", "1\n2\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestVM_Classes(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"class Foo {} print Foo; print Foo();", "Foo\nFoo instance\n"},
+		{"class Foo {} var foo = Foo(); foo.bar = 1; print foo.bar;", "1\n"},
+		{"class Foo { bar() { return this.baz; } } var foo = Foo(); foo.baz = 2; print foo.bar();", "2\n"},
+		{"class Foo { bar() { return this; } } var m = Foo().bar; print m();", "Foo instance\n"},
+		{"class Point { init(x, y) { this.x = x; this.y = y; } } var p = Point(1, 2); print p.x + p.y;", "3\n"},
+		{"class Foo { init() { return; } } var foo = Foo(); print foo.init();", "Foo instance\n"},
+		{"class Foo {} var foo = Foo(); fun f() { return 3; } foo.f = f; print foo.f();", "3\n"},
+		{`class A { method() { return "A method"; } }
+		class B < A { method() { return "B method"; } test() { return super.method(); } get() { return super.method; } }
+		class C < B {}
+		print C().test(); print C().get()();`, "A method\nA method\n"},
+		{"class A { init(n) { this.n = n; } } class B < A { init() { super.init(7); } } print B().n;", "7\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestVM_RuntimeErrors(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected *loxerror.Error
+	}{
+		{"-\"foo\";", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_OPERAND_NUMBER, Code: "LOX0503", Span: span(1, 1, 0, 1)}},
+		{"1 < \"foo\";", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_OPERANDS_NUMBERS, Code: "LOX0504", Span: span(1, 3, 2, 1)}},
+		{"1 + nil;", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_OPERANDS_NUMBERS_OR_STRINGS, Code: "LOX0505", Span: span(1, 3, 2, 1)}},
+		{"print a;", &loxerror.Error{Line: 1, Message: "Undefined variable 'a'.", Code: "LOX0501", Span: span(1, 7, 6, 1), Hint: "Declare it with 'var a' first."}},
+		{"a = 1;", &loxerror.Error{Line: 1, Message: "Undefined variable 'a'.", Code: "LOX0501", Span: span(1, 1, 0, 1), Hint: "Declare it with 'var a' first."}},
+		{"\"foo\"();", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_NOT_CALLABLE, Code: "LOX0506", Span: span(1, 7, 6, 1)}},
+		{"fun f(a) {}\nf();", &loxerror.Error{Line: 2, Message: "Expected 1 arguments but got 0.", Code: "LOX0507", Span: span(2, 3, 14, 1),
+			Notes: []loxerror.Note{{Message: "'f' is declared here.", Span: span(1, 5, 4, 1)}}}},
+		{"var f = (a) => a; f();", &loxerror.Error{Line: 1, Message: "Expected 1 arguments but got 0.", Code: "LOX0507", Span: span(1, 21, 20, 1),
+			Notes: []loxerror.Note{{Message: "The function is declared here.", Span: span(1, 13, 12, 2)}}}},
+		{"class Foo {} Foo(1);", &loxerror.Error{Line: 1, Message: "Expected 0 arguments but got 1.", Code: "LOX0507", Span: span(1, 19, 18, 1)}},
+		{"var a = 1; a.b;", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_INSTANCE_PROPERTIES, Code: "LOX0508", Span: span(1, 14, 13, 1)}},
+		{"var a = 1; a.b = 2;", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_INSTANCE_FIELDS, Code: "LOX0509", Span: span(1, 14, 13, 1)}},
+		{"class Foo {} Foo().bar();", &loxerror.Error{Line: 1, Message: "Undefined property 'bar'.", Code: "LOX0502", Span: span(1, 20, 19, 3)}},
+		{"var A = 1; class B < A {}", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_SUPERCLASS, Code: "LOX0510", Span: span(1, 22, 21, 1)}},
+		{"1 ~/ 0;", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_DIVISION_BY_ZERO, Code: "LOX0515", Span: span(1, 3, 2, 2)}},
+		{"1 % 0;", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_DIVISION_BY_ZERO, Code: "LOX0515", Span: span(1, 3, 2, 1)}},
+		{"\"a\" ** 2;", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_OPERANDS_NUMBERS, Code: "LOX0504", Span: span(1, 5, 4, 2)}},
+		{"1.5 & 1;", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_OPERANDS_INTEGERS, Code: "LOX0514", Span: span(1, 5, 4, 1)}},
+		{"~nil;", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_OPERAND_INTEGER, Code: "LOX0513", Span: span(1, 1, 0, 1)}},
+		{"1 << -1;", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_NEGATIVE_SHIFT, Code: "LOX0516", Span: span(1, 3, 2, 2)}},
+		{"var s = \"a\"; s++;", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_OPERAND_NUMBER, Code: "LOX0503", Span: span(1, 15, 14, 2)}},
+		{"var s = \"a\"; --s;", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_OPERAND_NUMBER, Code: "LOX0503", Span: span(1, 14, 13, 2)}},
+		{"var n = 1; n %= 0;", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_DIVISION_BY_ZERO, Code: "LOX0515", Span: span(1, 14, 13, 2)}},
+		{"var a; a.b += 1;", &loxerror.Error{Line: 1, Message: loxerror.RUNTIME_ERROR_INSTANCE_PROPERTIES, Code: "LOX0508", Span: span(1, 10, 9, 1)}},
+	}
+
+	for _, test := range tests {
+		_, err := run(t, vm.NewVM(), test.input)
+		require.Equal(t, test.expected, err, test.input)
+	}
+
+	_, err := run(t, vm.NewVM(), "fun f() { f(); } f();")
+	require.Equal(t, loxerror.RUNTIME_ERROR_STACK_OVERFLOW, err.(*loxerror.Error).Message)
+	require.Len(t, err.(*loxerror.Error).Trace, vm.FRAMES_MAX)
+
+}
+
+func TestVM_StackTrace(t *testing.T) {
+
+	input := `fun inner(a) {
+  return a + 1;
+}
+fun outer() {
+  return inner("x");
+}
+class A {
+  init() {
+    outer();
+  }
+}
+A();`
+	_, err := run(t, vm.NewVM(), input)
+	require.Equal(t, &loxerror.Error{Line: 2, Message: loxerror.RUNTIME_ERROR_OPERANDS_NUMBERS_OR_STRINGS, Code: "LOX0505", Span: span(2, 12, 26, 1), Trace: []loxerror.Frame{
+		{Function: "inner", Line: 2},
+		{Function: "outer", Line: 5},
+		{Function: "init", Line: 9},
+		{Function: "script", Line: 12},
+	}}, err)
+
+}
+
+func TestVM_Exceptions(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"try { throw \"boom\"; } catch (e) { print e; }", "boom\n"},
+		{"try { print 1; } catch (e) { print e; } print 2;", "1\n2\n"},
+		{"try { print a; } catch (e) { print e.message; }", "Undefined variable 'a'.\n"},
+		{"try { 1 + nil; } catch (e) { print e.message; print e.stack; }", "Operands must be two numbers or two strings.\n\n"},
+		{"fun f() { return -\"x\"; }\ntry { f(); } catch (e) { print e.stack; }", "at f (line 1)\nat script (line 2)\n"},
+		{"try { print 1; } finally { print \"finally\"; }", "1\nfinally\n"},
+		{"var a = 0; try { a = 1; } catch (e) { a = 2; } finally { a = a + 10; } print a;", "11\n"},
+		{"try { try { throw 1; } finally { print \"inner\"; } } catch (e) { print e + 1; }", "inner\n2\n"},
+		{"try { try { throw 1; } catch (e) { throw e + 1; } } catch (e) { print e; }", "2\n"},
+		{"fun f() { try { return 1; } finally { print \"finally\"; } } print f();", "finally\n1\n"},
+		{"fun f() { try { try { return 1; } finally { print 2; } } finally { print 3; } } print f();", "2\n3\n1\n"},
+		{"fun f() { try { return 1; } finally { return 2; } } print f();", "2\n"},
+		{"fun f() { var g; try { var a = \"captured\"; fun h() { return a; } g = h; throw nil; } catch (e) {} return g; } print f()();", "captured\n"},
+		{"class A { init() { throw this; } } try { A(); } catch (e) { print e; }", "A instance\n"},
+	}
+
+	for _, test := range tests {
+		testOutput(t, test.input, test.expected)
+	}
+
+}
+
+func TestVM_UncaughtException(t *testing.T) {
+
+	_, err := run(t, vm.NewVM(), "fun f() {\n  throw \"boom\";\n}\nf();")
+	require.Equal(t, &loxerror.Error{Line: 2, Message: "Uncaught exception: boom.", Code: "LOX0512", Span: span(2, 3, 12, 5), Thrown: loxvalue.NewString("boom"), Trace: []loxerror.Frame{
+		{Function: "f", Line: 2},
+		{Function: "script", Line: 4},
+	}}, err)
+
+	_, err = run(t, vm.NewVM(), "fun f() { print a; }\ntry { f(); } catch (e) { throw e; }")
+	require.Equal(t, &loxerror.Error{Line: 1, Message: "Undefined variable 'a'.", Code: "LOX0501", Span: span(1, 17, 16, 1), Hint: "Declare it with 'var a' first.", Trace: []loxerror.Frame{
+		{Function: "f", Line: 1},
+		{Function: "script", Line: 2},
+	}}, err)
+
+}
+
+func TestVM_GlobalsPersistAcrossRuns(t *testing.T) {
+
+	machine := vm.NewVM()
+	_, err := run(t, machine, "var a = 1; fun f() { return a + 1; }")
+	require.NoError(t, err)
+	out, err := run(t, machine, "print f();")
+	require.NoError(t, err)
+	require.Equal(t, "2\n", out)
+
+}
+
+func run(t *testing.T, machine *vm.VM, input string) (string, error) {
+
+	scanner := scanner.NewScanner(input)
+	tokens, errors := scanner.Scan()
+	require.Empty(t, errors)
+	parser := parser.NewParser(tokens)
+	statements, errors := parser.Parse()
+	require.Empty(t, errors)
+	_, errors = resolver.NewResolver().Resolve(statements)
+	require.Empty(t, errors)
+	function, errors := compiler.NewCompiler().Compile(statements)
+	require.Empty(t, errors)
+
+	out := &bytes.Buffer{}
+	machine.SetOutput(out)
+	err := machine.Interpret(function)
+	return out.String(), err
+
+}
+
+func testOutput(t *testing.T, input string, expected string) {
+
+	out, err := run(t, vm.NewVM(), input)
+	require.NoError(t, err, input)
+	require.Equal(t, expected, out, input)
+
+}
+
+// span returns the span of length bytes starting at column on line, offset
+// bytes into the input.
+func span(line, column, offset, length int) tkn.Span {
+	return tkn.Span{
+		Start: tkn.Position{Line: line, Column: column, Offset: offset},
+		End:   tkn.Position{Line: line, Column: column + length, Offset: offset + length},
+	}
+}